@@ -7,18 +7,26 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/internal/admin"
 	"github.com/qdm12/dns/internal/config"
 	"github.com/qdm12/dns/internal/health"
 	"github.com/qdm12/dns/internal/models"
 	"github.com/qdm12/dns/internal/splash"
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/check"
+	"github.com/qdm12/dns/pkg/hosts"
+	"github.com/qdm12/dns/pkg/metrics"
 	"github.com/qdm12/dns/pkg/nameserver"
+	"github.com/qdm12/dns/pkg/querylog"
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/command"
 	"github.com/qdm12/golibs/logging"
@@ -86,10 +94,18 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 		client := health.NewClient()
 		return client.Query(ctx)
 	}
-	fmt.Println(splash.Splash(buildInfo))
-
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	if admin.IsBlockingCommand(args) {
+		// Running the program in a separate instance to pause or
+		// resume blocking on the long running instance of the program
+		adminSettings, err := configReader.ReadAdminSettings()
+		if err != nil {
+			return err
+		} else if !adminSettings.Enabled() {
+			return admin.ErrDisabled
+		}
+		client := admin.NewClient(adminSettings)
+		return admin.RunBlockingCommand(ctx, args, client, os.Stdout)
+	}
 
 	const clientTimeout = 15 * time.Second
 	client := &http.Client{Timeout: clientTimeout}
@@ -98,9 +114,22 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	cmder := command.NewCmder()
 	const unboundEtcDir = "/unbound"
 	const unboundPath = "/unbound/unbound"
+	const unboundControlPath = "/unbound/unbound-control"
 	const cacertsPath = "/unbound/ca-certificates.crt"
 	dnsConf := unbound.NewConfigurator(logger, cmder, dnsCrypto,
-		unboundEtcDir, unboundPath, cacertsPath)
+		unboundEtcDir, unboundPath, unboundControlPath, cacertsPath)
+
+	if config.IsConfigCommand(args) {
+		// Running the program in a separate instance to validate or
+		// print the configuration, without writing files or starting
+		// anything
+		return config.RunConfigCommand(args, configReader.ReadSettings,
+			dnsConf, os.Stdout)
+	}
+	fmt.Println(splash.Splash(buildInfo))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	if len(args) > 1 && args[1] == "build" {
 		return dnsConf.SetupFiles(ctx)
@@ -112,12 +141,24 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	}
 	logger.Info("Unbound version: " + version)
 
-	settings, err := configReader.ReadSettings()
+	settings, err := configReader.ReadSettings(args[1:])
 	if err != nil {
 		return err
 	}
 	logger.Info("Settings summary:\n" + settings.String())
 
+	queryLog, err := querylog.New(settings.QueryLog)
+	if err != nil {
+		return err
+	}
+	if queryLog != nil {
+		defer func() {
+			if err := queryLog.Close(); err != nil {
+				logger.Warn("cannot close query log: " + err.Error())
+			}
+		}()
+	}
+
 	wg := &sync.WaitGroup{}
 	defer wg.Wait()
 	crashed := make(chan error)
@@ -129,11 +170,52 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	wg.Add(1)
 	go healthServer.Run(ctx, wg)
 
+	dnsMetrics := metrics.NewNoop()
+	if settings.MetricsAddress != "" {
+		dnsMetrics = metrics.New()
+		dnsMetrics.CollectUnbound(dnsConf)
+		metricsServer := metrics.NewServer(settings.MetricsAddress,
+			logger.NewChild(logging.Settings{Prefix: "metrics server: "}),
+			dnsMetrics)
+		wg.Add(1)
+		go metricsServer.Run(ctx, wg)
+	}
+
+	// blocking holds the block lists by category, the pauses and the
+	// monitor only categories, and matches the queries Unbound answered.
+	blocking := blacklist.NewPausable(nil)
+
+	events := admin.NewEvents()
+	var state admin.State
+	if settings.Admin.Enabled() {
+		state, err = admin.LoadState(settings.Admin.StateFile)
+		if err != nil {
+			return err
+		}
+		adminHandler := admin.NewHandler(
+			logger.NewChild(logging.Settings{Prefix: "admin: "}),
+			settings.Admin, buildInfo, settings.Lines("   ", " |--"),
+			dnsConf, blocking, events, queryLog, state)
+		adminServer := admin.NewServer(settings.Admin.Address,
+			logger.NewChild(logging.Settings{Prefix: "admin server: "}),
+			adminHandler)
+		wg.Add(1)
+		go adminServer.Run(ctx, wg)
+	}
+
 	localIP := net.IP{127, 0, 0, 1}
 	logger.Info("using DNS address " + localIP.String() + " internally")
 	nameserver.UseDNSInternally(localIP) // use Unbound
 	wg.Add(1)
-	go unboundRunLoop(ctx, wg, settings, logger, dnsConf, client, crashed)
+	go forwardSignals(ctx, wg, logger, events)
+
+	readSettings := func() (config.Settings, error) {
+		return configReader.ReadSettings(args[1:])
+	}
+
+	wg.Add(1)
+	go unboundRunLoop(ctx, wg, settings, readSettings, state, events, logger,
+		queryLog, dnsMetrics, blocking, dnsConf, client, crashed)
 
 	select {
 	case <-ctx.Done():
@@ -144,14 +226,56 @@ func _main(ctx context.Context, buildInfo models.BuildInformation,
 	return err
 }
 
-func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Settings, //nolint:gocognit
-	logger logging.Logger, dnsConf unbound.Configurator, client *http.Client, crashed chan<- error,
+func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, //nolint:gocognit,gocyclo
+	settings config.Settings, readSettings func() (config.Settings, error),
+	state admin.State, events *admin.Events,
+	logger logging.Logger, queryLog querylog.Logger, dnsMetrics metrics.Metrics,
+	blocking blacklist.Pausable, dnsConf unbound.Configurator,
+	client *http.Client, crashed chan<- error,
 ) {
 	defer wg.Done()
 	defer logger.Info("unbound loop exited")
 	timer := time.NewTimer(time.Hour)
 
+	// running are the settings read, before the changes
+	// below, to find the settings changed on reload.
+	running := settings
+
+	var localNamesTicker *time.Ticker
+	var localNamesTickerCh <-chan time.Time
+	defer func() {
+		if localNamesTicker != nil {
+			localNamesTicker.Stop()
+		}
+	}()
+	setLocalNames := func() {
+		if localNamesTicker != nil {
+			localNamesTicker.Stop()
+			localNamesTicker, localNamesTickerCh = nil, nil
+		}
+		settings.Unbound.LocalDomain = ""
+		settings.Unbound.LocalNames = nil
+		if !settings.LocalNames.Enabled() {
+			return
+		}
+		settings.Unbound.LocalDomain = settings.LocalNames.Domain
+		settings.Unbound.LocalNames = readLocalNames(logger, settings.LocalNames)
+		logger.Info(strconv.Itoa(len(settings.Unbound.LocalNames)) + " local names found")
+		if settings.LocalNames.UpdatePeriod > 0 {
+			localNamesTicker = time.NewTicker(settings.LocalNames.UpdatePeriod)
+			localNamesTickerCh = localNamesTicker.C
+		}
+	}
+	setLocalNames()
+
+	baseBlacklist := settings.Blacklist
+	settings.Blacklist = state.Apply(baseBlacklist)
+	var resumeBlockingTimer *time.Timer
+	var resumeBlockingCh <-chan time.Time
+
 	firstRun := true
+	downloadFiles := false
+	buildBlockLists := false
 
 	var (
 		unboundCtx               context.Context
@@ -161,33 +285,62 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 		err                      error
 	)
 
+	const metricsServer = "unbound"
+	monitor := func(match blacklist.Match) {
+		logger.Info("monitor only: " + match.Name + " would be blocked by " +
+			match.Category + " block list rule " + match.Rule)
+		dnsMetrics.MonitoredMatch(metricsServer, match.Category)
+	}
+	err = blocking.SetMonitorOnly(settings.Blacklist.MonitorOnly, monitor)
+	if err != nil {
+		crashed <- err
+		return
+	}
+
 	for ctx.Err() == nil {
-		timer.Stop()
-		if settings.UpdatePeriod > 0 {
-			timer.Reset(settings.UpdatePeriod)
+		if firstRun || downloadFiles {
+			timer.Stop()
+			if settings.UpdatePeriod > 0 {
+				timer.Reset(settings.UpdatePeriod)
+			}
 		}
 
-		if !firstRun {
+		if downloadFiles {
 			logger.Info("downloading DNSSEC root hints and named root")
 			if err := dnsConf.SetupFiles(ctx); err != nil {
 				logAndWait(ctx, logger, err)
 				continue
 			}
+			buildBlockLists = true
+		}
+
+		if buildBlockLists {
 			logger.Info("downloading and building DNS block lists")
 			blacklistBuilder := blacklist.NewBuilder(client)
-			blockedHostnames, blockedIPs, blockedIPPrefixes, errs :=
-				blacklistBuilder.All(ctx, settings.Blacklist)
+			categories, attribution, errs := blacklistBuilder.Categories(ctx, settings.Blacklist)
 			for _, err := range errs {
 				logger.Warn(err.Error())
 			}
-			logger.Info(strconv.Itoa(len(blockedHostnames)) + " hostnames blocked overall")
-			logger.Info(strconv.Itoa(len(blockedIPs)) + " IP addresses blocked overall")
-			logger.Info(strconv.Itoa(len(blockedIPPrefixes)) + " IP networks blocked overall")
-			settings.Unbound.Blacklist = blacklist.Settings{
-				FqdnHostnames: blockedHostnames,
-				IPs:           blockedIPs,
-				IPPrefixes:    blockedIPPrefixes,
-			}
+			blocking.Update(categories, attribution)
+			blockLists := mergeCategories(categories)
+			logger.Info(strconv.Itoa(len(blockLists.FqdnHostnames)) + " hostnames blocked overall")
+			logger.Info(strconv.Itoa(len(blockLists.IPs)) + " IP addresses blocked overall")
+			logger.Info(strconv.Itoa(len(blockLists.IPPrefixes)) + " IP networks blocked overall")
+			dnsMetrics.BlockLists(len(blockLists.FqdnHostnames),
+				len(blockLists.IPs), len(blockLists.IPPrefixes))
+			buildBlockLists = false
+		}
+
+		// Unbound blocks the categories not paused, and is
+		// restarted when the earliest pause expires.
+		settings.Unbound.Blacklist = blocking.Active()
+		if resumeBlockingTimer != nil {
+			resumeBlockingTimer.Stop()
+			resumeBlockingTimer, resumeBlockingCh = nil, nil
+		}
+		if end, paused := earliestEnd(blocking.Paused()); paused {
+			resumeBlockingTimer = time.NewTimer(time.Until(end))
+			resumeBlockingCh = resumeBlockingTimer.C
 		}
 
 		logger.Info("generating Unbound configuration")
@@ -212,7 +365,7 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 			break
 		}
 
-		go logUnboundStreams(logger, stdoutLines, stderrLines)
+		go logUnboundStreams(logger, queryLog, dnsMetrics, blocking, stdoutLines, stderrLines)
 
 		if settings.CheckDNS {
 			if err := check.WaitForDNS(ctx, net.DefaultResolver); err != nil {
@@ -224,30 +377,246 @@ func unboundRunLoop(ctx context.Context, wg *sync.WaitGroup, settings config.Set
 		if firstRun {
 			logger.Info("restarting Unbound the first time to get updated files")
 			firstRun = false
+			downloadFiles = true
 			continue
 		}
 
+	waitLoop:
+		for {
+			select {
+			case <-timer.C:
+				logger.Info("planned restart of unbound")
+				dnsMetrics.UnboundRestart(metrics.RestartPlanned)
+				downloadFiles = true
+				break waitLoop
+			case <-localNamesTickerCh:
+				localNames := readLocalNames(logger, settings.LocalNames)
+				if hosts.Equal(localNames, settings.Unbound.LocalNames) {
+					continue
+				}
+				logger.Info("local names changed, restarting unbound")
+				dnsMetrics.UnboundRestart(metrics.RestartLocalNames)
+				settings.Unbound.LocalNames = localNames
+				downloadFiles = false
+				break waitLoop
+			case <-events.Restart:
+				logger.Info("restarting unbound as requested")
+				dnsMetrics.UnboundRestart(metrics.RestartRequested)
+				downloadFiles = false
+				break waitLoop
+			case <-events.Update:
+				logger.Info("updating files and restarting unbound as requested")
+				dnsMetrics.UnboundRestart(metrics.RestartRequested)
+				downloadFiles = true
+				break waitLoop
+			case state := <-events.State:
+				logger.Info("block lists changed, restarting unbound")
+				dnsMetrics.UnboundRestart(metrics.RestartBlocking)
+				settings.Blacklist = state.Apply(baseBlacklist)
+				downloadFiles = false
+				buildBlockLists = true
+				break waitLoop
+			case pause := <-events.PauseBlocking:
+				if err := blocking.Pause(pause.Duration, pause.Categories...); err != nil {
+					logger.Warn("cannot pause blocking: " + err.Error())
+					continue
+				}
+				categories := "all categories"
+				if len(pause.Categories) > 0 {
+					categories = strings.Join(pause.Categories, ", ")
+				}
+				if pause.Duration > 0 {
+					logger.Info("pausing blocking for " + categories + " during " +
+						pause.Duration.String() + ", restarting unbound")
+				} else {
+					logger.Info("resuming blocking for " + categories + ", restarting unbound")
+				}
+				dnsMetrics.UnboundRestart(metrics.RestartBlocking)
+				downloadFiles = false
+				break waitLoop
+			case <-events.Reload:
+				logger.Info("reloading configuration")
+				reloaded, err := readSettings()
+				if err != nil {
+					logger.Error("keeping the running configuration: " + err.Error())
+					continue
+				}
+
+				for _, name := range notReloadable(running, reloaded) {
+					logger.Warn(name + " settings changed, restart the program to apply them")
+				}
+				settings.CheckDNS = reloaded.CheckDNS
+
+				if reloaded.UpdatePeriod != running.UpdatePeriod {
+					logger.Info("update period changed to " + reloaded.UpdatePeriod.String())
+					settings.UpdatePeriod = reloaded.UpdatePeriod
+					if !timer.Stop() {
+						select { // the timer may be already stopped
+						case <-timer.C:
+						default:
+						}
+					}
+					if settings.UpdatePeriod > 0 {
+						timer.Reset(settings.UpdatePeriod)
+					}
+				}
+
+				restart := false
+				if !reflect.DeepEqual(reloaded.Unbound, running.Unbound) {
+					logger.Info("Unbound settings changed")
+					unboundSettings := reloaded.Unbound
+					unboundSettings.LocalDomain = settings.Unbound.LocalDomain
+					unboundSettings.LocalNames = settings.Unbound.LocalNames
+					settings.Unbound = unboundSettings
+					restart = true
+				}
+
+				if !reflect.DeepEqual(reloaded.LocalNames, running.LocalNames) {
+					logger.Info("local names settings changed")
+					settings.LocalNames = reloaded.LocalNames
+					setLocalNames()
+					restart = true
+				}
+
+				if !reflect.DeepEqual(reloaded.Blacklist, running.Blacklist) {
+					logger.Info("block lists settings changed")
+					if err := blocking.SetMonitorOnly(reloaded.Blacklist.MonitorOnly, monitor); err != nil {
+						logger.Error("keeping the running block lists settings: " + err.Error())
+						reloaded.Blacklist = running.Blacklist
+					} else {
+						baseBlacklist = reloaded.Blacklist
+						settings.Blacklist = state.Apply(baseBlacklist)
+						buildBlockLists = true
+						restart = true
+					}
+				}
+
+				running = reloaded
+				if !restart {
+					logger.Info("configuration reloaded, Unbound is not restarted")
+					continue
+				}
+				logger.Info("configuration reloaded, restarting unbound")
+				dnsMetrics.UnboundRestart(metrics.RestartRequested)
+				downloadFiles = false
+				break waitLoop
+			case <-resumeBlockingCh:
+				logger.Info("blocking pause expired, restarting unbound")
+				dnsMetrics.UnboundRestart(metrics.RestartBlocking)
+				resumeBlockingTimer, resumeBlockingCh = nil, nil
+				downloadFiles = false
+				break waitLoop
+			case <-ctx.Done():
+				if !timer.Stop() {
+					<-timer.C
+				}
+				logger.Warn("context canceled: exiting unbound run loop")
+				break waitLoop
+			case waitErr := <-waitError:
+				dnsMetrics.UnboundCrash()
+				close(waitError)
+				close(stdoutLines)
+				close(stderrLines)
+				if !timer.Stop() {
+					<-timer.C
+				}
+				crashed <- waitErr
+				unboundCancel()
+				return
+			}
+		}
+	}
+	unboundCancel()
+}
+
+// mergeCategories merges the block lists of all the categories,
+// sorted by category for the result to be deterministic.
+func mergeCategories(categories map[string]blacklist.Settings) (merged blacklist.Settings) {
+	names := make([]string, 0, len(categories))
+	for name := range categories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	settings := make([]blacklist.Settings, len(names))
+	for i, name := range names {
+		settings[i] = categories[name]
+	}
+	return blacklist.Merge(settings...)
+}
+
+// earliestEnd returns the earliest end time of the pauses given,
+// and false if there is no pause.
+func earliestEnd(categoryToEnd map[string]time.Time) (end time.Time, paused bool) {
+	for _, categoryEnd := range categoryToEnd {
+		if !paused || categoryEnd.Before(end) {
+			end, paused = categoryEnd, true
+		}
+	}
+	return end, paused
+}
+
+// forwardSignals pauses blocking for all categories on SIGUSR1,
+// resumes blocking on SIGUSR2 and reloads the configuration on
+// SIGHUP, until the context is canceled.
+func forwardSignals(ctx context.Context, wg *sync.WaitGroup,
+	logger logging.Logger, events *admin.Events) {
+	defer wg.Done()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	const signalPauseDuration = 5 * time.Minute
+	for {
+		var sig os.Signal
 		select {
-		case <-timer.C:
-			logger.Info("planned restart of unbound")
 		case <-ctx.Done():
-			if !timer.Stop() {
-				<-timer.C
-			}
-			logger.Warn("context canceled: exiting unbound run loop")
-		case waitErr := <-waitError:
-			close(waitError)
-			close(stdoutLines)
-			close(stderrLines)
-			if !timer.Stop() {
-				<-timer.C
+			return
+		case sig = <-signals:
+			logger.Info("received signal " + sig.String())
+		}
+
+		if sig == syscall.SIGHUP {
+			select {
+			case <-ctx.Done():
+				return
+			case events.Reload <- struct{}{}:
 			}
-			crashed <- waitErr
-			unboundCancel()
+			continue
+		}
+
+		var pause admin.BlockingPause
+		if sig == syscall.SIGUSR1 {
+			pause.Duration = signalPauseDuration
+		}
+		select {
+		case <-ctx.Done():
 			return
+		case events.PauseBlocking <- pause:
 		}
 	}
-	unboundCancel()
+}
+
+// notReloadable returns the names of the settings changed
+// which cannot be applied without restarting the program.
+func notReloadable(running, reloaded config.Settings) (names []string) {
+	if !reflect.DeepEqual(reloaded.QueryLog, running.QueryLog) {
+		names = append(names, "query log")
+	}
+	if reloaded.MetricsAddress != running.MetricsAddress {
+		names = append(names, "metrics")
+	}
+	if reloaded.Admin != running.Admin {
+		names = append(names, "admin API")
+	}
+	return names
+}
+
+func readLocalNames(logger logging.Logger, settings hosts.Settings) (records []hosts.Record) {
+	records, errs := hosts.Read(settings)
+	for _, err := range errs {
+		logger.Warn("cannot read local names: " + err.Error())
+	}
+	return records
 }
 
 func logAndWait(ctx context.Context, logger logging.Logger, err error) {
@@ -263,7 +632,13 @@ func logAndWait(ctx context.Context, logger logging.Logger, err error) {
 	}
 }
 
-func logUnboundStreams(logger logging.Logger, stdout, stderr <-chan string) {
+// logUnboundStreams logs the lines from the Unbound output streams.
+// Reply lines are recorded in the metrics and sent to the query log
+// if it is enabled, instead of being logged. Replies for hostnames
+// blocked by the black lister given are marked as blocked.
+func logUnboundStreams(logger logging.Logger, queryLog querylog.Logger,
+	dnsMetrics metrics.Metrics, blackLister blacklist.Pausable,
+	stdout, stderr <-chan string) {
 	var line string
 	var ok bool
 	for {
@@ -274,6 +649,50 @@ func logUnboundStreams(logger logging.Logger, stdout, stderr <-chan string) {
 		if !ok {
 			return
 		}
+
+		if entry, isReply := unbound.ParseReplyLine(line); isReply {
+			const metricsServer = "unbound"
+			dnsMetrics.Query(metricsServer, entry.Type, entry.Rcode)
+			match, blocked := blockingMatch(blackLister, entry.Name)
+			entry.Blocked = blocked
+			entry.BlockedCategory = match.Category
+			entry.BlockedRule = match.Rule
+			switch {
+			case entry.Blocked:
+				dnsMetrics.Blocked(metricsServer, match.Category)
+			case entry.Cached:
+				dnsMetrics.CacheHit(metricsServer)
+			default:
+				dnsMetrics.CacheMiss(metricsServer)
+				entry.Upstream = metricsServer
+			}
+
+			if queryLog != nil {
+				if err := queryLog.Log(entry); err != nil {
+					logger.Warn("cannot log query: " + err.Error())
+				}
+			}
+			continue
+		}
+
 		logger.Info(line)
 	}
 }
+
+// blockingMatch returns the block list rule blocking the name or
+// one of its parent domains, since Unbound blocks hostnames with
+// static local zones which also cover their subdomains.
+func blockingMatch(blackLister blacklist.Pausable, name string) (
+	match blacklist.Match, blocked bool) {
+	name = dns.Fqdn(strings.ToLower(name))
+	request := new(dns.Msg)
+	for _, offset := range dns.Split(name) {
+		request.SetQuestion(name[offset:], dns.TypeA)
+		match, blocked = blackLister.MatchRequest(request)
+		if blocked {
+			match.Name = name
+			return match, true
+		}
+	}
+	return blacklist.Match{}, false
+}
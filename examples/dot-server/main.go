@@ -5,8 +5,10 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
+	"github.com/qdm12/dns/internal/health"
 	"github.com/qdm12/dns/pkg/dot"
 )
 
@@ -14,9 +16,21 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	logger := new(Logger)
-	server := dot.NewServer(ctx, logger, dot.ServerSettings{})
+	server, err := dot.NewServer(ctx, logger, dot.ServerSettings{})
+	if err != nil {
+		log.Fatal(err)
+	}
 	stopped := make(chan error)
 	go server.Run(ctx, stopped)
+
+	// The server is reported healthy only once its cache is warmed up.
+	healthServer := health.NewServer("127.0.0.1:9999", logger,
+		health.ReadyCheck(server.Ready, health.IsHealthy))
+	healthWg := &sync.WaitGroup{}
+	healthWg.Add(1)
+	go healthServer.Run(ctx, healthWg)
+	defer healthWg.Wait()
+
 	select {
 	case <-ctx.Done():
 		logger.Warn("\nCaught an OS signal, terminating...")
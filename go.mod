@@ -3,11 +3,16 @@ module github.com/qdm12/dns
 go 1.16
 
 require (
+	github.com/dnstap/golang-dnstap v0.4.0
 	github.com/golang/mock v1.6.0
 	github.com/kyokomi/emoji v2.2.4+incompatible
 	github.com/miekg/dns v1.1.40
+	github.com/pelletier/go-toml v1.9.5
+	github.com/prometheus/client_golang v1.10.0
 	github.com/qdm12/golibs v0.0.0-20210723175634-a75ca7fd74c2
 	github.com/qdm12/updated v0.0.0-20210603204757-205acfe6937e
 	github.com/stretchr/testify v1.7.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	inet.af/netaddr v0.0.0-20210511181906-37180328850c
 )
@@ -0,0 +1,127 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Client
+
+// Client is a client for the admin API of a running
+// instance of the program.
+type Client interface {
+	PauseBlocking(ctx context.Context, minutes uint, categories []string) (err error)
+	Explain(ctx context.Context, query string) (explanation blacklist.Explanation, err error)
+}
+
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// NewClient creates a client for the admin API listening on the
+// address given, using the loopback address if it listens on
+// all interfaces.
+func NewClient(settings Settings) Client {
+	const timeout = 5 * time.Second
+	return &client{
+		httpClient: &http.Client{Timeout: timeout},
+		baseURL:    "http://" + clientAddress(settings.Address),
+		token:      settings.Token,
+	}
+}
+
+func clientAddress(address string) string {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// PauseBlocking pauses blocking for the block list categories given,
+// or for all categories if none is given. Zero minutes resumes blocking.
+func (c *client) PauseBlocking(ctx context.Context, minutes uint,
+	categories []string) (err error) {
+	body, err := json.Marshal(pauseRequest{
+		Minutes:    minutes,
+		Categories: categories,
+	})
+	if err != nil {
+		return err
+	}
+
+	response, err := c.do(ctx, http.MethodPost, "/v1/blocking/pause", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// Explain explains why the hostname or IP address given is blocked.
+func (c *client) Explain(ctx context.Context, query string) (
+	explanation blacklist.Explanation, err error) {
+	path := "/v1/blocking/explain?" + url.Values{"query": []string{query}}.Encode()
+	response, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return explanation, err
+	}
+	defer response.Body.Close()
+
+	decoder := json.NewDecoder(response.Body)
+	if err := decoder.Decode(&explanation); err != nil {
+		return explanation, fmt.Errorf("cannot decode response body: %w", err)
+	}
+	return explanation, nil
+}
+
+// do sends an authenticated request to the admin API, and returns an
+// error if the response status code is not successful.
+func (c *client) do(ctx context.Context, method, path string,
+	body io.Reader) (response *http.Response, err error) {
+	request, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err = c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	const maxSuccessStatus = 299
+	if response.StatusCode <= maxSuccessStatus {
+		return response, nil
+	}
+
+	b, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: %d %s: %s", ErrUnexpectedStatus,
+		response.StatusCode, http.StatusText(response.StatusCode),
+		strings.TrimSpace(string(b)))
+}
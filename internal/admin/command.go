@@ -0,0 +1,137 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+)
+
+const (
+	pauseCommand   = "pause-blocking"
+	resumeCommand  = "resume-blocking"
+	explainCommand = "explain"
+)
+
+var (
+	ErrDisabled       = errors.New("admin API is disabled")
+	ErrMinutesInvalid = errors.New("number of minutes is invalid")
+	ErrQueryArgument  = errors.New("expected one hostname or IP address argument")
+)
+
+// IsBlockingCommand returns true if the program arguments are for
+// the pause-blocking, the resume-blocking or the explain subcommand.
+func IsBlockingCommand(args []string) bool {
+	if len(args) <= 1 {
+		return false
+	}
+	switch args[1] {
+	case pauseCommand, resumeCommand, explainCommand:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunBlockingCommand runs the pause-blocking, the resume-blocking or
+// the explain subcommand from the program arguments, to pause or resume
+// blocking, or to explain why a hostname or an IP address is blocked,
+// on the running instance of the program through its admin API.
+func RunBlockingCommand(ctx context.Context, args []string,
+	client Client, output io.Writer) (err error) {
+	command := args[1]
+	if command == explainCommand {
+		return runExplain(ctx, args[2:], client, output)
+	}
+
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	flagSet.SetOutput(output)
+	const defaultMinutes = 5
+	minutes := defaultMinutes
+	if command == pauseCommand {
+		flagSet.IntVar(&minutes, "minutes", defaultMinutes,
+			"number of minutes to pause blocking for")
+	}
+	categoriesCSV := flagSet.String("categories", "",
+		"comma separated block list categories, defaulting to all categories")
+	if err := flagSet.Parse(args[2:]); err != nil {
+		return err
+	}
+
+	var categories []string
+	if *categoriesCSV != "" {
+		categories = strings.Split(*categoriesCSV, ",")
+	}
+	for _, category := range categories {
+		if err := blacklist.CheckCategory(category); err != nil {
+			return err
+		}
+	}
+
+	if command == resumeCommand {
+		minutes = 0
+	} else if minutes < 1 {
+		return fmt.Errorf("%w: %d", ErrMinutesInvalid, minutes)
+	}
+
+	if err := client.PauseBlocking(ctx, uint(minutes), categories); err != nil {
+		return err
+	}
+
+	concerned := "all categories"
+	if len(categories) > 0 {
+		concerned = strings.Join(categories, ", ")
+	}
+	if minutes == 0 {
+		fmt.Fprintln(output, "blocking resumed for "+concerned)
+	} else {
+		fmt.Fprintf(output, "blocking paused for %s during %d minutes\n", concerned, minutes)
+	}
+	return nil
+}
+
+func runExplain(ctx context.Context, args []string,
+	client Client, output io.Writer) (err error) {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: %d arguments given", ErrQueryArgument, len(args))
+	}
+
+	explanation, err := client.Explain(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(output, formatExplanation(explanation))
+	return err
+}
+
+func formatExplanation(explanation blacklist.Explanation) string {
+	status := "is not blocked"
+	if explanation.Blocked {
+		status = "is blocked"
+	}
+	lines := []string{explanation.Query + " " + status}
+
+	for _, match := range explanation.Matches {
+		line := "- " + match.Entry + ": " + match.Category + " rule " + strconv.Quote(match.Rule)
+		if match.List != "" {
+			line += " from " + match.List
+		}
+		switch {
+		case match.AllowedBy != "":
+			line += " (allowed by " + match.AllowedBy + ")"
+		case match.Paused:
+			line += " (paused)"
+		case match.MonitorOnly:
+			line += " (monitor only)"
+		}
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
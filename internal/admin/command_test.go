@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/dns/internal/admin/mock_admin"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RunBlockingCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		args        []string
+		setupClient func(client *mock_admin.MockClient)
+		output      string
+		err         error
+	}{
+		"pause all categories": {
+			args: []string{"entrypoint", "pause-blocking"},
+			setupClient: func(client *mock_admin.MockClient) {
+				client.EXPECT().PauseBlocking(gomock.Any(), uint(5), nil).Return(nil)
+			},
+			output: "blocking paused for all categories during 5 minutes\n",
+		},
+		"pause categories": {
+			args: []string{"entrypoint", "pause-blocking", "-minutes", "30", "-categories", "ads,surveillance"},
+			setupClient: func(client *mock_admin.MockClient) {
+				client.EXPECT().PauseBlocking(gomock.Any(), uint(30),
+					[]string{"ads", "surveillance"}).Return(nil)
+			},
+			output: "blocking paused for ads, surveillance during 30 minutes\n",
+		},
+		"resume category": {
+			args: []string{"entrypoint", "resume-blocking", "-categories", "ads"},
+			setupClient: func(client *mock_admin.MockClient) {
+				client.EXPECT().PauseBlocking(gomock.Any(), uint(0), []string{"ads"}).Return(nil)
+			},
+			output: "blocking resumed for ads\n",
+		},
+		"unknown category": {
+			args: []string{"entrypoint", "pause-blocking", "-categories", "games"},
+			err:  errors.New("block list category is unknown: games"),
+		},
+		"invalid minutes": {
+			args: []string{"entrypoint", "pause-blocking", "-minutes", "0"},
+			err:  errors.New("number of minutes is invalid: 0"),
+		},
+		"explain": {
+			args: []string{"entrypoint", "explain", "sub.ads.com"},
+			setupClient: func(client *mock_admin.MockClient) {
+				explanation := blacklist.Explanation{
+					Query:   "sub.ads.com",
+					Blocked: true,
+					Matches: []blacklist.RuleMatch{
+						{Entry: "sub.ads.com", AllowedBy: "sub.ads.com", Source: blacklist.Source{
+							Category: "ads", List: "https://lists/ads", Rule: "sub.ads.com"}},
+						{Entry: "ads.com", Source: blacklist.Source{
+							Category: "custom", List: "settings", Rule: "ads.com"}},
+						{Entry: "ads.com", Paused: true, Source: blacklist.Source{
+							Category: "malicious", Rule: "ADS.com"}},
+					},
+				}
+				client.EXPECT().Explain(gomock.Any(), "sub.ads.com").Return(explanation, nil)
+			},
+			output: `sub.ads.com is blocked
+- sub.ads.com: ads rule "sub.ads.com" from https://lists/ads (allowed by sub.ads.com)
+- ads.com: custom rule "ads.com" from settings
+- ads.com: malicious rule "ADS.com" (paused)
+`,
+		},
+		"explain without argument": {
+			args: []string{"entrypoint", "explain"},
+			err:  errors.New("expected one hostname or IP address argument: 0 arguments given"),
+		},
+		"client error": {
+			args: []string{"entrypoint", "resume-blocking"},
+			setupClient: func(client *mock_admin.MockClient) {
+				client.EXPECT().PauseBlocking(gomock.Any(), uint(0), nil).
+					Return(errors.New("dummy"))
+			},
+			err: errors.New("dummy"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			client := mock_admin.NewMockClient(ctrl)
+			if testCase.setupClient != nil {
+				testCase.setupClient(client)
+			}
+			output := bytes.NewBuffer(nil)
+
+			err := RunBlockingCommand(context.Background(), testCase.args, client, output)
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.output, output.String())
+		})
+	}
+}
+
+func Test_clientAddress(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		address string
+		result  string
+	}{
+		"port only":       {address: ":8000", result: "127.0.0.1:8000"},
+		"unspecified IP":  {address: "0.0.0.0:8000", result: "127.0.0.1:8000"},
+		"specific IP":     {address: "192.168.1.2:8000", result: "192.168.1.2:8000"},
+		"hostname":        {address: "localhost:8000", result: "localhost:8000"},
+		"invalid address": {address: "invalid", result: "invalid"},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.result, clientAddress(testCase.address))
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboardFS embed.FS
+
+// newDashboardHandler returns an HTTP handler serving the
+// dashboard web page and its assets embedded in the binary.
+// The page itself calls the authenticated API endpoints.
+func newDashboardHandler() http.Handler {
+	assets, err := fs.Sub(dashboardFS, "dashboard")
+	if err != nil {
+		panic(err) // the embedded directory always exists
+	}
+	return http.FileServer(http.FS(assets))
+}
@@ -0,0 +1,214 @@
+"use strict";
+
+const tokenKey = "dns-admin-token";
+const refreshPeriod = 30000;
+
+function token() {
+  return window.localStorage.getItem(tokenKey);
+}
+
+async function api(method, path, body) {
+  const options = {
+    method: method,
+    headers: {"Authorization": "Bearer " + token()},
+  };
+  if (body !== undefined) {
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const response = await fetch(path, options);
+  if (response.status === 401) {
+    signOut();
+    throw new Error("invalid admin token");
+  }
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+  if (response.status === 204 || response.status === 202) {
+    return null;
+  }
+  return response.json();
+}
+
+function showError(error) {
+  const element = document.getElementById("error");
+  element.textContent = error.message;
+  element.hidden = false;
+}
+
+function clearError() {
+  document.getElementById("error").hidden = true;
+}
+
+function cell(row, text) {
+  const td = row.insertCell();
+  td.textContent = text;
+  return td;
+}
+
+function fillTop(id, counts) {
+  const table = document.getElementById(id);
+  table.replaceChildren();
+  for (const count of counts) {
+    const row = table.insertRow();
+    cell(row, count.name);
+    cell(row, count.count);
+  }
+}
+
+function fillChart(minutes) {
+  const chart = document.getElementById("chart");
+  chart.replaceChildren();
+  const max = Math.max(1, ...minutes.map((minute) => minute.queries));
+  for (const minute of minutes) {
+    const bar = document.createElement("div");
+    bar.className = "bar";
+    bar.style.height = (100 * minute.queries / max) + "%";
+    bar.title = new Date(minute.time).toLocaleTimeString() + ": " +
+      minute.queries + " queries, " + minute.blocked + " blocked";
+    const allowed = document.createElement("div");
+    allowed.className = "allowed";
+    allowed.style.flex = minute.queries - minute.blocked;
+    const blocked = document.createElement("div");
+    blocked.className = "blocked";
+    blocked.style.flex = minute.blocked;
+    bar.append(allowed, blocked);
+    chart.append(bar);
+  }
+}
+
+function fillUpstreams(upstreams) {
+  const body = document.querySelector("#upstreams tbody");
+  body.replaceChildren();
+  for (const upstream of upstreams) {
+    const row = body.insertRow();
+    cell(row, upstream.upstream);
+    cell(row, upstream.queries);
+    cell(row, upstream.failures);
+    cell(row, (upstream.average_latency_ns / 1e6).toFixed(1) + " ms");
+  }
+}
+
+async function loadStats() {
+  const stats = await api("GET", "/v1/stats");
+  document.getElementById("queries").textContent = stats.queries;
+  document.getElementById("blocked").textContent = stats.blocked;
+  document.getElementById("cache-hit-ratio").textContent =
+    Math.round(100 * stats.cache_hit_ratio) + "%";
+  fillChart(stats.queries_per_minute);
+  fillTop("top-domains", stats.top_domains);
+  fillTop("top-blocked", stats.top_blocked);
+  fillTop("top-clients", stats.top_clients);
+  fillUpstreams(stats.upstreams);
+}
+
+async function changeList(list, hostname) {
+  const change = {add: {}, remove: {}};
+  if (list === "allowed") {
+    change.add.allowed_hostnames = [hostname];
+    change.remove.blocked_hostnames = [hostname];
+  } else {
+    change.add.blocked_hostnames = [hostname];
+    change.remove.allowed_hostnames = [hostname];
+  }
+  try {
+    await api("POST", "/v1/lists", change);
+    clearError();
+  } catch (error) {
+    showError(error);
+  }
+}
+
+function listButton(row, entry) {
+  const hostname = entry.name.replace(/\.$/, "");
+  const button = document.createElement("button");
+  const list = entry.blocked ? "allowed" : "blocked";
+  button.textContent = entry.blocked ? "Allow" : "Block";
+  button.addEventListener("click", () => {
+    button.disabled = true;
+    changeList(list, hostname);
+  });
+  row.insertCell().append(button);
+}
+
+async function loadQueries() {
+  const parameters = new URLSearchParams({limit: "100"});
+  const name = document.getElementById("search-name").value;
+  const client = document.getElementById("search-client").value;
+  if (name !== "") {
+    parameters.set("name", name);
+  }
+  if (client !== "") {
+    parameters.set("client", client);
+  }
+  if (document.getElementById("search-blocked").checked) {
+    parameters.set("blocked", "true");
+  }
+  const entries = await api("GET", "/v1/queries?" + parameters.toString());
+  const body = document.querySelector("#recent tbody");
+  body.replaceChildren();
+  for (const entry of entries) {
+    const row = body.insertRow();
+    if (entry.blocked) {
+      row.className = "blocked-row";
+    }
+    cell(row, new Date(entry.time).toLocaleTimeString());
+    cell(row, entry.client_ip);
+    cell(row, entry.name);
+    cell(row, entry.type);
+    let result = entry.rcode;
+    if (entry.blocked) {
+      result = "blocked";
+    } else if (entry.cached) {
+      result += " (cached)";
+    }
+    cell(row, result);
+    listButton(row, entry);
+  }
+}
+
+async function refresh() {
+  try {
+    await Promise.all([loadStats(), loadQueries()]);
+    clearError();
+  } catch (error) {
+    showError(error);
+  }
+}
+
+let refreshTimer;
+
+function signIn() {
+  document.getElementById("token-form").hidden = true;
+  document.getElementById("sign-out").hidden = false;
+  document.getElementById("dashboard").hidden = false;
+  refresh();
+  refreshTimer = window.setInterval(refresh, refreshPeriod);
+}
+
+function signOut() {
+  window.localStorage.removeItem(tokenKey);
+  window.clearInterval(refreshTimer);
+  document.getElementById("token-form").hidden = false;
+  document.getElementById("sign-out").hidden = true;
+  document.getElementById("dashboard").hidden = true;
+}
+
+document.getElementById("token-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  window.localStorage.setItem(tokenKey, document.getElementById("token").value);
+  signIn();
+});
+
+document.getElementById("sign-out").addEventListener("click", signOut);
+
+document.getElementById("search").addEventListener("submit", (event) => {
+  event.preventDefault();
+  loadQueries().then(clearError, showError);
+});
+
+if (token()) {
+  signIn();
+} else {
+  signOut();
+}
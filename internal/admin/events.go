@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"time"
+)
+
+// Events are sent by the admin API to the Unbound run loop,
+// which must receive from each of the channels. The channels
+// are buffered so events are handed over without waiting for
+// the run loop, which can be busy restarting Unbound.
+type Events struct {
+	// Restart is sent to restart Unbound.
+	Restart chan struct{}
+	// Update is sent to update the block lists and
+	// the DNSSEC root files, and restart Unbound.
+	Update chan struct{}
+	// State is sent with the new state when its lists change,
+	// to rebuild the block lists and restart Unbound. A state
+	// not received yet is replaced by a newer state.
+	State chan State
+	// PauseBlocking is sent to pause or resume blocking.
+	PauseBlocking chan BlockingPause
+	// Reload is sent to read the configuration again and apply
+	// its changes, keeping the running configuration if it is invalid.
+	Reload chan struct{}
+}
+
+// BlockingPause is a request to pause blocking temporarily.
+type BlockingPause struct {
+	// Duration is the duration to pause blocking for.
+	// A zero duration resumes blocking immediately.
+	Duration time.Duration
+	// Categories are the block list categories to pause or
+	// resume, and all categories are concerned if it is empty.
+	Categories []string
+}
+
+// pausesQueueSize is the maximum number of blocking
+// pauses waiting to be received by the run loop.
+const pausesQueueSize = 8
+
+func NewEvents() *Events {
+	return &Events{
+		Restart:       make(chan struct{}, 1),
+		Update:        make(chan struct{}, 1),
+		State:         make(chan State, 1),
+		PauseBlocking: make(chan BlockingPause, pausesQueueSize),
+		Reload:        make(chan struct{}, 1),
+	}
+}
+
+// notify sends an event without blocking. It does nothing if an
+// event is already waiting to be received, since the run loop
+// handles both events the same way.
+func notify(events chan<- struct{}) {
+	select {
+	case events <- struct{}{}:
+	default:
+	}
+}
+
+// replaceState sends the state without blocking, replacing any
+// state waiting to be received. Calls must not run concurrently.
+func replaceState(states chan State, state State) {
+	for {
+		select {
+		case states <- state:
+			return
+		default:
+		}
+		select {
+		case <-states:
+		default:
+		}
+	}
+}
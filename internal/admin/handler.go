@@ -0,0 +1,374 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qdm12/dns/internal/models"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/golibs/logging"
+	"github.com/qdm12/golibs/verification"
+	"inet.af/netaddr"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Cache
+
+// Cache is the DNS cache managed through the admin API.
+type Cache interface {
+	FlushCache(ctx context.Context) (err error)
+	FlushName(ctx context.Context, name string) (err error)
+}
+
+// Explainer explains why a hostname or an IP address is blocked.
+type Explainer interface {
+	Explain(query string) (explanation blacklist.Explanation)
+}
+
+type handler struct {
+	logger        logging.Logger
+	token         string
+	buildInfo     models.BuildInformation
+	settingsLines []string
+	cache         Cache
+	explainer     Explainer
+	events        *Events
+	queryLog      querylog.Logger
+	verifier      verification.Verifier
+	dashboard     http.Handler
+
+	stateFile    string
+	stateMutex   sync.Mutex
+	state        State
+	stateVersion uint64
+
+	// sendMutex serializes sending states to the run loop,
+	// and sentVersion is the version of the last state sent.
+	sendMutex   sync.Mutex
+	sentVersion uint64
+}
+
+// NewHandler creates the admin API HTTP handler. The settings lines
+// are the current settings to show, and the state is the state
+// loaded from the state file at start. The query log can be nil,
+// in which case no statistics and no recent queries are available.
+func NewHandler(logger logging.Logger, settings Settings,
+	buildInfo models.BuildInformation, settingsLines []string,
+	cache Cache, explainer Explainer, events *Events,
+	queryLog querylog.Logger, state State) http.Handler {
+	return &handler{
+		logger:        logger,
+		token:         settings.Token,
+		buildInfo:     buildInfo,
+		settingsLines: settingsLines,
+		cache:         cache,
+		explainer:     explainer,
+		events:        events,
+		queryLog:      queryLog,
+		verifier:      verification.NewVerifier(),
+		dashboard:     newDashboardHandler(),
+		stateFile:     settings.StateFile,
+		state:         state,
+	}
+}
+
+const cacheEntriesPrefix = "/v1/cache/entries/"
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && !strings.HasPrefix(r.URL.Path, "/v1/") {
+		h.dashboard.ServeHTTP(w, r)
+		return
+	}
+
+	if !h.authenticated(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	path := r.URL.Path
+	switch {
+	case r.Method == http.MethodGet && path == "/v1/settings":
+		h.getSettings(w)
+	case r.Method == http.MethodPost && path == "/v1/cache/flush":
+		h.flushCache(w, r)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, cacheEntriesPrefix):
+		h.deleteCacheEntry(w, r, strings.TrimPrefix(path, cacheEntriesPrefix))
+	case r.Method == http.MethodGet && path == "/v1/stats":
+		h.getStats(w)
+	case r.Method == http.MethodGet && path == "/v1/queries":
+		h.getQueries(w, r)
+	case r.Method == http.MethodGet && path == "/v1/lists":
+		h.getLists(w)
+	case r.Method == http.MethodPost && path == "/v1/lists":
+		h.changeLists(w, r)
+	case r.Method == http.MethodGet && path == "/v1/blocking/explain":
+		h.explain(w, r)
+	case r.Method == http.MethodPost && path == "/v1/blocking/pause":
+		h.pauseBlocking(w, r)
+	case r.Method == http.MethodPost && path == "/v1/update":
+		h.sendEvent(w, h.events.Update)
+	case r.Method == http.MethodPost && path == "/v1/unbound/restart":
+		h.sendEvent(w, h.events.Restart)
+	case r.Method == http.MethodPost && path == "/v1/reload":
+		h.sendEvent(w, h.events.Reload)
+	default:
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+	}
+}
+
+func (h *handler) authenticated(r *http.Request) bool {
+	const prefix = "Bearer "
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, prefix) {
+		return false
+	}
+	token := strings.TrimPrefix(authorization, prefix)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+type settingsResponse struct {
+	Build    models.BuildInformation `json:"build"`
+	Settings []string                `json:"settings"`
+}
+
+func (h *handler) getSettings(w http.ResponseWriter) {
+	h.writeJSON(w, settingsResponse{
+		Build:    h.buildInfo,
+		Settings: h.settingsLines,
+	})
+}
+
+func (h *handler) flushCache(w http.ResponseWriter, r *http.Request) {
+	if err := h.cache.FlushCache(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) deleteCacheEntry(w http.ResponseWriter, r *http.Request, name string) {
+	if !h.verifier.MatchHostname(name) {
+		http.Error(w, "invalid name: "+name, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cache.FlushName(r.Context(), name); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const (
+	statsMinutes = 60
+	statsTop     = 10
+)
+
+func (h *handler) getStats(w http.ResponseWriter) {
+	var entries []querylog.Entry
+	if h.queryLog != nil {
+		entries = h.queryLog.Entries(querylog.Filter{})
+	}
+	summary := querylog.Summarize(entries, time.Now(), statsMinutes, statsTop)
+	h.writeJSON(w, summary)
+}
+
+func (h *handler) getQueries(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries := []querylog.Entry{}
+	if h.queryLog != nil {
+		if found := h.queryLog.Entries(filter); found != nil {
+			entries = found
+		}
+	}
+	h.writeJSON(w, entries)
+}
+
+var (
+	ErrClientIPInvalid = errors.New("client IP address is invalid")
+	ErrBlockedInvalid  = errors.New("blocked value is invalid")
+	ErrLimitInvalid    = errors.New("limit is invalid")
+)
+
+// parseFilter parses the query log filter from the URL query parameters
+// name, client, blocked and limit. The limit defaults to 100.
+func parseFilter(r *http.Request) (filter querylog.Filter, err error) {
+	values := r.URL.Query()
+
+	filter.Name = values.Get("name")
+
+	if client := values.Get("client"); client != "" {
+		filter.ClientIP, err = netaddr.ParseIP(client)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %s", ErrClientIPInvalid, client)
+		}
+	}
+
+	if blocked := values.Get("blocked"); blocked != "" {
+		filter.BlockedOnly, err = strconv.ParseBool(blocked)
+		if err != nil {
+			return filter, fmt.Errorf("%w: %s", ErrBlockedInvalid, blocked)
+		}
+	}
+
+	const defaultLimit = 100
+	filter.Limit = defaultLimit
+	if limit := values.Get("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 {
+			return filter, fmt.Errorf("%w: %s", ErrLimitInvalid, limit)
+		}
+	}
+
+	return filter, nil
+}
+
+func (h *handler) getLists(w http.ResponseWriter) {
+	h.stateMutex.Lock()
+	state := h.state
+	h.stateMutex.Unlock()
+	h.writeJSON(w, state)
+}
+
+var ErrHostnameInvalid = errors.New("hostname is invalid")
+
+func (h *handler) changeLists(w http.ResponseWriter, r *http.Request) {
+	var change Change
+	if !decodeJSON(w, r, &change) {
+		return
+	}
+
+	if err := h.validateChange(change); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.stateMutex.Lock()
+	state := h.state.apply(change)
+	if err := SaveState(h.stateFile, state); err != nil {
+		h.stateMutex.Unlock()
+		http.Error(w, "cannot save state: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.state = state
+	h.stateVersion++
+	version := h.stateVersion
+	h.stateMutex.Unlock()
+
+	h.sendState(version, state)
+
+	// The state is saved and is applied in the background.
+	h.writeJSONStatus(w, http.StatusAccepted, state)
+}
+
+// sendState sends the state of the version given to the run loop,
+// unless a newer state was already sent, such that the run loop
+// always ends up with the last state saved.
+func (h *handler) sendState(version uint64, state State) {
+	h.sendMutex.Lock()
+	defer h.sendMutex.Unlock()
+	if version < h.sentVersion {
+		return
+	}
+	h.sentVersion = version
+	replaceState(h.events.State, state)
+}
+
+func (h *handler) validateChange(change Change) (err error) {
+	hostnames := [][]string{
+		change.Add.BlockedHostnames, change.Add.AllowedHostnames,
+		change.Remove.BlockedHostnames, change.Remove.AllowedHostnames,
+	}
+	for _, list := range hostnames {
+		for _, hostname := range list {
+			if !h.verifier.MatchHostname(normalizeHostname(hostname)) {
+				return fmt.Errorf("%w: %q", ErrHostnameInvalid, hostname)
+			}
+		}
+	}
+	return nil
+}
+
+var ErrQueryMissing = errors.New("query parameter is missing")
+
+func (h *handler) explain(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		http.Error(w, ErrQueryMissing.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeJSON(w, h.explainer.Explain(query))
+}
+
+var ErrTooManyPauses = errors.New("too many blocking pauses are waiting to be applied")
+
+type pauseRequest struct {
+	Minutes    uint     `json:"minutes"`
+	Categories []string `json:"categories,omitempty"`
+}
+
+func (h *handler) pauseBlocking(w http.ResponseWriter, r *http.Request) {
+	var request pauseRequest
+	if !decodeJSON(w, r, &request) {
+		return
+	}
+
+	for _, category := range request.Categories {
+		if err := blacklist.CheckCategory(category); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	pause := BlockingPause{
+		Duration:   time.Duration(request.Minutes) * time.Minute,
+		Categories: request.Categories,
+	}
+	select {
+	case h.events.PauseBlocking <- pause:
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		http.Error(w, ErrTooManyPauses.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func (h *handler) sendEvent(w http.ResponseWriter, events chan<- struct{}) {
+	notify(events)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) (ok bool) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		http.Error(w, "cannot decode request body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func (h *handler) writeJSON(w http.ResponseWriter, v interface{}) {
+	h.writeJSONStatus(w, http.StatusOK, v)
+}
+
+func (h *handler) writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.logger.Warn("cannot write JSON response: " + err.Error())
+	}
+}
@@ -0,0 +1,352 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/dns/internal/admin/mock_admin"
+	"github.com/qdm12/dns/internal/models"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/querylog/mock_querylog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_handler(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		method      string
+		path        string
+		token       string
+		body        string
+		setupCache  func(cache *mock_admin.MockCache)
+		setupLog    func(queryLog *mock_querylog.MockLogger)
+		event       func(events *Events) (received interface{})
+		status      int
+		response    string
+		eventResult interface{}
+	}{
+		"missing token": {
+			method:   http.MethodGet,
+			path:     "/v1/settings",
+			status:   http.StatusUnauthorized,
+			response: "Unauthorized\n",
+		},
+		"wrong token": {
+			method:   http.MethodGet,
+			path:     "/v1/settings",
+			token:    "wrong",
+			status:   http.StatusUnauthorized,
+			response: "Unauthorized\n",
+		},
+		"unknown path": {
+			method:   http.MethodGet,
+			path:     "/v1/unknown",
+			token:    "token",
+			status:   http.StatusNotFound,
+			response: "Not Found\n",
+		},
+		"get settings": {
+			method: http.MethodGet,
+			path:   "/v1/settings",
+			token:  "token",
+			status: http.StatusOK,
+			response: `{"build":{"version":"v1","commit":"abc","buildDate":"today"},` +
+				`"settings":[" |--Check DNS: enabled"]}` + "\n",
+		},
+		"flush cache": {
+			method: http.MethodPost,
+			path:   "/v1/cache/flush",
+			token:  "token",
+			setupCache: func(cache *mock_admin.MockCache) {
+				cache.EXPECT().FlushCache(gomock.Any()).Return(nil)
+			},
+			status: http.StatusNoContent,
+		},
+		"flush cache error": {
+			method: http.MethodPost,
+			path:   "/v1/cache/flush",
+			token:  "token",
+			setupCache: func(cache *mock_admin.MockCache) {
+				cache.EXPECT().FlushCache(gomock.Any()).Return(errors.New("dummy"))
+			},
+			status:   http.StatusInternalServerError,
+			response: "dummy\n",
+		},
+		"delete cache entry": {
+			method: http.MethodDelete,
+			path:   "/v1/cache/entries/github.com",
+			token:  "token",
+			setupCache: func(cache *mock_admin.MockCache) {
+				cache.EXPECT().FlushName(gomock.Any(), "github.com").Return(nil)
+			},
+			status: http.StatusNoContent,
+		},
+		"get queries": {
+			method: http.MethodGet,
+			path:   "/v1/queries?name=ads&client=10.0.0.1&blocked=true&limit=1",
+			token:  "token",
+			setupLog: func(queryLog *mock_querylog.MockLogger) {
+				filter := querylog.Filter{
+					ClientIP:    netaddr.IPv4(10, 0, 0, 1),
+					Name:        "ads",
+					BlockedOnly: true,
+					Limit:       1,
+				}
+				entries := []querylog.Entry{{
+					Time:     time.Date(2021, 7, 22, 10, 30, 0, 0, time.UTC),
+					ClientIP: netaddr.IPv4(10, 0, 0, 1),
+					Name:     "ads.com.",
+					Type:     "A",
+					Rcode:    "NXDOMAIN",
+					Blocked:  true,
+				}}
+				queryLog.EXPECT().Entries(filter).Return(entries)
+			},
+			status: http.StatusOK,
+			response: `[{"time":"2021-07-22T10:30:00Z","client_ip":"10.0.0.1",` +
+				`"name":"ads.com.","type":"A","rcode":"NXDOMAIN","latency_ns":0,` +
+				`"cached":false,"blocked":true}]` + "\n",
+		},
+		"get queries without result": {
+			method: http.MethodGet,
+			path:   "/v1/queries",
+			token:  "token",
+			setupLog: func(queryLog *mock_querylog.MockLogger) {
+				queryLog.EXPECT().Entries(querylog.Filter{Limit: 100}).Return(nil)
+			},
+			status:   http.StatusOK,
+			response: "[]\n",
+		},
+		"get queries with invalid limit": {
+			method:   http.MethodGet,
+			path:     "/v1/queries?limit=0",
+			token:    "token",
+			status:   http.StatusBadRequest,
+			response: "limit is invalid: 0\n",
+		},
+		"get lists": {
+			method:   http.MethodGet,
+			path:     "/v1/lists",
+			token:    "token",
+			status:   http.StatusOK,
+			response: `{"blocked_hostnames":["ads.com"]}` + "\n",
+		},
+		"change lists": {
+			method: http.MethodPost,
+			path:   "/v1/lists",
+			token:  "token",
+			body: `{"add":{"allowed_hostnames":["Site.com"],"blocked_ips":["1.2.3.4"]},` +
+				`"remove":{"blocked_hostnames":["ads.com"]}}`,
+			event: func(events *Events) interface{} {
+				state := <-events.State
+				return state.AllowedHostnames
+			},
+			status:      http.StatusAccepted,
+			response:    `{"allowed_hostnames":["site.com"],"blocked_ips":["1.2.3.4"]}` + "\n",
+			eventResult: []string{"site.com"},
+		},
+		"change lists with unknown field": {
+			method:   http.MethodPost,
+			path:     "/v1/lists",
+			token:    "token",
+			body:     `{"added":{}}`,
+			status:   http.StatusBadRequest,
+			response: "cannot decode request body: json: unknown field \"added\"\n",
+		},
+		"explain": {
+			method: http.MethodGet,
+			path:   "/v1/blocking/explain?query=sub.ads.com",
+			token:  "token",
+			status: http.StatusOK,
+			response: `{"query":"sub.ads.com","blocked":true,"matches":` +
+				`[{"entry":"ads.com","category":"ads","rule":"ads.com"}]}` + "\n",
+		},
+		"explain without query": {
+			method:   http.MethodGet,
+			path:     "/v1/blocking/explain",
+			token:    "token",
+			status:   http.StatusBadRequest,
+			response: "query parameter is missing\n",
+		},
+		"pause blocking": {
+			method: http.MethodPost,
+			path:   "/v1/blocking/pause",
+			token:  "token",
+			body:   `{"minutes":5}`,
+			event: func(events *Events) interface{} {
+				return <-events.PauseBlocking
+			},
+			status:      http.StatusAccepted,
+			eventResult: BlockingPause{Duration: 5 * time.Minute},
+		},
+		"pause blocking for categories": {
+			method: http.MethodPost,
+			path:   "/v1/blocking/pause",
+			token:  "token",
+			body:   `{"minutes":5,"categories":["ads","surveillance"]}`,
+			event: func(events *Events) interface{} {
+				return <-events.PauseBlocking
+			},
+			status: http.StatusAccepted,
+			eventResult: BlockingPause{
+				Duration:   5 * time.Minute,
+				Categories: []string{"ads", "surveillance"},
+			},
+		},
+		"pause blocking for unknown category": {
+			method:   http.MethodPost,
+			path:     "/v1/blocking/pause",
+			token:    "token",
+			body:     `{"minutes":5,"categories":["games"]}`,
+			status:   http.StatusBadRequest,
+			response: "block list category is unknown: games\n",
+		},
+		"update": {
+			method: http.MethodPost,
+			path:   "/v1/update",
+			token:  "token",
+			event: func(events *Events) interface{} {
+				return <-events.Update
+			},
+			status:      http.StatusAccepted,
+			eventResult: struct{}{},
+		},
+		"restart unbound": {
+			method: http.MethodPost,
+			path:   "/v1/unbound/restart",
+			token:  "token",
+			event: func(events *Events) interface{} {
+				return <-events.Restart
+			},
+			status:      http.StatusAccepted,
+			eventResult: struct{}{},
+		},
+		"reload": {
+			method: http.MethodPost,
+			path:   "/v1/reload",
+			token:  "token",
+			event: func(events *Events) interface{} {
+				return <-events.Reload
+			},
+			status:      http.StatusAccepted,
+			eventResult: struct{}{},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			cache := mock_admin.NewMockCache(ctrl)
+			if testCase.setupCache != nil {
+				testCase.setupCache(cache)
+			}
+
+			queryLog := mock_querylog.NewMockLogger(ctrl)
+			if testCase.setupLog != nil {
+				testCase.setupLog(queryLog)
+			}
+
+			explainer := blacklist.NewPausable(map[string]blacklist.Settings{
+				blacklist.CategoryAds: {FqdnHostnames: []string{"ads.com."}},
+			})
+
+			settings := Settings{
+				Address:   ":8000",
+				Token:     "token",
+				StateFile: filepath.Join(t.TempDir(), "state.json"),
+			}
+			buildInfo := models.BuildInformation{Version: "v1", Commit: "abc", BuildDate: "today"}
+			events := NewEvents()
+			state := State{BlockedHostnames: []string{"ads.com"}}
+			handler := NewHandler(nil, settings, buildInfo,
+				[]string{" |--Check DNS: enabled"}, cache, explainer, events, queryLog, state)
+
+			eventResult := make(chan interface{}, 1)
+			if testCase.event != nil {
+				go func() { eventResult <- testCase.event(events) }()
+			}
+
+			request := httptest.NewRequest(testCase.method, testCase.path,
+				strings.NewReader(testCase.body))
+			if testCase.token != "" {
+				request.Header.Set("Authorization", "Bearer "+testCase.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, testCase.status, recorder.Code)
+			assert.Equal(t, testCase.response, recorder.Body.String())
+			if testCase.event != nil {
+				require.Equal(t, testCase.eventResult, <-eventResult)
+			}
+		})
+	}
+}
+
+func Test_handler_busyRunLoop(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		Token:     "token",
+		StateFile: filepath.Join(t.TempDir(), "state.json"),
+	}
+	events := NewEvents()
+	handler := NewHandler(nil, settings, models.BuildInformation{},
+		nil, nil, nil, events, nil, State{})
+
+	// Nothing receives from the events channels.
+	post := func(path, body string) (status int) {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		request.Header.Set("Authorization", "Bearer token")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Code
+	}
+
+	for _, hostname := range []string{"a.com", "b.com"} {
+		status := post("/v1/lists", `{"add":{"blocked_hostnames":["`+hostname+`"]}}`)
+		assert.Equal(t, http.StatusAccepted, status)
+	}
+	state := <-events.State
+	assert.Equal(t, []string{"a.com", "b.com"}, state.BlockedHostnames)
+	assert.Empty(t, events.State)
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusAccepted, post("/v1/update", ""))
+	}
+	assert.Len(t, events.Update, 1)
+
+	for i := 0; i < pausesQueueSize; i++ {
+		assert.Equal(t, http.StatusAccepted, post("/v1/blocking/pause", `{"minutes":1}`))
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, post("/v1/blocking/pause", `{"minutes":1}`))
+}
+
+func Test_handler_dashboard(t *testing.T) {
+	t.Parallel()
+
+	handler := NewHandler(nil, Settings{Token: "token"}, models.BuildInformation{},
+		nil, nil, nil, NewEvents(), nil, State{})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<title>DNS dashboard</title>")
+}
@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/internal/admin (interfaces: Client)
+
+// Package mock_admin is a generated GoMock package.
+package mock_admin
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	blacklist "github.com/qdm12/dns/pkg/blacklist"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// Explain mocks base method.
+func (m *MockClient) Explain(arg0 context.Context, arg1 string) (blacklist.Explanation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Explain", arg0, arg1)
+	ret0, _ := ret[0].(blacklist.Explanation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Explain indicates an expected call of Explain.
+func (mr *MockClientMockRecorder) Explain(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Explain", reflect.TypeOf((*MockClient)(nil).Explain), arg0, arg1)
+}
+
+// PauseBlocking mocks base method.
+func (m *MockClient) PauseBlocking(arg0 context.Context, arg1 uint, arg2 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PauseBlocking", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PauseBlocking indicates an expected call of PauseBlocking.
+func (mr *MockClientMockRecorder) PauseBlocking(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PauseBlocking", reflect.TypeOf((*MockClient)(nil).PauseBlocking), arg0, arg1, arg2)
+}
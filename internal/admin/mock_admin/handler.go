@@ -0,0 +1,63 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/internal/admin (interfaces: Cache)
+
+// Package mock_admin is a generated GoMock package.
+package mock_admin
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCache is a mock of Cache interface.
+type MockCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheMockRecorder
+}
+
+// MockCacheMockRecorder is the mock recorder for MockCache.
+type MockCacheMockRecorder struct {
+	mock *MockCache
+}
+
+// NewMockCache creates a new mock instance.
+func NewMockCache(ctrl *gomock.Controller) *MockCache {
+	mock := &MockCache{ctrl: ctrl}
+	mock.recorder = &MockCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCache) EXPECT() *MockCacheMockRecorder {
+	return m.recorder
+}
+
+// FlushCache mocks base method.
+func (m *MockCache) FlushCache(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushCache", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushCache indicates an expected call of FlushCache.
+func (mr *MockCacheMockRecorder) FlushCache(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushCache", reflect.TypeOf((*MockCache)(nil).FlushCache), arg0)
+}
+
+// FlushName mocks base method.
+func (m *MockCache) FlushName(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushName", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FlushName indicates an expected call of FlushName.
+func (mr *MockCacheMockRecorder) FlushName(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushName", reflect.TypeOf((*MockCache)(nil).FlushName), arg0, arg1)
+}
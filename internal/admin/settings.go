@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"strings"
+)
+
+type Settings struct {
+	// Address is the listening address of the admin HTTP API.
+	// It defaults to the empty string, meaning the API is disabled.
+	Address string
+	// Token is the bearer token clients must send
+	// in the Authorization header.
+	Token string
+	// StateFile is the path of the JSON file where changes made
+	// through the API are persisted and loaded from at start.
+	StateFile string
+}
+
+func (s *Settings) SetDefaults() {
+	if s.StateFile == "" {
+		s.StateFile = "/unbound/state.json"
+	}
+}
+
+func (s *Settings) Enabled() bool {
+	return s.Address != ""
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled() {
+		return []string{subSection + "Admin API: disabled"}
+	}
+
+	lines = append(lines, subSection+"Admin API:")
+	lines = append(lines, indent+subSection+"Listening address: "+s.Address)
+	lines = append(lines, indent+subSection+"State file: "+s.StateFile)
+
+	return lines
+}
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"inet.af/netaddr"
+)
+
+// State contains the changes made through the admin API,
+// which are persisted to the state file.
+type State struct {
+	BlockedHostnames []string     `json:"blocked_hostnames,omitempty"`
+	AllowedHostnames []string     `json:"allowed_hostnames,omitempty"`
+	BlockedIPs       []netaddr.IP `json:"blocked_ips,omitempty"`
+	AllowedIPs       []netaddr.IP `json:"allowed_ips,omitempty"`
+}
+
+// LoadState reads the state from the JSON file at the path
+// given. An empty state is returned if the file does not exist.
+func LoadState(path string) (state State, err error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&state)
+	_ = file.Close()
+	if err != nil {
+		return state, fmt.Errorf("cannot decode state file %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+// SaveState writes the state as JSON to a temporary
+// file and then moves it to the path given.
+func SaveState(path string, state State) (err error) {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+
+	_, err = tempFile.Write(data)
+	if err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	return os.Rename(tempPath, path)
+}
+
+// Apply returns the block lists builder settings given
+// with the hostnames and IP addresses of the state added.
+func (s State) Apply(settings blacklist.BuilderSettings) blacklist.BuilderSettings {
+	settings.AddBlockedHosts = append(append([]string(nil),
+		settings.AddBlockedHosts...), s.BlockedHostnames...)
+	settings.AllowedHosts = append(append([]string(nil),
+		settings.AllowedHosts...), s.AllowedHostnames...)
+	settings.AddBlockedIPs = append(append([]netaddr.IP(nil),
+		settings.AddBlockedIPs...), s.BlockedIPs...)
+	settings.AllowedIPs = append(append([]netaddr.IP(nil),
+		settings.AllowedIPs...), s.AllowedIPs...)
+	return settings
+}
+
+// Change is a change to the state lists.
+type Change struct {
+	Add    State `json:"add"`
+	Remove State `json:"remove"`
+}
+
+// apply returns a copy of the state with the change applied.
+// Hostnames are lower cased and stripped of their trailing dot.
+func (s State) apply(change Change) (updated State) {
+	updated.BlockedHostnames = changeHostnames(s.BlockedHostnames,
+		change.Add.BlockedHostnames, change.Remove.BlockedHostnames)
+	updated.AllowedHostnames = changeHostnames(s.AllowedHostnames,
+		change.Add.AllowedHostnames, change.Remove.AllowedHostnames)
+	updated.BlockedIPs = changeIPs(s.BlockedIPs,
+		change.Add.BlockedIPs, change.Remove.BlockedIPs)
+	updated.AllowedIPs = changeIPs(s.AllowedIPs,
+		change.Add.AllowedIPs, change.Remove.AllowedIPs)
+	return updated
+}
+
+func normalizeHostname(hostname string) string {
+	return strings.TrimSuffix(strings.ToLower(hostname), ".")
+}
+
+func changeHostnames(hostnames, add, remove []string) (changed []string) {
+	set := make(map[string]struct{}, len(hostnames)+len(add))
+	for _, hostname := range hostnames {
+		set[hostname] = struct{}{}
+	}
+	for _, hostname := range add {
+		set[normalizeHostname(hostname)] = struct{}{}
+	}
+	for _, hostname := range remove {
+		delete(set, normalizeHostname(hostname))
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+
+	changed = make([]string, 0, len(set))
+	for hostname := range set {
+		changed = append(changed, hostname)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func changeIPs(ips, add, remove []netaddr.IP) (changed []netaddr.IP) {
+	set := make(map[netaddr.IP]struct{}, len(ips)+len(add))
+	for _, ip := range ips {
+		set[ip] = struct{}{}
+	}
+	for _, ip := range add {
+		set[ip] = struct{}{}
+	}
+	for _, ip := range remove {
+		delete(set, ip)
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+
+	changed = make([]netaddr.IP, 0, len(set))
+	for ip := range set {
+		changed = append(changed, ip)
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].Compare(changed[j]) < 0
+	})
+	return changed
+}
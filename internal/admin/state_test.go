@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_SaveState_LoadState(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, State{}, state)
+
+	state = State{
+		BlockedHostnames: []string{"ads.com"},
+		AllowedIPs:       []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+	}
+	err = SaveState(path, state)
+	require.NoError(t, err)
+
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+}
+
+func Test_State_apply(t *testing.T) {
+	t.Parallel()
+
+	state := State{
+		BlockedHostnames: []string{"a.com", "b.com"},
+		BlockedIPs:       []netaddr.IP{netaddr.IPv4(1, 1, 1, 1)},
+	}
+	change := Change{
+		Add: State{
+			BlockedHostnames: []string{"C.com.", "a.com"},
+			AllowedHostnames: []string{"d.com"},
+			AllowedIPs:       []netaddr.IP{netaddr.IPv4(2, 2, 2, 2)},
+		},
+		Remove: State{
+			BlockedHostnames: []string{"b.com"},
+			BlockedIPs:       []netaddr.IP{netaddr.IPv4(1, 1, 1, 1)},
+		},
+	}
+
+	updated := state.apply(change)
+
+	expected := State{
+		BlockedHostnames: []string{"a.com", "c.com"},
+		AllowedHostnames: []string{"d.com"},
+		AllowedIPs:       []netaddr.IP{netaddr.IPv4(2, 2, 2, 2)},
+	}
+	assert.Equal(t, expected, updated)
+}
+
+func Test_State_Apply(t *testing.T) {
+	t.Parallel()
+
+	state := State{
+		BlockedHostnames: []string{"b.com"},
+		AllowedHostnames: []string{"c.com"},
+		BlockedIPs:       []netaddr.IP{netaddr.IPv4(1, 1, 1, 1)},
+		AllowedIPs:       []netaddr.IP{netaddr.IPv4(2, 2, 2, 2)},
+	}
+	settings := blacklist.BuilderSettings{
+		BlockMalicious:  true,
+		AddBlockedHosts: []string{"a.com"},
+	}
+
+	applied := state.Apply(settings)
+
+	expected := blacklist.BuilderSettings{
+		BlockMalicious:  true,
+		AddBlockedHosts: []string{"a.com", "b.com"},
+		AllowedHosts:    []string{"c.com"},
+		AddBlockedIPs:   []netaddr.IP{netaddr.IPv4(1, 1, 1, 1)},
+		AllowedIPs:      []netaddr.IP{netaddr.IPv4(2, 2, 2, 2)},
+	}
+	assert.Equal(t, expected, applied)
+	assert.Equal(t, []string{"a.com"}, settings.AddBlockedHosts)
+}
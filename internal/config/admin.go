@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/qdm12/dns/internal/admin"
+	"github.com/qdm12/golibs/params"
+)
+
+var errAdminTokenMissing = errors.New("admin token is missing")
+
+func getAdminSettings(reader *reader) (settings admin.Settings, errs []error) {
+	var err error
+	settings.Address, err = reader.env.Get("ADMIN_ADDRESS")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable ADMIN_ADDRESS: %w", err))
+	}
+
+	settings.Token, err = reader.env.Get("ADMIN_TOKEN", params.CaseSensitiveValue())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable ADMIN_TOKEN: %w", err))
+	}
+	if settings.Enabled() && settings.Token == "" {
+		errs = append(errs, fmt.Errorf("environment variable ADMIN_TOKEN: %w", errAdminTokenMissing))
+	}
+
+	settings.StateFile, err = reader.env.Get("ADMIN_STATE_FILE", params.CaseSensitiveValue())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable ADMIN_STATE_FILE: %w", err))
+	}
+	settings.SetDefaults()
+
+	return settings, errs
+}
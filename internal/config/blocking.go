@@ -9,39 +9,61 @@ import (
 	"inet.af/netaddr"
 )
 
-func getBlacklistSettings(reader *reader) (settings blacklist.BuilderSettings, err error) {
+func getBlacklistSettings(reader *reader) (settings blacklist.BuilderSettings, errs []error) {
+	var err error
 	settings.BlockMalicious, err = reader.env.OnOff("BLOCK_MALICIOUS", params.Default("on"))
 	if err != nil {
-		return settings, fmt.Errorf("environment variable BLOCK_MALICIOUS: %w", err)
+		errs = append(errs, fmt.Errorf("environment variable BLOCK_MALICIOUS: %w", err))
 	}
 	settings.BlockSurveillance, err = reader.env.OnOff("BLOCK_SURVEILLANCE", params.Default("off"),
 		params.RetroKeys([]string{"BLOCK_NSA"}, reader.onRetroActive))
 	if err != nil {
-		return settings, fmt.Errorf("environment variable BLOCK_SURVEILLANCE: %w", err)
+		errs = append(errs, fmt.Errorf("environment variable BLOCK_SURVEILLANCE: %w", err))
 	}
 	settings.BlockAds, err = reader.env.OnOff("BLOCK_ADS", params.Default("off"))
 	if err != nil {
-		return settings, fmt.Errorf("environment variable BLOCK_SURVEILLANCE: %w", err)
+		errs = append(errs, fmt.Errorf("environment variable BLOCK_ADS: %w", err))
+	}
+	settings.MonitorOnly, err = getMonitorOnlyCategories(reader)
+	if err != nil {
+		errs = append(errs, err)
 	}
 	settings.AllowedHosts, err = getAllowedHostnames(reader)
 	if err != nil {
-		return settings, err
+		errs = append(errs, err)
 	}
 	settings.AddBlockedHosts, err = getBlockedHostnames(reader)
 	if err != nil {
-		return settings, err
+		errs = append(errs, err)
 	}
 	settings.AddBlockedIPs, settings.AddBlockedIPPrefixes, err = getBlockedIPs(reader)
 	if err != nil {
-		return settings, err
+		errs = append(errs, err)
 	}
-	privateIPs, privateIPPrefixes, err := getPrivateAddresses(reader)
+	settings.PrivateIPs, settings.PrivateIPPrefixes, err = getPrivateAddresses(reader)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return settings, errs
+}
+
+// getMonitorOnlyCategories obtains the block list categories for which
+// matches are only logged and counted, from the comma separated list
+// for the environment variable BLOCK_MONITOR_ONLY.
+func getMonitorOnlyCategories(reader *reader) (categories []string, err error) {
+	categories, err = reader.env.CSV("BLOCK_MONITOR_ONLY")
 	if err != nil {
-		return settings, err
+		return nil, fmt.Errorf("environment variable BLOCK_MONITOR_ONLY: %w", err)
+	}
+	for _, category := range categories {
+		if category == blacklist.AllCategories {
+			continue
+		}
+		if err := blacklist.CheckCategory(category); err != nil {
+			return nil, fmt.Errorf("environment variable BLOCK_MONITOR_ONLY: %w", err)
+		}
 	}
-	settings.AddBlockedIPs = append(settings.AddBlockedIPs, privateIPs...)
-	settings.AddBlockedIPPrefixes = append(settings.AddBlockedIPPrefixes, privateIPPrefixes...)
-	return settings, nil
+	return categories, nil
 }
 
 var errAllowedHostnameInvalid = errors.New("allowed hostname is invalid")
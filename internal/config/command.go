@@ -0,0 +1,163 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/qdm12/dns/internal/admin"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/hosts"
+	"github.com/qdm12/dns/pkg/unbound"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	validateCommand    = "validate"
+	printConfigCommand = "print-config"
+)
+
+var (
+	ErrSettingsInvalid = errors.New("settings are invalid")
+	ErrFormatUnknown   = errors.New("format is unknown")
+)
+
+// UnboundConfer renders the Unbound configuration for the settings given.
+type UnboundConfer interface {
+	UnboundConf(settings unbound.Settings) (conf string)
+}
+
+// IsConfigCommand returns true if the program arguments are
+// for the validate or the print-config subcommand.
+func IsConfigCommand(args []string) bool {
+	if len(args) <= 1 {
+		return false
+	}
+	switch args[1] {
+	case validateCommand, printConfigCommand:
+		return true
+	default:
+		return false
+	}
+}
+
+// RunConfigCommand runs the validate or the print-config subcommand from
+// the program arguments, to report all the errors of the settings, or to
+// print the effective settings in YAML or JSON, or the Unbound configuration,
+// without writing any file or starting anything.
+func RunConfigCommand(args []string,
+	readSettings func(args []string) (Settings, error),
+	unboundConfer UnboundConfer, output io.Writer) (err error) {
+	if args[1] == validateCommand {
+		return runValidate(args[2:], readSettings, output)
+	}
+
+	format := "yaml"
+	flagArgs := args[2:]
+	if len(flagArgs) > 0 && !strings.HasPrefix(flagArgs[0], "-") {
+		format, flagArgs = flagArgs[0], flagArgs[1:]
+	}
+
+	settings, err := readSettings(flagArgs)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "yaml":
+		encoder := yaml.NewEncoder(output)
+		const indent = 2
+		encoder.SetIndent(indent)
+		if err := encoder.Encode(newFileSettings(settings)); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "json":
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(newFileSettings(settings))
+	case "unbound":
+		unboundSettings, comments, err := prepareUnboundSettings(settings)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			fmt.Fprintln(output, "# "+comment)
+		}
+		_, err = fmt.Fprintln(output, unboundConfer.UnboundConf(unboundSettings))
+		return err
+	default:
+		return fmt.Errorf("%w: %s, it must be yaml, json or unbound",
+			ErrFormatUnknown, format)
+	}
+}
+
+func runValidate(args []string,
+	readSettings func(args []string) (Settings, error),
+	output io.Writer) (err error) {
+	_, err = readSettings(args)
+	if err == nil {
+		_, err = fmt.Fprintln(output, "configuration is valid")
+		return err
+	}
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		errs = Errors{err}
+	}
+	for _, err := range errs {
+		fmt.Fprintln(output, "- "+err.Error())
+	}
+	return fmt.Errorf("%w: %d error(s) found", ErrSettingsInvalid, len(errs))
+}
+
+// prepareUnboundSettings returns the Unbound settings as the program
+// sets them before generating the Unbound configuration, except block
+// lists are not downloaded. Comments are returned to explain how the
+// Unbound settings differ from the ones used at runtime.
+func prepareUnboundSettings(settings Settings) (
+	unboundSettings unbound.Settings, comments []string, err error) {
+	unboundSettings = settings.Unbound
+
+	if settings.LocalNames.Enabled() {
+		unboundSettings.LocalDomain = settings.LocalNames.Domain
+		var errs []error
+		unboundSettings.LocalNames, errs = hosts.Read(settings.LocalNames)
+		for _, err := range errs {
+			comments = append(comments, "cannot read local names: "+err.Error())
+		}
+	}
+
+	builderSettings := settings.Blacklist
+	if settings.Admin.Enabled() {
+		state, err := admin.LoadState(settings.Admin.StateFile)
+		if err != nil {
+			return unboundSettings, nil, err
+		}
+		builderSettings = state.Apply(builderSettings)
+	}
+
+	// Only build the custom block list category from the settings.
+	if builderSettings.BlockMalicious || builderSettings.BlockAds ||
+		builderSettings.BlockSurveillance {
+		comments = append(comments, "block lists downloaded at runtime are not included")
+	}
+	customSettings := builderSettings
+	customSettings.BlockMalicious = false
+	customSettings.BlockAds = false
+	customSettings.BlockSurveillance = false
+	categories, _, _ := blacklist.NewBuilder(nil).Categories(
+		context.Background(), customSettings)
+
+	blocking := blacklist.NewPausable(categories)
+	err = blocking.SetMonitorOnly(builderSettings.MonitorOnly, nil)
+	if err != nil {
+		return unboundSettings, nil, err
+	}
+	unboundSettings.Blacklist = blocking.Active()
+
+	return unboundSettings, comments, nil
+}
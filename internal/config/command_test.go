@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/unbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_RunConfigCommand(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		Unbound: unbound.Settings{
+			Providers:     []provider.Provider{provider.CloudflareFamily()},
+			ListeningPort: 53,
+			Caching:       true,
+		},
+		Blacklist: blacklist.BuilderSettings{
+			BlockMalicious:       true,
+			AddBlockedHosts:      []string{"ads.com"},
+			AddBlockedIPPrefixes: []netaddr.IPPrefix{{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 8}},
+			PrivateIPPrefixes:    []netaddr.IPPrefix{{IP: netaddr.IPv4(192, 168, 0, 0), Bits: 16}},
+		},
+		UpdatePeriod: 24 * time.Hour,
+	}
+	settings.Admin.Address = ":8000"
+	settings.Admin.Token = "secret"
+
+	testCases := map[string]struct {
+		args        []string
+		settings    Settings
+		settingsErr error
+		flagArgs    []string
+		output      string
+		err         error
+	}{
+		"validate valid settings": {
+			args:     []string{"entrypoint", "validate", "--block-ads=on"},
+			flagArgs: []string{"--block-ads=on"},
+			output:   "configuration is valid\n",
+		},
+		"validate invalid settings": {
+			args:     []string{"entrypoint", "validate"},
+			flagArgs: []string{},
+			settingsErr: Errors{
+				errors.New("environment variable BLOCK_ADS: invalid"),
+				errors.New("environment variable CACHING: invalid"),
+			},
+			output: "- environment variable BLOCK_ADS: invalid\n" +
+				"- environment variable CACHING: invalid\n",
+			err: errors.New("settings are invalid: 2 error(s) found"),
+		},
+		"validate configuration file error": {
+			args:        []string{"entrypoint", "validate"},
+			flagArgs:    []string{},
+			settingsErr: errors.New("cannot read configuration file"),
+			output:      "- cannot read configuration file\n",
+			err:         errors.New("settings are invalid: 1 error(s) found"),
+		},
+		"print-config YAML": {
+			args:     []string{"entrypoint", "print-config", "--block-ads=on"},
+			flagArgs: []string{"--block-ads=on"},
+			settings: settings,
+			output: `unbound:
+  providers:
+    - cloudflare family
+  listening_port: 53
+  caching: true
+  ipv4: false
+  ipv6: false
+  verbosity: 0
+  verbosity_details: 0
+  validation_log_level: 0
+  dnstap_socket: ""
+blacklist:
+  block_malicious: true
+  block_surveillance: false
+  block_ads: false
+  monitor_only: []
+  allowed_hosts: []
+  blocked_hosts:
+    - ads.com
+  blocked_ips:
+    - 10.0.0.0/8
+  private_addresses:
+    - 192.168.0.0/16
+local_names:
+  hosts_files: []
+  leases_files: []
+  domain: ""
+  update_period: 0s
+query_log:
+  stdout: false
+  file: ""
+  file_max_size: 0
+  file_max_backups: 0
+  memory_size: 0
+  anonymize_ips: false
+metrics_address: ""
+admin:
+  address: :8000
+  token: '[redacted]'
+  state_file: ""
+check_dns: false
+update_period: 24h0m0s
+`,
+		},
+		"print-config JSON": {
+			args:     []string{"entrypoint", "print-config", "json"},
+			flagArgs: []string{},
+			settings: Settings{},
+			output: `{
+  "unbound": {
+    "providers": [],
+    "listening_port": 0,
+    "caching": false,
+    "ipv4": false,
+    "ipv6": false,
+    "verbosity": 0,
+    "verbosity_details": 0,
+    "validation_log_level": 0,
+    "dnstap_socket": ""
+  },
+  "blacklist": {
+    "block_malicious": false,
+    "block_surveillance": false,
+    "block_ads": false,
+    "monitor_only": [],
+    "allowed_hosts": [],
+    "blocked_hosts": [],
+    "blocked_ips": [],
+    "private_addresses": []
+  },
+  "local_names": {
+    "hosts_files": [],
+    "leases_files": [],
+    "domain": "",
+    "update_period": "0s"
+  },
+  "query_log": {
+    "stdout": false,
+    "file": "",
+    "file_max_size": 0,
+    "file_max_backups": 0,
+    "memory_size": 0,
+    "anonymize_ips": false
+  },
+  "metrics_address": "",
+  "admin": {
+    "address": "",
+    "token": "",
+    "state_file": ""
+  },
+  "check_dns": false,
+  "update_period": "0s"
+}
+`,
+		},
+		"print-config settings error": {
+			args:        []string{"entrypoint", "print-config", "json"},
+			flagArgs:    []string{},
+			settingsErr: errors.New("settings error"),
+			err:         errors.New("settings error"),
+		},
+		"print-config unknown format": {
+			args:     []string{"entrypoint", "print-config", "xml"},
+			flagArgs: []string{},
+			err:      errors.New("format is unknown: xml, it must be yaml, json or unbound"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			readSettings := func(args []string) (Settings, error) {
+				assert.Equal(t, testCase.flagArgs, args)
+				return testCase.settings, testCase.settingsErr
+			}
+			output := bytes.NewBuffer(nil)
+
+			err := RunConfigCommand(testCase.args, readSettings, nil, output)
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.output, output.String())
+		})
+	}
+}
+
+func Test_prepareUnboundSettings(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings        Settings
+		unboundSettings unbound.Settings
+		comments        []string
+		err             error
+	}{
+		"no block list": {
+			settings: Settings{
+				Unbound: unbound.Settings{ListeningPort: 53},
+			},
+			unboundSettings: unbound.Settings{ListeningPort: 53},
+		},
+		"custom block list": {
+			settings: Settings{
+				Blacklist: blacklist.BuilderSettings{
+					BlockAds:        true,
+					AllowedHosts:    []string{"allowed.ads.com"},
+					AddBlockedHosts: []string{"ads.com", "allowed.ads.com"},
+					AddBlockedIPs:   []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+				},
+			},
+			unboundSettings: unbound.Settings{
+				Blacklist: blacklist.Settings{
+					FqdnHostnames: []string{"ads.com."},
+					IPs:           []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+				},
+			},
+			comments: []string{"block lists downloaded at runtime are not included"},
+		},
+		"custom block list monitor only": {
+			settings: Settings{
+				Blacklist: blacklist.BuilderSettings{
+					AddBlockedHosts: []string{"ads.com"},
+					MonitorOnly:     []string{blacklist.CategoryCustom},
+				},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			unboundSettings, comments, err := prepareUnboundSettings(testCase.settings)
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.unboundSettings, unboundSettings)
+			assert.Equal(t, testCase.comments, comments)
+		})
+	}
+}
@@ -3,15 +3,25 @@ package config
 import (
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/qdm12/golibs/params"
 	"inet.af/netaddr"
 )
 
+//nolint:gochecknoglobals
+var defaultPrivateAddresses = []string{
+	"127.0.0.1/8", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "169.254.0.0/16",
+	"::1/128", "fc00::/7", "fe80::/10", "::ffff:7f00:1/104", "::ffff:a00:0/104",
+	"::ffff:a9fe:0/112", "::ffff:ac10:0/108", "::ffff:c0a8:0/112",
+}
+
 var errPrivateIPInvalid = errors.New("invalid private IP address string")
 
 func getPrivateAddresses(reader *reader) (privateIPs []netaddr.IP,
 	privateIPPrefixes []netaddr.IPPrefix, err error) {
-	values, err := reader.env.CSV("PRIVATE_ADDRESS")
+	values, err := reader.env.CSV("PRIVATE_ADDRESS",
+		params.Default(strings.Join(defaultPrivateAddresses, ",")))
 	if err != nil {
 		return nil, nil, fmt.Errorf("environment variable PRIVATE_ADDRESS: %w", err)
 	}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// settingKey associates a key of the configuration file,
+// where sections are separated by dots, with the environment
+// variable for the same setting.
+type settingKey struct {
+	file string
+	env  string
+}
+
+//nolint:gochecknoglobals
+var settingKeys = []settingKey{
+	{file: "unbound.providers", env: "PROVIDERS"},
+	{file: "unbound.listening_port", env: "LISTENINGPORT"},
+	{file: "unbound.caching", env: "CACHING"},
+	{file: "unbound.ipv4", env: "IPV4"},
+	{file: "unbound.ipv6", env: "IPV6"},
+	{file: "unbound.verbosity", env: "VERBOSITY"},
+	{file: "unbound.verbosity_details", env: "VERBOSITY_DETAILS"},
+	{file: "unbound.validation_log_level", env: "VALIDATION_LOGLEVEL"},
+	{file: "unbound.dnstap_socket", env: "DNSTAP_SOCKET"},
+	{file: "blacklist.block_malicious", env: "BLOCK_MALICIOUS"},
+	{file: "blacklist.block_surveillance", env: "BLOCK_SURVEILLANCE"},
+	{file: "blacklist.block_ads", env: "BLOCK_ADS"},
+	{file: "blacklist.monitor_only", env: "BLOCK_MONITOR_ONLY"},
+	{file: "blacklist.allowed_hosts", env: "UNBLOCK"},
+	{file: "blacklist.blocked_hosts", env: "BLOCK_HOSTNAMES"},
+	{file: "blacklist.blocked_ips", env: "BLOCK_IPS"},
+	{file: "blacklist.private_addresses", env: "PRIVATE_ADDRESS"},
+	{file: "local_names.hosts_files", env: "HOSTS_FILES"},
+	{file: "local_names.leases_files", env: "DHCP_LEASES_FILES"},
+	{file: "local_names.domain", env: "LOCAL_DOMAIN"},
+	{file: "local_names.update_period", env: "LOCAL_NAMES_UPDATE_PERIOD"},
+	{file: "query_log.stdout", env: "QUERY_LOG_STDOUT"},
+	{file: "query_log.file", env: "QUERY_LOG_FILE"},
+	{file: "query_log.file_max_size", env: "QUERY_LOG_FILE_MAX_SIZE"},
+	{file: "query_log.file_max_backups", env: "QUERY_LOG_FILE_MAX_BACKUPS"},
+	{file: "query_log.memory_size", env: "QUERY_LOG_MEMORY_SIZE"},
+	{file: "query_log.anonymize_ips", env: "QUERY_LOG_ANONYMIZE_IPS"},
+	{file: "metrics_address", env: "METRICS_ADDRESS"},
+	{file: "admin.address", env: "ADMIN_ADDRESS"},
+	{file: "admin.token", env: "ADMIN_TOKEN"},
+	{file: "admin.state_file", env: "ADMIN_STATE_FILE"},
+	{file: "check_dns", env: "CHECK_DNS"},
+	{file: "update_period", env: "UPDATE_PERIOD"},
+}
+
+var errConfigFileFormat = errors.New("configuration file format is not supported")
+
+// readConfigFile reads the YAML or TOML configuration file at the path
+// given, and returns its values by environment variable key.
+func readConfigFile(path string) (envValues map[string]string, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read configuration file: %w", err)
+	}
+
+	envValues, err = parseConfigFile(b, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("configuration file %s: %w", path, err)
+	}
+	return envValues, nil
+}
+
+func parseConfigFile(b []byte, extension string) (envValues map[string]string, err error) {
+	values := newFileValues()
+	switch strings.ToLower(extension) {
+	case ".yaml", ".yml":
+		err = parseYAML(b, values)
+	case ".toml":
+		err = parseTOML(b, values)
+	default:
+		return nil, fmt.Errorf("%w: %q, it must be .yaml, .yml or .toml",
+			errConfigFileFormat, extension)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return values.envValues, nil
+}
+
+var (
+	errKeyUnknown    = errors.New("unknown key")
+	errKeyDuplicated = errors.New("duplicated key")
+	errKeyNotSection = errors.New("key must be a section")
+	errKeyIsSection  = errors.New("key is a section and cannot have a value")
+	errValueInvalid  = errors.New("value must be a string, a number, a boolean or a list of these")
+)
+
+// fileValues collects the values of the configuration file,
+// checking each key is known and set once only.
+type fileValues struct {
+	keyToEnv  map[string]string
+	sections  map[string]struct{}
+	keyToLine map[string]int
+	envValues map[string]string
+}
+
+func newFileValues() *fileValues {
+	values := &fileValues{
+		keyToEnv:  make(map[string]string, len(settingKeys)),
+		sections:  make(map[string]struct{}),
+		keyToLine: make(map[string]int),
+		envValues: make(map[string]string),
+	}
+	for _, key := range settingKeys {
+		values.keyToEnv[key.file] = key.env
+		if i := strings.LastIndex(key.file, "."); i > 0 {
+			values.sections[key.file[:i]] = struct{}{}
+		}
+	}
+	return values
+}
+
+func (f *fileValues) isSection(key string) bool {
+	_, ok := f.sections[key]
+	return ok
+}
+
+// section checks the key found at the line given is a section.
+func (f *fileValues) section(key string, line int) (err error) {
+	if f.isSection(key) {
+		return nil
+	}
+	if _, ok := f.keyToEnv[key]; ok {
+		return fmt.Errorf("line %d: %w: %s", line, errKeyNotSection, key)
+	}
+	return fmt.Errorf("line %d: %w: %s", line, errKeyUnknown, key)
+}
+
+// set sets the value of the key found at the line given. Empty values
+// are ignored, such that the environment variable default value is used.
+func (f *fileValues) set(key string, line int, value string) (err error) {
+	envKey, ok := f.keyToEnv[key]
+	switch {
+	case f.isSection(key):
+		return fmt.Errorf("line %d: %w: %s", line, errKeyIsSection, key)
+	case !ok:
+		return fmt.Errorf("line %d: %w: %s", line, errKeyUnknown, key)
+	}
+
+	if previousLine, ok := f.keyToLine[key]; ok {
+		return fmt.Errorf("line %d: %w: %s is already set at line %d",
+			line, errKeyDuplicated, key, previousLine)
+	}
+	f.keyToLine[key] = line
+
+	if value != "" {
+		f.envValues[envKey] = value
+	}
+	return nil
+}
+
+var errYAMLNotMapping = errors.New("YAML document must be a mapping")
+
+func parseYAML(b []byte, values *fileValues) (err error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(b, &document); err != nil {
+		return err
+	}
+	if len(document.Content) == 0 { // empty document
+		return nil
+	}
+
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: %w", root.Line, errYAMLNotMapping)
+	}
+	return walkYAML(root, "", values)
+}
+
+func walkYAML(mapping *yaml.Node, prefix string, values *fileValues) (err error) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		keyNode, valueNode := mapping.Content[i], mapping.Content[i+1]
+		key := keyNode.Value
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if valueNode.Kind == yaml.AliasNode {
+			valueNode = valueNode.Alias
+		}
+
+		emptySection := valueNode.Tag == "!!null" && values.isSection(key)
+		if valueNode.Kind == yaml.MappingNode || emptySection {
+			if err := values.section(key, keyNode.Line); err != nil {
+				return err
+			}
+			if err := walkYAML(valueNode, key, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := yamlValue(valueNode)
+		if err != nil {
+			return fmt.Errorf("line %d: %w: %s", valueNode.Line, err, key)
+		}
+
+		if err := values.set(key, keyNode.Line, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlValue returns the value of a YAML node as an environment variable
+// value, where booleans are converted to on or off, and lists are
+// converted to comma separated values.
+func yamlValue(node *yaml.Node) (value string, err error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!null":
+			return "", nil
+		case "!!bool":
+			if strings.EqualFold(node.Value, "true") {
+				return "on", nil
+			}
+			return "off", nil
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		elements := make([]string, len(node.Content))
+		for i, element := range node.Content {
+			if element.Kind != yaml.ScalarNode {
+				return "", errValueInvalid
+			}
+			elements[i] = element.Value
+		}
+		return strings.Join(elements, ","), nil
+	default:
+		return "", errValueInvalid
+	}
+}
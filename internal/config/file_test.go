@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseConfigFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		content   string
+		extension string
+		envValues map[string]string
+		err       error
+	}{
+		"unsupported extension": {
+			extension: ".json",
+			err: errors.New(`configuration file format is not supported: ` +
+				`".json", it must be .yaml, .yml or .toml`),
+		},
+		"empty YAML": {
+			extension: ".yml",
+			envValues: map[string]string{},
+		},
+		"YAML": {
+			content: `
+unbound:
+  providers: [cloudflare, google]
+  caching: false
+  verbosity: 3
+blacklist:
+  block_ads: on
+  blocked_hosts:
+    - ads.com
+    - tracker.net
+  blocked_ips: []
+local_names:
+  domain: lan
+admin:
+check_dns: true
+update_period: 12h
+`,
+			extension: ".yaml",
+			envValues: map[string]string{
+				"PROVIDERS":       "cloudflare,google",
+				"CACHING":         "off",
+				"VERBOSITY":       "3",
+				"BLOCK_ADS":       "on",
+				"BLOCK_HOSTNAMES": "ads.com,tracker.net",
+				"LOCAL_DOMAIN":    "lan",
+				"CHECK_DNS":       "on",
+				"UPDATE_PERIOD":   "12h",
+			},
+		},
+		"YAML unknown key": {
+			content: `
+blacklist:
+  block_ads: true
+  block_everything: true
+`,
+			extension: ".yaml",
+			err:       errors.New("line 4: unknown key: blacklist.block_everything"),
+		},
+		"YAML section with value": {
+			content:   "unbound: 1\n",
+			extension: ".yaml",
+			err:       errors.New("line 1: key is a section and cannot have a value: unbound"),
+		},
+		"YAML value with section": {
+			content:   "check_dns:\n  value: true\n",
+			extension: ".yaml",
+			err:       errors.New("line 1: key must be a section: check_dns"),
+		},
+		"YAML duplicated key": {
+			content:   "check_dns: true\ncheck_dns: false\n",
+			extension: ".yaml",
+			err:       errors.New("line 2: duplicated key: check_dns is already set at line 1"),
+		},
+		"YAML nested list": {
+			content:   "unbound:\n  providers: [[google]]\n",
+			extension: ".yaml",
+			err: errors.New("line 2: value must be a string, a number, " +
+				"a boolean or a list of these: unbound.providers"),
+		},
+		"YAML syntax error": {
+			content:   "unbound:\n  providers: google\n   caching: true\n",
+			extension: ".yaml",
+			err:       errors.New("yaml: line 3: mapping values are not allowed in this context"),
+		},
+		"TOML": {
+			content: `# DNS settings
+check_dns = false
+update_period = "12h"
+
+[unbound]
+providers = [
+  "cloudflare", # first
+  'google',
+]
+listening_port = 5_353
+
+[blacklist]
+block_ads = true
+blocked_hosts = ["ads.com"]
+allowed_hosts = []
+
+[admin]
+token = "secret#token"
+`,
+			extension: ".toml",
+			envValues: map[string]string{
+				"CHECK_DNS":       "off",
+				"UPDATE_PERIOD":   "12h",
+				"PROVIDERS":       "cloudflare,google",
+				"LISTENINGPORT":   "5353",
+				"BLOCK_ADS":       "on",
+				"BLOCK_HOSTNAMES": "ads.com",
+				"ADMIN_TOKEN":     "secret#token",
+			},
+		},
+		"TOML unknown table": {
+			content:   "check_dns = true\n\n[blocking]\n",
+			extension: ".toml",
+			err:       errors.New("line 3: unknown key: blocking"),
+		},
+		"TOML unknown key": {
+			content:   "[query_log]\nstdout = true\nsize = 1\n",
+			extension: ".toml",
+			err:       errors.New("line 3: unknown key: query_log.size"),
+		},
+		"TOML malformed value": {
+			content:   "[unbound]\nproviders = [\"google\"] x\n",
+			extension: ".toml",
+			err:       errors.New("(2, 24): parsing error: no value can start with x"),
+		},
+		"TOML inline table": {
+			content:   "admin = { token = \"x\" }\n",
+			extension: ".toml",
+			envValues: map[string]string{
+				"ADMIN_TOKEN": "x",
+			},
+		},
+		"TOML array of tables": {
+			content:   "check_dns = true\n\n[[admin]]\ntoken = \"x\"\n",
+			extension: ".toml",
+			err: errors.New("line 3: value must be a string, a number, " +
+				"a boolean or a list of these: admin"),
+		},
+		"TOML duplicated key": {
+			content:   "check_dns = true\ncheck_dns = false\n",
+			extension: ".toml",
+			err:       errors.New("(2, 1): The following key was defined twice: check_dns"),
+		},
+		"TOML missing equal sign": {
+			content:   "check_dns true\n",
+			extension: ".toml",
+			err:       errors.New("(1, 11): was expecting token =, but got keys cannot contain new lines instead"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			envValues, err := parseConfigFile([]byte(testCase.content), testCase.extension)
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.envValues, envValues)
+		})
+	}
+}
+
+func Test_parseFlags(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		args      []string
+		envValues map[string]string
+		err       error
+	}{
+		"no flag": {
+			envValues: map[string]string{},
+		},
+		"flags": {
+			args: []string{"--config-file=/config.yml", "-block-ads", "on", "--providers=google"},
+			envValues: map[string]string{
+				"CONFIG_FILE": "/config.yml",
+				"BLOCK_ADS":   "on",
+				"PROVIDERS":   "google",
+			},
+		},
+		"unknown flag": {
+			args: []string{"--block-everything=on"},
+			err:  errors.New("flag provided but not defined: -block-everything"),
+		},
+		"unexpected argument": {
+			args: []string{"--block-ads=on", "run"},
+			err:  errors.New("unexpected argument: run"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			envValues, err := parseFlags(testCase.args)
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.envValues, envValues)
+		})
+	}
+}
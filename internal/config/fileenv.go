@@ -0,0 +1,70 @@
+package config
+
+import (
+	"time"
+
+	"github.com/qdm12/golibs/params"
+)
+
+// fileEnv reads environment variables, and falls back on the values
+// of the configuration file before the default values, such that an
+// environment variable or one of its retro-compatible keys takes
+// precedence over the configuration file.
+type fileEnv struct {
+	params.Env
+	envValues map[string]string
+}
+
+// options appends the configuration file value as the default value,
+// overriding any default value previously set.
+func (e *fileEnv) options(key string, optionSetters []params.OptionSetter) []params.OptionSetter {
+	value, ok := e.envValues[key]
+	if !ok {
+		return optionSetters
+	}
+	optionSetters = optionSetters[:len(optionSetters):len(optionSetters)]
+	return append(optionSetters, params.Default(value))
+}
+
+func (e *fileEnv) Get(key string, optionSetters ...params.OptionSetter) (value string, err error) {
+	return e.Env.Get(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) Int(key string, optionSetters ...params.OptionSetter) (n int, err error) {
+	return e.Env.Int(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) IntRange(key string, lower, upper int,
+	optionSetters ...params.OptionSetter) (n int, err error) {
+	return e.Env.IntRange(key, lower, upper, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) YesNo(key string, optionSetters ...params.OptionSetter) (yes bool, err error) {
+	return e.Env.YesNo(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) OnOff(key string, optionSetters ...params.OptionSetter) (on bool, err error) {
+	return e.Env.OnOff(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) Inside(key string, possibilities []string,
+	optionSetters ...params.OptionSetter) (value string, err error) {
+	return e.Env.Inside(key, possibilities, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) CSV(key string, optionSetters ...params.OptionSetter) (values []string, err error) {
+	return e.Env.CSV(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) Duration(key string, optionSetters ...params.OptionSetter) (
+	duration time.Duration, err error) {
+	return e.Env.Duration(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) Port(key string, optionSetters ...params.OptionSetter) (port uint16, err error) {
+	return e.Env.Port(key, e.options(key, optionSetters)...)
+}
+
+func (e *fileEnv) Path(key string, optionSetters ...params.OptionSetter) (path string, err error) {
+	return e.Env.Path(key, e.options(key, optionSetters)...)
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"strings"
+
+	"inet.af/netaddr"
+)
+
+// fileSettings are the settings laid out as in the configuration file,
+// to print the effective settings in YAML or JSON.
+type fileSettings struct {
+	Unbound        unboundFileSettings    `json:"unbound" yaml:"unbound"`
+	Blacklist      blacklistFileSettings  `json:"blacklist" yaml:"blacklist"`
+	LocalNames     localNamesFileSettings `json:"local_names" yaml:"local_names"`
+	QueryLog       queryLogFileSettings   `json:"query_log" yaml:"query_log"`
+	MetricsAddress string                 `json:"metrics_address" yaml:"metrics_address"`
+	Admin          adminFileSettings      `json:"admin" yaml:"admin"`
+	CheckDNS       bool                   `json:"check_dns" yaml:"check_dns"`
+	UpdatePeriod   string                 `json:"update_period" yaml:"update_period"`
+}
+
+type unboundFileSettings struct {
+	Providers          []string `json:"providers" yaml:"providers"`
+	ListeningPort      uint16   `json:"listening_port" yaml:"listening_port"`
+	Caching            bool     `json:"caching" yaml:"caching"`
+	IPv4               bool     `json:"ipv4" yaml:"ipv4"`
+	IPv6               bool     `json:"ipv6" yaml:"ipv6"`
+	Verbosity          uint8    `json:"verbosity" yaml:"verbosity"`
+	VerbosityDetails   uint8    `json:"verbosity_details" yaml:"verbosity_details"`
+	ValidationLogLevel uint8    `json:"validation_log_level" yaml:"validation_log_level"`
+	DnstapSocket       string   `json:"dnstap_socket" yaml:"dnstap_socket"`
+}
+
+type blacklistFileSettings struct {
+	BlockMalicious    bool     `json:"block_malicious" yaml:"block_malicious"`
+	BlockSurveillance bool     `json:"block_surveillance" yaml:"block_surveillance"`
+	BlockAds          bool     `json:"block_ads" yaml:"block_ads"`
+	MonitorOnly       []string `json:"monitor_only" yaml:"monitor_only"`
+	AllowedHosts      []string `json:"allowed_hosts" yaml:"allowed_hosts"`
+	BlockedHosts      []string `json:"blocked_hosts" yaml:"blocked_hosts"`
+	BlockedIPs        []string `json:"blocked_ips" yaml:"blocked_ips"`
+	PrivateAddresses  []string `json:"private_addresses" yaml:"private_addresses"`
+}
+
+type localNamesFileSettings struct {
+	HostsFiles   []string `json:"hosts_files" yaml:"hosts_files"`
+	LeasesFiles  []string `json:"leases_files" yaml:"leases_files"`
+	Domain       string   `json:"domain" yaml:"domain"`
+	UpdatePeriod string   `json:"update_period" yaml:"update_period"`
+}
+
+type queryLogFileSettings struct {
+	Stdout         bool   `json:"stdout" yaml:"stdout"`
+	File           string `json:"file" yaml:"file"`
+	FileMaxSize    int64  `json:"file_max_size" yaml:"file_max_size"`
+	FileMaxBackups int    `json:"file_max_backups" yaml:"file_max_backups"`
+	MemorySize     int    `json:"memory_size" yaml:"memory_size"`
+	AnonymizeIPs   bool   `json:"anonymize_ips" yaml:"anonymize_ips"`
+}
+
+// adminFileSettings has the admin token redacted.
+type adminFileSettings struct {
+	Address   string `json:"address" yaml:"address"`
+	Token     string `json:"token" yaml:"token"`
+	StateFile string `json:"state_file" yaml:"state_file"`
+}
+
+func newFileSettings(settings Settings) (s fileSettings) {
+	s.Unbound = unboundFileSettings{
+		Providers:          make([]string, len(settings.Unbound.Providers)),
+		ListeningPort:      settings.Unbound.ListeningPort,
+		Caching:            settings.Unbound.Caching,
+		IPv4:               settings.Unbound.IPv4,
+		IPv6:               settings.Unbound.IPv6,
+		Verbosity:          settings.Unbound.VerbosityLevel,
+		VerbosityDetails:   settings.Unbound.VerbosityDetailsLevel,
+		ValidationLogLevel: settings.Unbound.ValidationLogLevel,
+		DnstapSocket:       settings.Unbound.DnstapSocket,
+	}
+	for i, provider := range settings.Unbound.Providers {
+		s.Unbound.Providers[i] = strings.ToLower(provider.String())
+	}
+
+	s.Blacklist = blacklistFileSettings{
+		BlockMalicious:    settings.Blacklist.BlockMalicious,
+		BlockSurveillance: settings.Blacklist.BlockSurveillance,
+		BlockAds:          settings.Blacklist.BlockAds,
+		MonitorOnly:       nonNil(settings.Blacklist.MonitorOnly),
+		AllowedHosts:      nonNil(settings.Blacklist.AllowedHosts),
+		BlockedHosts:      nonNil(settings.Blacklist.AddBlockedHosts),
+		BlockedIPs: ipsToStrings(settings.Blacklist.AddBlockedIPs,
+			settings.Blacklist.AddBlockedIPPrefixes),
+		PrivateAddresses: ipsToStrings(settings.Blacklist.PrivateIPs,
+			settings.Blacklist.PrivateIPPrefixes),
+	}
+
+	s.LocalNames = localNamesFileSettings{
+		HostsFiles:   nonNil(settings.LocalNames.HostsFiles),
+		LeasesFiles:  nonNil(settings.LocalNames.LeasesFiles),
+		Domain:       settings.LocalNames.Domain,
+		UpdatePeriod: settings.LocalNames.UpdatePeriod.String(),
+	}
+
+	s.QueryLog = queryLogFileSettings{
+		Stdout:         settings.QueryLog.Stdout,
+		File:           settings.QueryLog.File,
+		FileMaxSize:    settings.QueryLog.FileMaxBytes,
+		FileMaxBackups: settings.QueryLog.FileMaxBackups,
+		MemorySize:     settings.QueryLog.RingSize,
+		AnonymizeIPs:   settings.QueryLog.AnonymizeIPs,
+	}
+
+	s.MetricsAddress = settings.MetricsAddress
+
+	s.Admin = adminFileSettings{
+		Address:   settings.Admin.Address,
+		StateFile: settings.Admin.StateFile,
+	}
+	if settings.Admin.Token != "" {
+		s.Admin.Token = "[redacted]"
+	}
+
+	s.CheckDNS = settings.CheckDNS
+	s.UpdatePeriod = settings.UpdatePeriod.String()
+	return s
+}
+
+// ipsToStrings returns the IP addresses and networks as strings,
+// and an empty slice if there are none.
+func ipsToStrings(ips []netaddr.IP, ipPrefixes []netaddr.IPPrefix) (values []string) {
+	values = make([]string, 0, len(ips)+len(ipPrefixes))
+	for _, ip := range ips {
+		values = append(values, ip.String())
+	}
+	for _, ipPrefix := range ipPrefixes {
+		values = append(values, ipPrefix.String())
+	}
+	return values
+}
+
+// nonNil returns an empty slice instead of nil,
+// so it is printed as an empty list.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
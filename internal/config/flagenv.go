@@ -0,0 +1,84 @@
+package config
+
+import (
+	"time"
+
+	"github.com/qdm12/golibs/params"
+)
+
+// flagEnv uses the values of the command line flags, and falls back
+// on the environment variables it wraps for keys without a flag value,
+// such that a flag takes precedence over an environment variable,
+// its retro-compatible keys and the configuration file.
+type flagEnv struct {
+	params.Env
+	flagValues map[string]string
+}
+
+// keyAndOptions returns the key and options to read a value with.
+// If a flag value is set for the key, the empty key is returned since
+// no environment variable can be set for it, together with the flag
+// value as the default value and without any retro-compatible keys,
+// so the flag value is parsed like an environment variable value.
+func (e *flagEnv) keyAndOptions(key string, optionSetters []params.OptionSetter) (
+	string, []params.OptionSetter) {
+	value := e.flagValues[key]
+	if value == "" {
+		return key, optionSetters
+	}
+	optionSetters = optionSetters[:len(optionSetters):len(optionSetters)]
+	return "", append(optionSetters, params.RetroKeys(nil, nil), params.Default(value))
+}
+
+func (e *flagEnv) Get(key string, optionSetters ...params.OptionSetter) (value string, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.Get(key, optionSetters...)
+}
+
+func (e *flagEnv) Int(key string, optionSetters ...params.OptionSetter) (n int, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.Int(key, optionSetters...)
+}
+
+func (e *flagEnv) IntRange(key string, lower, upper int,
+	optionSetters ...params.OptionSetter) (n int, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.IntRange(key, lower, upper, optionSetters...)
+}
+
+func (e *flagEnv) YesNo(key string, optionSetters ...params.OptionSetter) (yes bool, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.YesNo(key, optionSetters...)
+}
+
+func (e *flagEnv) OnOff(key string, optionSetters ...params.OptionSetter) (on bool, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.OnOff(key, optionSetters...)
+}
+
+func (e *flagEnv) Inside(key string, possibilities []string,
+	optionSetters ...params.OptionSetter) (value string, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.Inside(key, possibilities, optionSetters...)
+}
+
+func (e *flagEnv) CSV(key string, optionSetters ...params.OptionSetter) (values []string, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.CSV(key, optionSetters...)
+}
+
+func (e *flagEnv) Duration(key string, optionSetters ...params.OptionSetter) (
+	duration time.Duration, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.Duration(key, optionSetters...)
+}
+
+func (e *flagEnv) Port(key string, optionSetters ...params.OptionSetter) (port uint16, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.Port(key, optionSetters...)
+}
+
+func (e *flagEnv) Path(key string, optionSetters ...params.OptionSetter) (path string, err error) {
+	key, optionSetters = e.keyAndOptions(key, optionSetters)
+	return e.Env.Path(key, optionSetters...)
+}
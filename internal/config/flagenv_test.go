@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/qdm12/golibs/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_flagEnv_Get(t *testing.T) {
+	t.Parallel()
+
+	// Each test case uses its own environment variable keys
+	// since the environment is shared by the parallel subtests.
+	testCases := map[string]struct {
+		key        string
+		retroKey   string
+		environ    map[string]string
+		fileValues map[string]string
+		flagValues map[string]string
+		value      string
+	}{
+		"default value": {
+			key:   "FLAG_ENV_TEST_DEFAULT",
+			value: "default",
+		},
+		"configuration file": {
+			key:        "FLAG_ENV_TEST_FILE",
+			fileValues: map[string]string{"FLAG_ENV_TEST_FILE": "file"},
+			value:      "file",
+		},
+		"environment variable over configuration file": {
+			key:        "FLAG_ENV_TEST_ENV",
+			environ:    map[string]string{"FLAG_ENV_TEST_ENV": "env"},
+			fileValues: map[string]string{"FLAG_ENV_TEST_ENV": "file"},
+			value:      "env",
+		},
+		"flag over environment variable and configuration file": {
+			key:        "FLAG_ENV_TEST_FLAG",
+			environ:    map[string]string{"FLAG_ENV_TEST_FLAG": "env"},
+			fileValues: map[string]string{"FLAG_ENV_TEST_FLAG": "file"},
+			flagValues: map[string]string{"FLAG_ENV_TEST_FLAG": "flag"},
+			value:      "flag",
+		},
+		"flag over retro-compatible environment variable": {
+			key:        "FLAG_ENV_TEST_RETRO",
+			retroKey:   "FLAG_ENV_TEST_RETRO_OLD",
+			environ:    map[string]string{"FLAG_ENV_TEST_RETRO_OLD": "env"},
+			flagValues: map[string]string{"FLAG_ENV_TEST_RETRO": "flag"},
+			value:      "flag",
+		},
+		"empty flag": {
+			key:        "FLAG_ENV_TEST_EMPTY",
+			environ:    map[string]string{"FLAG_ENV_TEST_EMPTY": "env"},
+			flagValues: map[string]string{"FLAG_ENV_TEST_EMPTY": ""},
+			value:      "env",
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for key, value := range testCase.environ {
+				key := key
+				err := os.Setenv(key, value)
+				require.NoError(t, err)
+				t.Cleanup(func() { _ = os.Unsetenv(key) })
+			}
+
+			env := &flagEnv{
+				Env: &fileEnv{
+					Env:       params.NewEnv(),
+					envValues: testCase.fileValues,
+				},
+				flagValues: testCase.flagValues,
+			}
+
+			onRetro := func(oldKey, newKey string) {}
+			value, err := env.Get(testCase.key, params.Default("default"),
+				params.RetroKeys([]string{testCase.retroKey}, onRetro))
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.value, value)
+		})
+	}
+}
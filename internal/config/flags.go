@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const configFileKey = "CONFIG_FILE"
+
+var errArgumentUnexpected = errors.New("unexpected argument")
+
+// parseFlags parses the command line flags, each named after its
+// environment variable such that for example --block-ads=on sets
+// BLOCK_ADS, and returns the values set by environment variable key.
+func parseFlags(args []string) (envValues map[string]string, err error) {
+	flagSet := flag.NewFlagSet("dns", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	flagToEnv := make(map[string]string, len(settingKeys)+1)
+	envKeys := []string{configFileKey}
+	for _, key := range settingKeys {
+		envKeys = append(envKeys, key.env)
+	}
+	for _, envKey := range envKeys {
+		name := flagName(envKey)
+		flagToEnv[name] = envKey
+		flagSet.String(name, "", "environment variable "+envKey)
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+	if flagSet.NArg() > 0 {
+		return nil, fmt.Errorf("%w: %s", errArgumentUnexpected, flagSet.Arg(0))
+	}
+
+	envValues = make(map[string]string, flagSet.NFlag())
+	flagSet.Visit(func(f *flag.Flag) {
+		envValues[flagToEnv[f.Name]] = f.Value.String()
+	})
+	return envValues, nil
+}
+
+func flagName(envKey string) string {
+	return strings.ToLower(strings.ReplaceAll(envKey, "_", "-"))
+}
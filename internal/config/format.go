@@ -14,13 +14,16 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 		disabled = "disabled"
 		enabled  = "enabled"
 	)
-	checkDNS, update := disabled, disabled
+	checkDNS, update, metrics := disabled, disabled, disabled
 	if s.CheckDNS {
 		checkDNS = enabled
 	}
 	if s.UpdatePeriod > 0 {
 		update = fmt.Sprintf("every %s", s.UpdatePeriod)
 	}
+	if s.MetricsAddress != "" {
+		metrics = "listening on " + s.MetricsAddress
+	}
 
 	lines = append(lines, subSection+"Unbound settings:")
 	for _, line := range s.Unbound.Lines() {
@@ -31,6 +34,19 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 	for _, line := range s.Blacklist.Lines(indent, subSection) {
 		lines = append(lines, indent+line)
 	}
+
+	lines = append(lines, subSection+"Local names settings:")
+	for _, line := range s.LocalNames.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines, subSection+"Query log settings:")
+	for _, line := range s.QueryLog.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines, subSection+"Metrics: "+metrics)
+	lines = append(lines, s.Admin.Lines(indent, subSection)...)
 	lines = append(lines, subSection+"Check DNS: "+checkDNS)
 	lines = append(lines, subSection+"Update: "+update)
 
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/hosts"
+	"github.com/qdm12/golibs/params"
+)
+
+var errLocalDomainInvalid = errors.New("local domain is invalid")
+
+func getLocalNamesSettings(reader *reader) (settings hosts.Settings, errs []error) {
+	var err error
+	settings.HostsFiles, err = reader.env.CSV("HOSTS_FILES", params.CaseSensitiveValue())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable HOSTS_FILES: %w", err))
+	}
+
+	settings.LeasesFiles, err = reader.env.CSV("DHCP_LEASES_FILES", params.CaseSensitiveValue())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable DHCP_LEASES_FILES: %w", err))
+	}
+
+	settings.Domain, err = reader.env.Get("LOCAL_DOMAIN")
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable LOCAL_DOMAIN: %w", err))
+	}
+	settings.Domain = strings.Trim(settings.Domain, ".")
+	if settings.Domain != "" {
+		if _, ok := dns.IsDomainName(settings.Domain); !ok {
+			errs = append(errs, fmt.Errorf("environment variable LOCAL_DOMAIN: %w: %s",
+				errLocalDomainInvalid, settings.Domain))
+		}
+	}
+
+	settings.UpdatePeriod, err = reader.env.Duration("LOCAL_NAMES_UPDATE_PERIOD", params.Default("1m"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable LOCAL_NAMES_UPDATE_PERIOD: %w", err))
+	}
+
+	return settings, errs
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/golibs/params"
+)
+
+func getQueryLogSettings(reader *reader) (settings querylog.Settings, errs []error) {
+	var err error
+	settings.Stdout, err = reader.env.OnOff("QUERY_LOG_STDOUT", params.Default("off"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable QUERY_LOG_STDOUT: %w", err))
+	}
+
+	settings.File, err = reader.env.Get("QUERY_LOG_FILE", params.CaseSensitiveValue())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable QUERY_LOG_FILE: %w", err))
+	}
+
+	fileMaxBytes, err := reader.env.Int("QUERY_LOG_FILE_MAX_SIZE", params.Default("10000000"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable QUERY_LOG_FILE_MAX_SIZE: %w", err))
+	}
+	settings.FileMaxBytes = int64(fileMaxBytes)
+
+	settings.FileMaxBackups, err = reader.env.Int("QUERY_LOG_FILE_MAX_BACKUPS", params.Default("3"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable QUERY_LOG_FILE_MAX_BACKUPS: %w", err))
+	}
+
+	settings.RingSize, err = reader.env.Int("QUERY_LOG_MEMORY_SIZE", params.Default("0"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable QUERY_LOG_MEMORY_SIZE: %w", err))
+	}
+
+	settings.AnonymizeIPs, err = reader.env.OnOff("QUERY_LOG_ANONYMIZE_IPS", params.Default("off"))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("environment variable QUERY_LOG_ANONYMIZE_IPS: %w", err))
+	}
+
+	return settings, errs
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"fmt"
+
+	"github.com/qdm12/dns/internal/admin"
 	"github.com/qdm12/golibs/logging"
 	"github.com/qdm12/golibs/params"
 	"github.com/qdm12/golibs/verification"
@@ -9,7 +12,8 @@ import (
 //go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Reader
 
 type Reader interface {
-	ReadSettings() (s Settings, err error)
+	ReadSettings(args []string) (s Settings, err error)
+	ReadAdminSettings() (settings admin.Settings, err error)
 }
 
 type reader struct {
@@ -26,9 +30,67 @@ func NewReader(logger logging.Logger) Reader {
 	}
 }
 
-func (r *reader) ReadSettings() (s Settings, err error) {
-	err = s.get(r)
-	return s, err
+// ReadSettings reads the settings from the command line flags given,
+// the environment variables and the configuration file, in this order
+// of precedence, before using the default values. If the settings are
+// invalid, the error returned is of type Errors with all the errors.
+func (r *reader) ReadSettings(args []string) (s Settings, err error) {
+	flagValues, err := parseFlags(args)
+	if err != nil {
+		return s, fmt.Errorf("cannot parse flags: %w", err)
+	}
+
+	fileReader, err := r.withConfigFile(flagValues)
+	if err != nil {
+		return s, err
+	}
+
+	if errs := s.get(fileReader); len(errs) > 0 {
+		return s, Errors(errs)
+	}
+	return s, nil
+}
+
+// ReadAdminSettings reads the admin API settings only,
+// for example to use the admin API client.
+func (r *reader) ReadAdminSettings() (settings admin.Settings, err error) {
+	fileReader, err := r.withConfigFile(nil)
+	if err != nil {
+		return settings, err
+	}
+	settings, errs := getAdminSettings(fileReader)
+	if len(errs) > 0 {
+		return settings, Errors(errs)
+	}
+	return settings, nil
+}
+
+// withConfigFile returns a reader using the flag values given first,
+// and falling back on the values of the configuration file set by
+// CONFIG_FILE, if any, before the defaults.
+func (r *reader) withConfigFile(flagValues map[string]string) (fileReader *reader, err error) {
+	env := &flagEnv{Env: r.env, flagValues: flagValues}
+	fileReader = &reader{
+		env:      env,
+		logger:   r.logger,
+		verifier: r.verifier,
+	}
+
+	path, err := env.Get(configFileKey, params.CaseSensitiveValue())
+	if err != nil {
+		return nil, fmt.Errorf("environment variable %s: %w", configFileKey, err)
+	} else if path == "" {
+		return fileReader, nil
+	}
+
+	envValues, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flags take precedence over the configuration file.
+	env.Env = &fileEnv{Env: r.env, envValues: envValues}
+	return fileReader, nil
 }
 
 func (r *reader) onRetroActive(oldKey, newKey string) {
@@ -2,40 +2,83 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/qdm12/dns/internal/admin"
 	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/hosts"
+	"github.com/qdm12/dns/pkg/querylog"
 	"github.com/qdm12/dns/pkg/unbound"
 	"github.com/qdm12/golibs/params"
 )
 
 type Settings struct {
-	Unbound      unbound.Settings
-	Blacklist    blacklist.BuilderSettings
-	CheckDNS     bool
-	UpdatePeriod time.Duration
+	Unbound    unbound.Settings
+	Blacklist  blacklist.BuilderSettings
+	LocalNames hosts.Settings
+	QueryLog   querylog.Settings
+	// MetricsAddress is the listening address of the Prometheus
+	// metrics server. It is empty if metrics are disabled.
+	MetricsAddress string
+	Admin          admin.Settings
+	CheckDNS       bool
+	UpdatePeriod   time.Duration
 }
 
-func (settings *Settings) get(reader *reader) (err error) {
-	settings.Unbound, err = getUnboundSettings(reader)
-	if err != nil {
-		return err
+// Errors are the errors found reading the settings.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
 	}
+	return strings.Join(messages, "; ")
+}
+
+// get reads the settings, and returns all the errors
+// found instead of stopping at the first error.
+func (settings *Settings) get(reader *reader) (errs []error) {
+	var settingsErrs []error
+	settings.Unbound, settingsErrs = getUnboundSettings(reader)
+	errs = append(errs, settingsErrs...)
 
 	// Blacklist building settings
-	settings.Blacklist, err = getBlacklistSettings(reader)
+	settings.Blacklist, settingsErrs = getBlacklistSettings(reader)
+	errs = append(errs, settingsErrs...)
+	settings.LocalNames, settingsErrs = getLocalNamesSettings(reader)
+	errs = append(errs, settingsErrs...)
+	settings.QueryLog, settingsErrs = getQueryLogSettings(reader)
+	errs = append(errs, settingsErrs...)
+
+	var err error
+	settings.MetricsAddress, err = reader.env.Get("METRICS_ADDRESS")
 	if err != nil {
-		return err
+		errs = append(errs, fmt.Errorf("environment variable METRICS_ADDRESS: %w", err))
+	}
+	settings.Admin, settingsErrs = getAdminSettings(reader)
+	errs = append(errs, settingsErrs...)
+	// The dashboard needs recent queries kept in memory
+	if settings.Admin.Enabled() && settings.QueryLog.RingSize == 0 {
+		const defaultDashboardRingSize = 10000
+		settings.QueryLog.RingSize = defaultDashboardRingSize
 	}
+	// Unbound replies are parsed for the query log and the metrics
+	settings.Unbound.LogReplies = settings.QueryLog.Enabled() || settings.MetricsAddress != ""
+	// Unbound is controlled for the metrics statistics
+	// and the admin API cache operations.
+	settings.Unbound.RemoteControl = settings.MetricsAddress != "" || settings.Admin.Enabled()
+
 	settings.CheckDNS, err = reader.env.OnOff("CHECK_DNS", params.Default("on"),
 		params.RetroKeys([]string{"CHECK_UNBOUND"}, reader.onRetroActive))
 	if err != nil {
-		return fmt.Errorf("environment variable CHECK_DNS: %w", err)
+		errs = append(errs, fmt.Errorf("environment variable CHECK_DNS: %w", err))
 	}
 	settings.UpdatePeriod, err = reader.env.Duration("UPDATE_PERIOD", params.Default("24h"))
 	if err != nil {
-		return fmt.Errorf("environment variable UPDATE_PERIOD: %w", err)
+		errs = append(errs, fmt.Errorf("environment variable UPDATE_PERIOD: %w", err))
 	}
 
-	return nil
+	return errs
 }
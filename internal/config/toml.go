@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+func parseTOML(b []byte, values *fileValues) (err error) {
+	tree, err := toml.LoadBytes(b)
+	if err != nil {
+		return err
+	}
+	return walkTOML(tree, "", values)
+}
+
+func walkTOML(tree *toml.Tree, prefix string, values *fileValues) (err error) {
+	// Sort keys by position so the first error
+	// in the file is the one reported.
+	keys := tree.Keys()
+	sort.Slice(keys, func(i, j int) bool {
+		iPosition := tree.GetPositionPath([]string{keys[i]})
+		jPosition := tree.GetPositionPath([]string{keys[j]})
+		if iPosition.Line != jPosition.Line {
+			return iPosition.Line < jPosition.Line
+		}
+		return iPosition.Col < jPosition.Col
+	})
+
+	for _, tomlKey := range keys {
+		key := tomlKey
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		line := tree.GetPositionPath([]string{tomlKey}).Line
+
+		node := tree.GetPath([]string{tomlKey})
+		if subTree, ok := node.(*toml.Tree); ok {
+			if err := values.section(key, line); err != nil {
+				return err
+			}
+			if err := walkTOML(subTree, key, values); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := tomlValue(node)
+		if err != nil {
+			return fmt.Errorf("line %d: %w: %s", line, err, key)
+		}
+
+		if err := values.set(key, line, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tomlValue returns the TOML value given as an environment
+// variable value, where booleans are converted to on or off, and
+// arrays are converted to comma separated values.
+func tomlValue(node interface{}) (value string, err error) {
+	switch node := node.(type) {
+	case []interface{}:
+		elements := make([]string, len(node))
+		for i, element := range node {
+			if _, ok := element.([]interface{}); ok {
+				return "", errValueInvalid
+			}
+			elements[i], err = tomlValue(element)
+			if err != nil {
+				return "", err
+			}
+		}
+		return strings.Join(elements, ","), nil
+	case string:
+		return node, nil
+	case bool:
+		if node {
+			return "on", nil
+		}
+		return "off", nil
+	case int64:
+		return strconv.FormatInt(node, 10), nil
+	case float64:
+		return strconv.FormatFloat(node, 'f', -1, 64), nil
+	default:
+		return "", errValueInvalid
+	}
+}
@@ -2,10 +2,13 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 )
 
+var ErrNotReady = errors.New("not ready")
+
 // IsHealthy checks the localhost DNS UDP server is working by
 // resolving github.com.
 func IsHealthy() (err error) {
@@ -22,3 +25,15 @@ func IsHealthy() (err error) {
 	}
 	return nil
 }
+
+// ReadyCheck returns a healthcheck failing while the ready function
+// returns false, for example while a DNS server warms up its cache,
+// and running the healthcheck given otherwise.
+func ReadyCheck(ready func() bool, healthcheck func() error) func() error {
+	return func() (err error) {
+		if !ready() {
+			return ErrNotReady
+		}
+		return healthcheck()
+	}
+}
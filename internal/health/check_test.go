@@ -0,0 +1,52 @@
+package health
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReadyCheck(t *testing.T) {
+	t.Parallel()
+
+	errUnhealthy := errors.New("unhealthy")
+
+	testCases := map[string]struct {
+		ready          bool
+		healthcheckErr error
+		err            error
+	}{
+		"not ready": {
+			err: ErrNotReady,
+		},
+		"ready and unhealthy": {
+			ready:          true,
+			healthcheckErr: errUnhealthy,
+			err:            errUnhealthy,
+		},
+		"ready and healthy": {
+			ready: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ready := func() bool { return testCase.ready }
+			healthcheck := func() error { return testCase.healthcheckErr }
+
+			err := ReadyCheck(ready, healthcheck)()
+
+			if testCase.err != nil {
+				require.Error(t, err)
+				assert.Equal(t, testCase.err.Error(), err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package blacklist
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// SourceSettings is the list of the sources of entries
+// blocked or allowed in the settings, and not by a block list.
+const SourceSettings = "settings"
+
+// Source is the source of a blocked hostname, IP address or IP network.
+type Source struct {
+	Category string `json:"category"`
+	// List is the URL of the block list, or SourceSettings for
+	// entries added in the settings. It is empty if unknown.
+	List string `json:"list,omitempty"`
+	// Rule is the original rule found in the list.
+	Rule string `json:"rule"`
+}
+
+// Attribution indexes the sources of each blocked entry, as well
+// as the allowed hostnames and IP addresses overriding them.
+type Attribution struct {
+	sources          []source
+	hostnames        map[string][]sourceRef
+	ips              map[netaddr.IP][]sourceRef
+	ipPrefixes       map[netaddr.IPPrefix][]sourceRef
+	allowedHostnames []string
+	allowedIPs       map[netaddr.IP]struct{}
+}
+
+type source struct {
+	category string
+	list     string
+}
+
+// sourceRef references a source of the attribution sources slice,
+// together with the original rule if it differs from the entry,
+// to use less memory for large block lists.
+type sourceRef struct {
+	index int
+	rule  string
+}
+
+func newAttribution(allowedHostnames []string, allowedIPs []netaddr.IP) *Attribution {
+	a := &Attribution{
+		hostnames:        make(map[string][]sourceRef),
+		ips:              make(map[netaddr.IP][]sourceRef),
+		ipPrefixes:       make(map[netaddr.IPPrefix][]sourceRef),
+		allowedHostnames: make([]string, len(allowedHostnames)),
+		allowedIPs:       make(map[netaddr.IP]struct{}, len(allowedIPs)),
+	}
+	for i, hostname := range allowedHostnames {
+		a.allowedHostnames[i] = dns.Fqdn(strings.ToLower(hostname))
+	}
+	for _, ip := range allowedIPs {
+		a.allowedIPs[ip] = struct{}{}
+	}
+	return a
+}
+
+// newSettingsAttribution creates an attribution from block lists by
+// category, for which the original lists and rules are unknown.
+func newSettingsAttribution(categories map[string]Settings) *Attribution {
+	a := newAttribution(nil, nil)
+	for category, settings := range categories {
+		index := a.addSource(category, "")
+		for _, hostname := range settings.FqdnHostnames {
+			a.hostnames[hostname] = append(a.hostnames[hostname], sourceRef{index: index})
+		}
+		for _, ip := range settings.IPs {
+			a.ips[ip] = append(a.ips[ip], sourceRef{index: index})
+		}
+		for _, ipPrefix := range settings.IPPrefixes {
+			a.ipPrefixes[ipPrefix] = append(a.ipPrefixes[ipPrefix], sourceRef{index: index})
+		}
+	}
+	return a
+}
+
+func (a *Attribution) addSource(category, list string) (index int) {
+	a.sources = append(a.sources, source{category: category, list: list})
+	return len(a.sources) - 1
+}
+
+// addHostnames adds the hostname rules from the source given.
+func (a *Attribution) addHostnames(index int, rules []string) {
+	for _, rule := range rules {
+		entry := dns.Fqdn(strings.ToLower(strings.TrimSpace(rule)))
+		if entry == "." {
+			continue
+		}
+		ref := sourceRef{index: index}
+		if rule != strings.TrimSuffix(entry, ".") {
+			ref.rule = rule
+		}
+		a.hostnames[entry] = append(a.hostnames[entry], ref)
+	}
+}
+
+// addIPs adds the IP address and IP network rules from the source given.
+func (a *Attribution) addIPs(index int, rules []string) {
+	for _, rule := range rules {
+		trimmed := strings.TrimSpace(rule)
+		if ip, err := netaddr.ParseIP(trimmed); err == nil {
+			ref := sourceRef{index: index}
+			if rule != ip.String() {
+				ref.rule = rule
+			}
+			a.ips[ip] = append(a.ips[ip], ref)
+			continue
+		}
+
+		if ipPrefix, err := netaddr.ParseIPPrefix(trimmed); err == nil {
+			ref := sourceRef{index: index}
+			if rule != ipPrefix.String() {
+				ref.rule = rule
+			}
+			a.ipPrefixes[ipPrefix] = append(a.ipPrefixes[ipPrefix], ref)
+		}
+	}
+}
+
+func (a *Attribution) source(ref sourceRef, entry string) Source {
+	s := a.sources[ref.index]
+	rule := ref.rule
+	if rule == "" {
+		rule = strings.TrimSuffix(entry, ".")
+	}
+	return Source{Category: s.category, List: s.list, Rule: rule}
+}
+
+// hostnameAllowedBy returns the allowed hostname overriding the blocked
+// hostname entry from the source given, or the empty string if none does.
+// Block list entries are only overridden by the same allowed hostname,
+// whereas entries from the settings are also overridden by a parent domain.
+func (a *Attribution) hostnameAllowedBy(entry string, ref sourceRef) (allowedBy string) {
+	fromSettings := a.sources[ref.index].list == SourceSettings
+	for _, allowed := range a.allowedHostnames {
+		if entry == allowed || (fromSettings && strings.HasSuffix(entry, "."+allowed)) {
+			return strings.TrimSuffix(allowed, ".")
+		}
+	}
+	return ""
+}
+
+// categories returns the block lists for each category,
+// without the entries overridden by an allowed entry.
+func (a *Attribution) categories() (categories map[string]Settings) {
+	categoryToHostnames := make(map[string][]string)
+	categoryToIPs := make(map[string][]netaddr.IP)
+	categoryToIPPrefixes := make(map[string][]netaddr.IPPrefix)
+
+	for entry, refs := range a.hostnames {
+		for _, category := range a.refCategories(refs, func(ref sourceRef) bool {
+			return a.hostnameAllowedBy(entry, ref) == ""
+		}) {
+			categoryToHostnames[category] = append(categoryToHostnames[category], entry)
+		}
+	}
+
+	for ip, refs := range a.ips {
+		if _, allowed := a.allowedIPs[ip]; allowed {
+			continue
+		}
+		for _, category := range a.refCategories(refs, nil) {
+			categoryToIPs[category] = append(categoryToIPs[category], ip)
+		}
+	}
+
+	for ipPrefix, refs := range a.ipPrefixes {
+		for _, category := range a.refCategories(refs, nil) {
+			categoryToIPPrefixes[category] = append(categoryToIPPrefixes[category], ipPrefix)
+		}
+	}
+
+	categories = make(map[string]Settings)
+	for _, s := range a.sources {
+		settings := Settings{
+			FqdnHostnames: categoryToHostnames[s.category],
+			IPs:           categoryToIPs[s.category],
+			IPPrefixes:    categoryToIPPrefixes[s.category],
+		}
+		sort.Strings(settings.FqdnHostnames)
+		sort.Slice(settings.IPs, func(i, j int) bool {
+			return settings.IPs[i].Compare(settings.IPs[j]) < 0
+		})
+		sort.Slice(settings.IPPrefixes, func(i, j int) bool {
+			return settings.IPPrefixes[i].String() < settings.IPPrefixes[j].String()
+		})
+		categories[s.category] = settings
+	}
+	return categories
+}
+
+// refCategories returns the unique categories of the source references
+// given, keeping only the references for which keep returns true if
+// keep is not nil.
+func (a *Attribution) refCategories(refs []sourceRef,
+	keep func(ref sourceRef) bool) (categories []string) {
+	for _, ref := range refs {
+		if keep != nil && !keep(ref) {
+			continue
+		}
+		category := a.sources[ref.index].category
+		duplicate := false
+		for _, existing := range categories {
+			if existing == category {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// Explanation explains whether a hostname or an IP address is blocked.
+type Explanation struct {
+	// Query is the hostname or IP address explained.
+	Query string `json:"query"`
+	// Blocked is true if at least one of the matches is not
+	// allowed, not paused and not in monitor only mode.
+	Blocked bool        `json:"blocked"`
+	Matches []RuleMatch `json:"matches"`
+}
+
+// RuleMatch is a block list rule matching a query.
+type RuleMatch struct {
+	// Entry is the blocked hostname, IP address or IP network
+	// matching the query. A hostname entry can be a parent domain
+	// of the query hostname, which blocks it with Unbound only.
+	Entry string `json:"entry"`
+	Source
+	// AllowedBy is the allowed hostname or IP address overriding
+	// the rule, and is empty if the rule is not overridden.
+	AllowedBy string `json:"allowed_by,omitempty"`
+	// Paused is true if blocking is paused for the rule category.
+	Paused bool `json:"paused,omitempty"`
+	// MonitorOnly is true if the rule category is in monitor only mode.
+	MonitorOnly bool `json:"monitor_only,omitempty"`
+}
+
+// explain returns the rules matching the hostname or IP address given.
+func (a *Attribution) explain(query string) (matches []RuleMatch) {
+	matches = []RuleMatch{}
+
+	if ip, err := netaddr.ParseIP(query); err == nil {
+		for _, ref := range a.ips[ip] {
+			match := RuleMatch{Entry: ip.String(), Source: a.source(ref, ip.String())}
+			if _, allowed := a.allowedIPs[ip]; allowed {
+				match.AllowedBy = ip.String()
+			}
+			matches = append(matches, match)
+		}
+
+		ipPrefixes := make([]netaddr.IPPrefix, 0)
+		for ipPrefix := range a.ipPrefixes {
+			if ipPrefix.Contains(ip) {
+				ipPrefixes = append(ipPrefixes, ipPrefix)
+			}
+		}
+		sort.Slice(ipPrefixes, func(i, j int) bool {
+			return ipPrefixes[i].String() < ipPrefixes[j].String()
+		})
+		for _, ipPrefix := range ipPrefixes {
+			for _, ref := range a.ipPrefixes[ipPrefix] {
+				matches = append(matches, RuleMatch{
+					Entry:  ipPrefix.String(),
+					Source: a.source(ref, ipPrefix.String()),
+				})
+			}
+		}
+		return matches
+	}
+
+	name := dns.Fqdn(strings.ToLower(query))
+	for _, offset := range dns.Split(name) {
+		entry := name[offset:]
+		for _, ref := range a.hostnames[entry] {
+			matches = append(matches, RuleMatch{
+				Entry:     strings.TrimSuffix(entry, "."),
+				Source:    a.source(ref, entry),
+				AllowedBy: a.hostnameAllowedBy(entry, ref),
+			})
+		}
+	}
+	return matches
+}
@@ -23,16 +23,17 @@ func (b *builder) All(ctx context.Context, settings BuilderSettings) (
 	}()
 
 	go func() {
+		customIPs, customIPPrefixes := settings.customIPs()
 		blockedIPs, blockedIPPrefixes, errs := b.IPs(ctx,
 			settings.BlockMalicious, settings.BlockAds, settings.BlockSurveillance,
-			settings.AddBlockedIPs, settings.AddBlockedIPPrefixes)
+			customIPs, customIPPrefixes)
 		chIPs <- blockedIPs
 		chIPPrefixes <- blockedIPPrefixes
 		chErrors <- errs
 	}()
 
 	blockedHostnames = <-chHostnames
-	blockedIPs = <-chIPs
+	blockedIPs = removeIPs(<-chIPs, settings.AllowedIPs)
 	blockedIPPrefixes = <-chIPPrefixes
 
 	routineErrs := <-chErrors
@@ -42,3 +43,22 @@ func (b *builder) All(ctx context.Context, settings BuilderSettings) (
 
 	return blockedHostnames, blockedIPs, blockedIPPrefixes, errs
 }
+
+func removeIPs(ips, ipsToRemove []netaddr.IP) (filtered []netaddr.IP) {
+	if len(ipsToRemove) == 0 {
+		return ips
+	}
+
+	toRemove := make(map[netaddr.IP]struct{}, len(ipsToRemove))
+	for _, ip := range ipsToRemove {
+		toRemove[ip] = struct{}{}
+	}
+
+	filtered = make([]netaddr.IP, 0, len(ips))
+	for _, ip := range ips {
+		if _, remove := toRemove[ip]; !remove {
+			filtered = append(filtered, ip)
+		}
+	}
+	return filtered
+}
@@ -109,6 +109,21 @@ func Test_builder_All(t *testing.T) {
 			blockedHostnames: []string{"malicious.com"},
 			blockedIPs:       []string{"1.2.3.4", "1.2.3.7"},
 		},
+		"blocked with allowed IP addresses": {
+			settings: BuilderSettings{
+				BlockMalicious: true,
+				AddBlockedIPs:  []netaddr.IP{netaddr.IPv4(1, 2, 3, 7)},
+				AllowedIPs:     []netaddr.IP{netaddr.IPv4(1, 2, 3, 4), netaddr.IPv4(1, 2, 3, 7)},
+			},
+			maliciousHosts: httpCase{
+				content: []byte("malicious.com"),
+			},
+			maliciousIPs: httpCase{
+				content: []byte("1.2.3.4\n1.2.3.5"),
+			},
+			blockedHostnames: []string{"malicious.com"},
+			blockedIPs:       []string{"1.2.3.5"},
+		},
 		"all blocked with lists and one error": {
 			settings: BuilderSettings{
 				BlockMalicious:    true,
@@ -11,6 +11,8 @@ type Builder interface {
 	All(ctx context.Context, settings BuilderSettings) (
 		blockedHostnames []string, blockedIPs []netaddr.IP,
 		blockedIPPrefixes []netaddr.IPPrefix, errs []error)
+	Categories(ctx context.Context, settings BuilderSettings) (
+		categories map[string]Settings, attribution *Attribution, errs []error)
 	Hostnames(ctx context.Context,
 		blockMalicious, blockAds, blockSurveillance bool,
 		additionalBlockedHostnames, allowedHostnames []string) (
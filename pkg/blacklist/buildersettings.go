@@ -15,6 +15,30 @@ type BuilderSettings struct {
 	AddBlockedHosts      []string
 	AddBlockedIPs        []netaddr.IP
 	AddBlockedIPPrefixes []netaddr.IPPrefix
+	// PrivateIPs and PrivateIPPrefixes are the private IP addresses
+	// and networks blocked to prevent DNS rebinding. They are blocked
+	// in the custom category together with the additional ones.
+	PrivateIPs        []netaddr.IP
+	PrivateIPPrefixes []netaddr.IPPrefix
+	// AllowedIPs are IP addresses removed from the
+	// blocked IP addresses, including additional ones.
+	AllowedIPs []netaddr.IP
+	// MonitorOnly are the block list categories, or AllCategories,
+	// for which matches are only logged and counted instead of
+	// being blocked. It is not used to build the block lists.
+	MonitorOnly []string
+}
+
+// customIPs returns the additional and private IP addresses
+// and networks blocked.
+func (s *BuilderSettings) customIPs() (ips []netaddr.IP, ipPrefixes []netaddr.IPPrefix) {
+	ips = make([]netaddr.IP, 0, len(s.AddBlockedIPs)+len(s.PrivateIPs))
+	ips = append(ips, s.AddBlockedIPs...)
+	ips = append(ips, s.PrivateIPs...)
+	ipPrefixes = make([]netaddr.IPPrefix, 0, len(s.AddBlockedIPPrefixes)+len(s.PrivateIPPrefixes))
+	ipPrefixes = append(ipPrefixes, s.AddBlockedIPPrefixes...)
+	ipPrefixes = append(ipPrefixes, s.PrivateIPPrefixes...)
+	return ips, ipPrefixes
 }
 
 func (s *BuilderSettings) String() string {
@@ -38,11 +62,21 @@ func (s *BuilderSettings) Lines(indent, subSection string) (lines []string) {
 	}
 	lines = append(lines, subSection+"Blocked categories: "+strings.Join(blockedCategories, ", "))
 
+	if len(s.MonitorOnly) > 0 {
+		lines = append(lines, subSection+"Monitor only categories: "+
+			strings.Join(s.MonitorOnly, ", "))
+	}
+
 	if len(s.AllowedHosts) > 0 {
 		lines = append(lines, subSection+"Hostnames unblocked: "+
 			strconv.Itoa(len(s.AllowedHosts)))
 	}
 
+	if len(s.AllowedIPs) > 0 {
+		lines = append(lines, subSection+"IP addresses unblocked: "+
+			strconv.Itoa(len(s.AllowedIPs)))
+	}
+
 	if len(s.AddBlockedHosts) > 0 {
 		lines = append(lines, subSection+"Additional hostnames blocked: "+
 			strconv.Itoa(len(s.AddBlockedHosts)))
@@ -58,5 +92,10 @@ func (s *BuilderSettings) Lines(indent, subSection string) (lines []string) {
 			strconv.Itoa(len(s.AddBlockedIPPrefixes)))
 	}
 
+	if len(s.PrivateIPs)+len(s.PrivateIPPrefixes) > 0 {
+		lines = append(lines, subSection+"Private IP addresses and networks blocked: "+
+			strconv.Itoa(len(s.PrivateIPs)+len(s.PrivateIPPrefixes)))
+	}
+
 	return lines
 }
@@ -0,0 +1,136 @@
+package blacklist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"inet.af/netaddr"
+)
+
+// Block list categories, used to pause blocking selectively.
+const (
+	CategoryMalicious    = "malicious"
+	CategoryAds          = "ads"
+	CategorySurveillance = "surveillance"
+	// CategoryCustom is the category of the additional hostnames,
+	// IP addresses and IP networks blocked.
+	CategoryCustom = "custom"
+)
+
+var ErrCategoryUnknown = errors.New("block list category is unknown")
+
+// CheckCategory returns an error if the category is not one
+// of the block list categories.
+func CheckCategory(category string) (err error) {
+	switch category {
+	case CategoryMalicious, CategoryAds, CategorySurveillance, CategoryCustom:
+		return nil
+	default:
+		return fmt.Errorf("%w: %s", ErrCategoryUnknown, category)
+	}
+}
+
+// Categories builds the block lists separately for each category
+// enabled in the settings, without the allowed hostnames and IP
+// addresses, together with the attribution of each blocked entry.
+func (b *builder) Categories(ctx context.Context, settings BuilderSettings) (
+	categories map[string]Settings, attribution *Attribution, errs []error) {
+	type list struct {
+		category string
+		url      string
+		ips      bool
+	}
+	var lists []list
+	if settings.BlockMalicious {
+		lists = append(lists,
+			list{category: CategoryMalicious, url: maliciousBlockListHostnamesURL},
+			list{category: CategoryMalicious, url: maliciousBlockListIPsURL, ips: true})
+	}
+	if settings.BlockAds {
+		lists = append(lists,
+			list{category: CategoryAds, url: adsBlockListHostnamesURL},
+			list{category: CategoryAds, url: adsBlockListIPsURL, ips: true})
+	}
+	if settings.BlockSurveillance {
+		lists = append(lists,
+			list{category: CategorySurveillance, url: surveillanceBlockListHostnamesURL},
+			list{category: CategorySurveillance, url: surveillanceBlockListIPsURL, ips: true})
+	}
+
+	type result struct {
+		index int
+		rules []string
+		err   error
+	}
+	results := make(chan result)
+	for i, l := range lists {
+		go func(index int, url string) {
+			rules, err := getList(ctx, b.client, url)
+			results <- result{index: index, rules: rules, err: err}
+		}(i, l.url)
+	}
+	listRules := make([][]string, len(lists))
+	for range lists {
+		result := <-results
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+		listRules[result.index] = result.rules
+	}
+
+	attribution = newAttribution(settings.AllowedHosts, settings.AllowedIPs)
+	for i, l := range lists {
+		index := attribution.addSource(l.category, l.url)
+		if l.ips {
+			attribution.addIPs(index, listRules[i])
+		} else {
+			attribution.addHostnames(index, listRules[i])
+		}
+	}
+
+	customIPs, customIPPrefixes := settings.customIPs()
+	if len(settings.AddBlockedHosts) > 0 || len(customIPs) > 0 ||
+		len(customIPPrefixes) > 0 {
+		index := attribution.addSource(CategoryCustom, SourceSettings)
+		attribution.addHostnames(index, settings.AddBlockedHosts)
+		rules := make([]string, 0, len(customIPs)+len(customIPPrefixes))
+		for _, ip := range customIPs {
+			rules = append(rules, ip.String())
+		}
+		for _, ipPrefix := range customIPPrefixes {
+			rules = append(rules, ipPrefix.String())
+		}
+		attribution.addIPs(index, rules)
+	}
+
+	return attribution.categories(), attribution, errs
+}
+
+// Merge merges the settings given together, removing duplicates.
+func Merge(settings ...Settings) (merged Settings) {
+	hostnames := make(map[string]struct{})
+	ips := make(map[netaddr.IP]struct{})
+	ipPrefixes := make(map[netaddr.IPPrefix]struct{})
+	for _, s := range settings {
+		for _, hostname := range s.FqdnHostnames {
+			if _, exists := hostnames[hostname]; !exists {
+				hostnames[hostname] = struct{}{}
+				merged.FqdnHostnames = append(merged.FqdnHostnames, hostname)
+			}
+		}
+		for _, ip := range s.IPs {
+			if _, exists := ips[ip]; !exists {
+				ips[ip] = struct{}{}
+				merged.IPs = append(merged.IPs, ip)
+			}
+		}
+		for _, ipPrefix := range s.IPPrefixes {
+			if _, exists := ipPrefixes[ipPrefix]; !exists {
+				ipPrefixes[ipPrefix] = struct{}{}
+				merged.IPPrefixes = append(merged.IPPrefixes, ipPrefix)
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,179 @@
+package blacklist
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_Merge(t *testing.T) {
+	t.Parallel()
+
+	a := Settings{
+		FqdnHostnames: []string{"a.com.", "b.com."},
+		IPs:           []netaddr.IP{netaddr.IPv4(1, 1, 1, 1)},
+	}
+	b := Settings{
+		FqdnHostnames: []string{"b.com.", "c.com."},
+		IPs:           []netaddr.IP{netaddr.IPv4(1, 1, 1, 1), netaddr.IPv4(2, 2, 2, 2)},
+		IPPrefixes:    []netaddr.IPPrefix{{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 8}},
+	}
+
+	merged := Merge(a, b)
+
+	expected := Settings{
+		FqdnHostnames: []string{"a.com.", "b.com.", "c.com."},
+		IPs:           []netaddr.IP{netaddr.IPv4(1, 1, 1, 1), netaddr.IPv4(2, 2, 2, 2)},
+		IPPrefixes:    []netaddr.IPPrefix{{IP: netaddr.IPv4(10, 0, 0, 0), Bits: 8}},
+	}
+	assert.Equal(t, expected, merged)
+	assert.Equal(t, Settings{}, Merge())
+}
+
+func Test_builder_Categories(t *testing.T) {
+	t.Parallel()
+
+	urlToContent := map[string]string{
+		maliciousBlockListHostnamesURL: "malware.com\nads.com\nAllowed.com",
+		maliciousBlockListIPsURL:       "6.6.6.6\n7.7.7.0/24",
+		adsBlockListHostnamesURL:       "ads.com\ntracker.ads.com",
+		adsBlockListIPsURL:             "",
+	}
+	client := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			content, ok := urlToContent[r.URL.String()]
+			if !ok {
+				return nil, errors.New("unexpected URL: " + r.URL.String())
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(strings.NewReader(content)),
+			}, nil
+		}),
+	}
+	builder := NewBuilder(client)
+
+	settings := BuilderSettings{
+		BlockMalicious:  true,
+		BlockAds:        true,
+		AllowedHosts:    []string{"allowed.com", "sub.custom.com"},
+		AllowedIPs:      []netaddr.IP{netaddr.IPv4(6, 6, 6, 6)},
+		AddBlockedHosts: []string{"custom.com", "sub.custom.com"},
+		AddBlockedIPs:   []netaddr.IP{netaddr.IPv4(7, 7, 7, 7)},
+		PrivateIPPrefixes: []netaddr.IPPrefix{
+			{IP: netaddr.IPv4(192, 168, 0, 0), Bits: 16},
+		},
+	}
+
+	categories, attribution, errs := builder.Categories(context.Background(), settings)
+
+	assert.Empty(t, errs)
+	expectedCategories := map[string]Settings{
+		CategoryMalicious: {
+			FqdnHostnames: []string{"ads.com.", "malware.com."},
+			IPPrefixes:    []netaddr.IPPrefix{{IP: netaddr.IPv4(7, 7, 7, 0), Bits: 24}},
+		},
+		CategoryAds: {
+			FqdnHostnames: []string{"ads.com.", "tracker.ads.com."},
+		},
+		CategoryCustom: {
+			FqdnHostnames: []string{"custom.com."},
+			IPs:           []netaddr.IP{netaddr.IPv4(7, 7, 7, 7)},
+			IPPrefixes:    []netaddr.IPPrefix{{IP: netaddr.IPv4(192, 168, 0, 0), Bits: 16}},
+		},
+	}
+	assert.Equal(t, expectedCategories, categories)
+
+	blocking := NewPausable(nil)
+	blocking.Update(categories, attribution)
+	err := blocking.Pause(time.Hour, CategoryAds)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		query       string
+		explanation Explanation
+	}{
+		"parent domains": {
+			query: "Tracker.Ads.com",
+			explanation: Explanation{
+				Query:   "Tracker.Ads.com",
+				Blocked: true,
+				Matches: []RuleMatch{
+					{Entry: "tracker.ads.com", Paused: true, Source: Source{
+						Category: CategoryAds, List: adsBlockListHostnamesURL, Rule: "tracker.ads.com"}},
+					{Entry: "ads.com", Source: Source{
+						Category: CategoryMalicious, List: maliciousBlockListHostnamesURL, Rule: "ads.com"}},
+					{Entry: "ads.com", Paused: true, Source: Source{
+						Category: CategoryAds, List: adsBlockListHostnamesURL, Rule: "ads.com"}},
+				},
+			},
+		},
+		"allowed list entry": {
+			query: "allowed.com",
+			explanation: Explanation{
+				Query: "allowed.com",
+				Matches: []RuleMatch{
+					{Entry: "allowed.com", AllowedBy: "allowed.com", Source: Source{
+						Category: CategoryMalicious, List: maliciousBlockListHostnamesURL, Rule: "Allowed.com"}},
+				},
+			},
+		},
+		"allowed settings entry": {
+			query: "sub.custom.com",
+			explanation: Explanation{
+				Query:   "sub.custom.com",
+				Blocked: true,
+				Matches: []RuleMatch{
+					{Entry: "sub.custom.com", AllowedBy: "sub.custom.com", Source: Source{
+						Category: CategoryCustom, List: SourceSettings, Rule: "sub.custom.com"}},
+					{Entry: "custom.com", Source: Source{
+						Category: CategoryCustom, List: SourceSettings, Rule: "custom.com"}},
+				},
+			},
+		},
+		"allowed IP address": {
+			query: "6.6.6.6",
+			explanation: Explanation{
+				Query: "6.6.6.6",
+				Matches: []RuleMatch{
+					{Entry: "6.6.6.6", AllowedBy: "6.6.6.6", Source: Source{
+						Category: CategoryMalicious, List: maliciousBlockListIPsURL, Rule: "6.6.6.6"}},
+				},
+			},
+		},
+		"IP network": {
+			query: "7.7.7.7",
+			explanation: Explanation{
+				Query:   "7.7.7.7",
+				Blocked: true,
+				Matches: []RuleMatch{
+					{Entry: "7.7.7.7", Source: Source{
+						Category: CategoryCustom, List: SourceSettings, Rule: "7.7.7.7"}},
+					{Entry: "7.7.7.0/24", Source: Source{
+						Category: CategoryMalicious, List: maliciousBlockListIPsURL, Rule: "7.7.7.0/24"}},
+				},
+			},
+		},
+		"not blocked": {
+			query:       "github.com",
+			explanation: Explanation{Query: "github.com", Matches: []RuleMatch{}},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			explanation := blocking.Explain(testCase.query)
+			assert.Equal(t, testCase.explanation, explanation)
+		})
+	}
+}
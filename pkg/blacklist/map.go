@@ -32,48 +32,62 @@ func NewMap(settings Settings) BlackLister {
 }
 
 func (m *mapBased) FilterRequest(request *dns.Msg) (blocked bool) {
+	_, blocked = m.matchRequest(request)
+	return blocked
+}
+
+func (m *mapBased) FilterResponse(response *dns.Msg) (blocked bool) {
+	_, blocked = m.matchResponse(response)
+	return blocked
+}
+
+// matchRequest returns the blocked hostname matched
+// by a question of the request, if any.
+func (m *mapBased) matchRequest(request *dns.Msg) (rule string, matched bool) {
 	for _, question := range request.Question {
 		fqdnHostname := question.Name
 		if _, blocked := m.fqdnHostnames[fqdnHostname]; blocked {
-			return blocked
+			return fqdnHostname, true
 		}
 	}
-	return false
+	return "", false
 }
 
-func (m *mapBased) FilterResponse(response *dns.Msg) (blocked bool) {
+// matchResponse returns the blocked IP address or IP network
+// matched by an answer of the response, if any.
+func (m *mapBased) matchResponse(response *dns.Msg) (rule string, matched bool) {
 	for _, rr := range response.Answer {
 		// only filter A and AAAA responses for now
 		switch rr.Header().Rrtype {
 		case dns.TypeA:
 			record := rr.(*dns.A)
-			if blocked := m.isIPBlocked(record.A); blocked {
-				return blocked
+			if rule, blocked := m.matchIP(record.A); blocked {
+				return rule, blocked
 			}
 		case dns.TypeAAAA:
 			record := rr.(*dns.AAAA)
-			if blocked := m.isIPBlocked(record.AAAA); blocked {
-				return blocked
+			if rule, blocked := m.matchIP(record.AAAA); blocked {
+				return rule, blocked
 			}
 		}
 	}
-	return false
+	return "", false
 }
 
-func (m *mapBased) isIPBlocked(ip net.IP) (blocked bool) {
+func (m *mapBased) matchIP(ip net.IP) (rule string, blocked bool) {
 	netaddrIP, ok := netaddr.FromStdIP(ip)
 	if !ok {
-		return true
+		return ip.String(), true
 	}
 
 	if _, blocked := m.ips[netaddrIP]; blocked {
-		return blocked
+		return netaddrIP.String(), blocked
 	}
 
 	for _, ipPrefix := range m.ipPrefixes {
 		if ipPrefix.Contains(netaddrIP) {
-			return true
+			return ipPrefix.String(), true
 		}
 	}
-	return false
+	return "", false
 }
@@ -0,0 +1,272 @@
+package blacklist
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// AllCategories is the key used in the map returned by
+// Paused for the pause of all the categories, and the category
+// to set all the categories in monitor only mode.
+const AllCategories = "all"
+
+// Match is a block list rule matched by a request or a response.
+type Match struct {
+	// Name is the first question name of the message.
+	Name     string
+	Category string
+	// Rule is the blocked hostname, IP address or IP network matched.
+	Rule string
+}
+
+// MonitorFunc is called with each match of a block list
+// category in monitor only mode, which is not blocked.
+type MonitorFunc func(match Match)
+
+// Pausable is a black lister made of block list categories
+// which can be paused temporarily, all together or separately.
+// It is safe for concurrent use.
+type Pausable interface {
+	BlackLister
+	// MatchRequest returns the block list rule blocking
+	// the request, and false if the request is not blocked.
+	MatchRequest(request *dns.Msg) (match Match, blocked bool)
+	// MatchResponse returns the block list rule blocking
+	// the response, and false if the response is not blocked.
+	MatchResponse(response *dns.Msg) (match Match, blocked bool)
+	// Pause pauses blocking for the categories given, or for all
+	// the categories if none is given, until the duration elapses.
+	// A zero duration resumes blocking for the categories given,
+	// or for all the categories if none is given.
+	Pause(duration time.Duration, categories ...string) (err error)
+	// Paused returns the time at which blocking resumes for each
+	// category paused, where the key AllCategories is used if all
+	// the categories are paused.
+	Paused() (categoryToEnd map[string]time.Time)
+	// Active returns the block lists of the categories
+	// not paused and not in monitor only mode, merged together.
+	Active() (settings Settings)
+	// Update sets the block lists for each category and their
+	// attribution, keeping the current pauses. The attribution
+	// can be nil, in which case the block list sources are unknown.
+	Update(categories map[string]Settings, attribution *Attribution)
+	// Explain explains whether the hostname or IP address
+	// given is blocked, and by which block list rules.
+	Explain(query string) (explanation Explanation)
+	// SetMonitorOnly sets the categories in monitor only mode,
+	// for which matches are reported to the monitor function
+	// instead of being blocked. The category AllCategories
+	// sets all the categories in monitor only mode.
+	SetMonitorOnly(categories []string, monitor MonitorFunc) (err error)
+}
+
+type pausable struct {
+	mutex        sync.RWMutex
+	settings     map[string]Settings
+	blackListers map[string]*mapBased
+	categories   []string // sorted, to match categories in a stable order
+	attribution  *Attribution
+	pausedUntil  map[string]time.Time
+	monitorOnly  map[string]struct{}
+	monitor      MonitorFunc
+	timeNow      func() time.Time
+}
+
+// NewPausable creates a pausable black lister with the block lists
+// given for each category, where each key should be one of the
+// Category constants.
+func NewPausable(categories map[string]Settings) Pausable {
+	p := &pausable{
+		pausedUntil: make(map[string]time.Time),
+		timeNow:     time.Now,
+	}
+	p.Update(categories, nil)
+	return p
+}
+
+func (p *pausable) Update(categories map[string]Settings, attribution *Attribution) {
+	blackListers := make(map[string]*mapBased, len(categories))
+	sortedCategories := make([]string, 0, len(categories))
+	for category, settings := range categories {
+		blackListers[category] = NewMap(settings).(*mapBased)
+		sortedCategories = append(sortedCategories, category)
+	}
+	sort.Strings(sortedCategories)
+
+	if attribution == nil {
+		attribution = newSettingsAttribution(categories)
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.settings = categories
+	p.blackListers = blackListers
+	p.categories = sortedCategories
+	p.attribution = attribution
+}
+
+func (p *pausable) Explain(query string) (explanation Explanation) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	now := p.timeNow()
+
+	explanation.Query = query
+	explanation.Matches = p.attribution.explain(query)
+	for i, match := range explanation.Matches {
+		match.Paused = p.isPaused(match.Category, now)
+		match.MonitorOnly = p.isMonitorOnly(match.Category)
+		if match.AllowedBy == "" && !match.Paused && !match.MonitorOnly {
+			explanation.Blocked = true
+		}
+		explanation.Matches[i] = match
+	}
+	return explanation
+}
+
+func (p *pausable) FilterRequest(request *dns.Msg) (blocked bool) {
+	_, blocked = p.MatchRequest(request)
+	return blocked
+}
+
+func (p *pausable) FilterResponse(response *dns.Msg) (blocked bool) {
+	_, blocked = p.MatchResponse(response)
+	return blocked
+}
+
+func (p *pausable) MatchRequest(request *dns.Msg) (match Match, blocked bool) {
+	return p.match(request, (*mapBased).matchRequest)
+}
+
+func (p *pausable) MatchResponse(response *dns.Msg) (match Match, blocked bool) {
+	return p.match(response, (*mapBased).matchResponse)
+}
+
+func (p *pausable) match(message *dns.Msg,
+	matchRule func(m *mapBased, message *dns.Msg) (rule string, matched bool)) (
+	match Match, blocked bool) {
+	if len(message.Question) > 0 {
+		match.Name = message.Question[0].Name
+	}
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	now := p.timeNow()
+	for _, category := range p.categories {
+		if p.isPaused(category, now) {
+			continue
+		}
+
+		rule, matched := matchRule(p.blackListers[category], message)
+		if !matched {
+			continue
+		}
+
+		match.Category = category
+		match.Rule = rule
+		if !p.isMonitorOnly(category) {
+			return match, true
+		}
+
+		if p.monitor != nil {
+			p.monitor(match)
+		}
+	}
+	return Match{}, false
+}
+
+// isPaused must be called with the mutex locked.
+func (p *pausable) isPaused(category string, now time.Time) bool {
+	return now.Before(p.pausedUntil[AllCategories]) ||
+		now.Before(p.pausedUntil[category])
+}
+
+// isMonitorOnly must be called with the mutex locked.
+func (p *pausable) isMonitorOnly(category string) bool {
+	_, all := p.monitorOnly[AllCategories]
+	_, monitorOnly := p.monitorOnly[category]
+	return all || monitorOnly
+}
+
+func (p *pausable) SetMonitorOnly(categories []string, monitor MonitorFunc) (err error) {
+	monitorOnly := make(map[string]struct{}, len(categories))
+	for _, category := range categories {
+		if category != AllCategories {
+			if err := CheckCategory(category); err != nil {
+				return err
+			}
+		}
+		monitorOnly[category] = struct{}{}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.monitorOnly = monitorOnly
+	p.monitor = monitor
+	return nil
+}
+
+func (p *pausable) Pause(duration time.Duration, categories ...string) (err error) {
+	for _, category := range categories {
+		if err := CheckCategory(category); err != nil {
+			return err
+		}
+	}
+
+	if len(categories) == 0 {
+		if duration == 0 {
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+			p.pausedUntil = make(map[string]time.Time)
+			return nil
+		}
+		categories = []string{AllCategories}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	end := p.timeNow().Add(duration)
+	for _, category := range categories {
+		if duration == 0 {
+			delete(p.pausedUntil, category)
+			continue
+		}
+		p.pausedUntil[category] = end
+	}
+	return nil
+}
+
+func (p *pausable) Paused() (categoryToEnd map[string]time.Time) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	now := p.timeNow()
+	categoryToEnd = make(map[string]time.Time, len(p.pausedUntil))
+	for category, end := range p.pausedUntil {
+		if now.Before(end) {
+			categoryToEnd[category] = end
+		}
+	}
+	return categoryToEnd
+}
+
+func (p *pausable) Active() (settings Settings) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	now := p.timeNow()
+
+	categories := make([]string, 0, len(p.settings))
+	for category := range p.settings {
+		if !p.isPaused(category, now) && !p.isMonitorOnly(category) {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+
+	active := make([]Settings, len(categories))
+	for i, category := range categories {
+		active[i] = p.settings[category]
+	}
+	return Merge(active...)
+}
@@ -0,0 +1,155 @@
+package blacklist
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_pausable(t *testing.T) {
+	t.Parallel()
+
+	ads := Settings{FqdnHostnames: []string{"ads.com."}}
+	malicious := Settings{FqdnHostnames: []string{"malware.com."}}
+	p := NewPausable(map[string]Settings{
+		CategoryAds:       ads,
+		CategoryMalicious: malicious,
+	}).(*pausable)
+
+	now := time.Date(2021, 7, 22, 10, 0, 0, 0, time.UTC)
+	p.timeNow = func() time.Time { return now }
+
+	request := func(name string) *dns.Msg {
+		return &dns.Msg{Question: []dns.Question{{Name: name}}}
+	}
+
+	assert.True(t, p.FilterRequest(request("ads.com.")))
+	assert.True(t, p.FilterRequest(request("malware.com.")))
+	assert.Equal(t, Merge(ads, malicious), p.Active())
+
+	err := p.Pause(time.Minute, "unknown")
+	require.Error(t, err)
+	assert.Equal(t, "block list category is unknown: unknown", err.Error())
+
+	err = p.Pause(time.Minute, CategoryAds)
+	require.NoError(t, err)
+	assert.False(t, p.FilterRequest(request("ads.com.")))
+	assert.True(t, p.FilterRequest(request("malware.com.")))
+	assert.Equal(t, malicious, p.Active())
+	assert.Equal(t, map[string]time.Time{CategoryAds: now.Add(time.Minute)}, p.Paused())
+
+	err = p.Pause(2 * time.Minute)
+	require.NoError(t, err)
+	assert.False(t, p.FilterRequest(request("malware.com.")))
+	assert.Equal(t, Settings{}, p.Active())
+
+	now = now.Add(90 * time.Second) // ads pause expired
+	assert.Equal(t, map[string]time.Time{AllCategories: now.Add(30 * time.Second)}, p.Paused())
+
+	err = p.Pause(0, CategoryAds)
+	require.NoError(t, err)
+	assert.False(t, p.FilterRequest(request("ads.com.")))
+
+	now = now.Add(time.Minute) // all pause expired
+	assert.True(t, p.FilterRequest(request("ads.com.")))
+	assert.Empty(t, p.Paused())
+
+	err = p.Pause(time.Hour, CategoryMalicious)
+	require.NoError(t, err)
+	err = p.Pause(0)
+	require.NoError(t, err)
+	assert.True(t, p.FilterRequest(request("malware.com.")))
+
+	p.Update(map[string]Settings{CategoryAds: malicious}, nil)
+	assert.False(t, p.FilterRequest(request("ads.com.")))
+	assert.True(t, p.FilterRequest(request("malware.com.")))
+}
+
+func Test_pausable_Match(t *testing.T) {
+	t.Parallel()
+
+	p := NewPausable(map[string]Settings{
+		CategoryAds: {FqdnHostnames: []string{"tracker.com."}},
+		CategorySurveillance: {
+			FqdnHostnames: []string{"tracker.com."},
+			IPs:           []netaddr.IP{netaddr.IPv4(6, 6, 6, 6)},
+		},
+	})
+
+	request := &dns.Msg{Question: []dns.Question{{Name: "tracker.com."}}}
+	match, blocked := p.MatchRequest(request)
+	assert.True(t, blocked)
+	expectedMatch := Match{Name: "tracker.com.", Category: CategoryAds, Rule: "tracker.com."}
+	assert.Equal(t, expectedMatch, match)
+
+	response := &dns.Msg{
+		Question: []dns.Question{{Name: "site.com."}},
+		Answer:   []dns.RR{&dns.A{Hdr: dns.RR_Header{Rrtype: dns.TypeA}, A: net.IPv4(6, 6, 6, 6)}},
+	}
+	match, blocked = p.MatchResponse(response)
+	assert.True(t, blocked)
+	expectedMatch = Match{Name: "site.com.", Category: CategorySurveillance, Rule: "6.6.6.6"}
+	assert.Equal(t, expectedMatch, match)
+
+	err := p.Pause(time.Hour, CategoryAds)
+	require.NoError(t, err)
+	match, blocked = p.MatchRequest(request)
+	assert.True(t, blocked)
+	assert.Equal(t, CategorySurveillance, match.Category)
+
+	err = p.Pause(time.Hour)
+	require.NoError(t, err)
+	match, blocked = p.MatchRequest(request)
+	assert.False(t, blocked)
+	assert.Equal(t, Match{}, match)
+}
+
+func Test_pausable_SetMonitorOnly(t *testing.T) {
+	t.Parallel()
+
+	ads := Settings{FqdnHostnames: []string{"ads.com."}}
+	malicious := Settings{
+		FqdnHostnames: []string{"malware.com."},
+		IPPrefixes:    []netaddr.IPPrefix{{IP: netaddr.IPv4(6, 6, 6, 0), Bits: 24}},
+	}
+	p := NewPausable(map[string]Settings{
+		CategoryAds:       ads,
+		CategoryMalicious: malicious,
+	})
+
+	var matches []Match
+	monitor := func(match Match) { matches = append(matches, match) }
+
+	err := p.SetMonitorOnly([]string{"unknown"}, monitor)
+	require.Error(t, err)
+	assert.Equal(t, "block list category is unknown: unknown", err.Error())
+
+	err = p.SetMonitorOnly([]string{CategoryMalicious}, monitor)
+	require.NoError(t, err)
+
+	request := &dns.Msg{Question: []dns.Question{{Name: "malware.com."}}}
+	assert.False(t, p.FilterRequest(request))
+	assert.True(t, p.FilterRequest(&dns.Msg{Question: []dns.Question{{Name: "ads.com."}}}))
+	response := &dns.Msg{
+		Question: []dns.Question{{Name: "site.com."}},
+		Answer:   []dns.RR{&dns.A{Hdr: dns.RR_Header{Rrtype: dns.TypeA}, A: net.IPv4(6, 6, 6, 6)}},
+	}
+	assert.False(t, p.FilterResponse(response))
+	assert.Equal(t, ads, p.Active())
+
+	expectedMatches := []Match{
+		{Name: "malware.com.", Category: CategoryMalicious, Rule: "malware.com."},
+		{Name: "site.com.", Category: CategoryMalicious, Rule: "6.6.6.0/24"},
+	}
+	assert.Equal(t, expectedMatches, matches)
+
+	err = p.SetMonitorOnly([]string{AllCategories}, nil)
+	require.NoError(t, err)
+	assert.False(t, p.FilterRequest(&dns.Msg{Question: []dns.Question{{Name: "ads.com."}}}))
+	assert.Equal(t, Settings{}, p.Active())
+}
@@ -1,8 +1,12 @@
 package cache
 
 import (
+	"io"
+
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache/lfu"
 	"github.com/qdm12/dns/pkg/cache/lru"
+	"github.com/qdm12/dns/pkg/cache/stats"
 )
 
 //go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Cache
@@ -10,8 +14,18 @@ import (
 type Cache interface {
 	Add(request, response *dns.Msg)
 	Get(request *dns.Msg) (response *dns.Msg)
+	GetStale(request *dns.Msg) (response *dns.Msg)
+	RemoveExpired()
+	Stats() (stats Stats)
+	Remove(name string, qType uint16)
+	RemoveDomain(domain string)
+	Flush()
+	Save(writer io.Writer) (err error)
+	Load(reader io.Reader) (err error)
 }
 
+type Stats = stats.Stats
+
 // New creates a new cache object except when the cache type
 // is set to Disabled. In this case it returns a nil Cache.
 func New(settings Settings) Cache {
@@ -19,6 +33,8 @@ func New(settings Settings) Cache {
 	switch settings.Type {
 	case LRU:
 		return lru.New(settings.LRU)
+	case LFU:
+		return lfu.New(settings.LFU)
 	case Disabled:
 		return nil
 	default: // coding error as an end user should use ParseType
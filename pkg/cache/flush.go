@@ -0,0 +1,20 @@
+package cache
+
+// OnFlush wraps the cache given such that the function
+// given is called each time after the cache is flushed.
+func OnFlush(cache Cache, onFlush func()) Cache {
+	return &flushHook{
+		Cache:   cache,
+		onFlush: onFlush,
+	}
+}
+
+type flushHook struct {
+	Cache
+	onFlush func()
+}
+
+func (f *flushHook) Flush() {
+	f.Cache.Flush()
+	f.onFlush()
+}
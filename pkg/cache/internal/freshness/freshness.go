@@ -0,0 +1,118 @@
+// Package freshness has the logic shared by the caches to decide
+// how long entries are fresh, when they are served stale and when
+// they are prefetched, independently of their eviction policy.
+package freshness
+
+import (
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache/internal/ttl"
+	"github.com/qdm12/dns/pkg/cache/key"
+)
+
+// Policy applies the freshness settings to cache entries.
+// It is safe for concurrent use.
+type Policy struct {
+	minTTL         uint32
+	maxTTL         uint32
+	maxNegativeTTL uint32
+	errorTTL       uint32
+	staleWindow    int64
+	keyWithECS     bool
+	prefetchHits   int
+	exchange       ExchangeFunc
+	prefetchSlots  chan struct{}
+}
+
+// New creates a policy from the settings given,
+// which must have their defaults set.
+func New(settings Settings) *Policy {
+	return &Policy{
+		minTTL:         uint32(settings.MinTTL / time.Second),
+		maxTTL:         uint32(settings.MaxTTL / time.Second),
+		maxNegativeTTL: uint32(*settings.MaxNegativeTTL / time.Second),
+		errorTTL:       uint32(settings.ErrorTTL / time.Second),
+		staleWindow:    int64(settings.StaleWindow / time.Second),
+		keyWithECS:     settings.KeyWithECS,
+		prefetchHits:   settings.PrefetchMinHits,
+		exchange:       settings.Exchange,
+		prefetchSlots:  make(chan struct{}, settings.PrefetchConcurrency),
+	}
+}
+
+// Key returns the cache key for the request,
+// and false if the request cannot be cached.
+func (p *Policy) Key(request *dns.Msg) (requestKey string, ok bool) {
+	return key.Make(request, p.keyWithECS)
+}
+
+// Prepare returns a copy of the response with its TTLs clamped,
+// to be cached, and its expiry unix time. It returns false if
+// the response must not be cached.
+func (p *Policy) Prepare(response *dns.Msg, nowUnix int64) (
+	prepared *dns.Msg, expUnix int64, ok bool) {
+	prepared = response.Copy()
+	ttl.Clamp(prepared, p.minTTL, p.maxTTL)
+	expUnix, ok = ttl.ExpUnix(prepared, nowUnix, p.maxNegativeTTL, p.errorTTL)
+	if !ok {
+		return nil, 0, false
+	}
+	return prepared, expUnix, true
+}
+
+// Expired returns true if an entry expiring at expUnix is expired
+// for longer than the stale window at nowUnix, and can be removed.
+func (p *Policy) Expired(expUnix, nowUnix int64) bool {
+	return nowUnix >= expUnix+p.staleWindow
+}
+
+// Response returns a copy of the cached response with its TTLs
+// decremented by the time elapsed since it was added, or set to
+// 30 seconds if the response expired and is served stale.
+func Response(cached *dns.Msg, addedUnix, expUnix, nowUnix int64) (response *dns.Msg) {
+	response = cached.Copy()
+	if nowUnix < expUnix {
+		ttl.Decrement(response, uint32(nowUnix-addedUnix))
+	} else {
+		ttl.Clamp(response, ttl.Stale, ttl.Stale)
+	}
+	return response
+}
+
+// ShouldPrefetch returns true if an entry with the hits given is
+// popular enough, is not already being prefetched and has less
+// than 10% of its original TTL left, similarly to Unbound.
+func (p *Policy) ShouldPrefetch(hits int, prefetching bool,
+	addedUnix, expUnix, nowUnix int64) bool {
+	if p.exchange == nil || p.prefetchHits == 0 ||
+		prefetching || hits < p.prefetchHits {
+		return false
+	}
+	const ttlFractionLeft = 10
+	return (expUnix-nowUnix)*ttlFractionLeft <= expUnix-addedUnix
+}
+
+// Prefetch exchanges the request in the background if there is a
+// prefetch slot available, and returns false otherwise. Once the
+// exchange is done, add is called with the response, or failed is
+// called if the exchange failed.
+func (p *Policy) Prefetch(request *dns.Msg,
+	add func(request, response *dns.Msg), failed func()) (started bool) {
+	select {
+	case p.prefetchSlots <- struct{}{}:
+	default: // too many prefetches in progress
+		return false
+	}
+
+	go func() {
+		response, err := p.exchange(request)
+		<-p.prefetchSlots
+		if err != nil {
+			failed()
+			return
+		}
+		add(request, response)
+	}()
+	return true
+}
@@ -0,0 +1,110 @@
+package freshness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Policy_ShouldPrefetch(t *testing.T) {
+	t.Parallel()
+
+	exchange := func(request *dns.Msg) (*dns.Msg, error) { return nil, nil }
+
+	const (
+		addedUnix = 1000
+		expUnix   = 1100
+	)
+
+	testCases := map[string]struct {
+		settings    Settings
+		hits        int
+		prefetching bool
+		nowUnix     int64
+		prefetch    bool
+	}{
+		"prefetch disabled": {
+			settings: Settings{Exchange: exchange},
+			hits:     10,
+			nowUnix:  1095,
+		},
+		"no exchange function": {
+			settings: Settings{PrefetchMinHits: 2},
+			hits:     10,
+			nowUnix:  1095,
+		},
+		"not enough hits": {
+			settings: Settings{PrefetchMinHits: 2, Exchange: exchange},
+			hits:     1,
+			nowUnix:  1095,
+		},
+		"already prefetching": {
+			settings:    Settings{PrefetchMinHits: 2, Exchange: exchange},
+			hits:        2,
+			prefetching: true,
+			nowUnix:     1095,
+		},
+		"more than 10% of TTL left": {
+			settings: Settings{PrefetchMinHits: 2, Exchange: exchange},
+			hits:     2,
+			nowUnix:  1089,
+		},
+		"10% of TTL left": {
+			settings: Settings{PrefetchMinHits: 2, Exchange: exchange},
+			hits:     2,
+			nowUnix:  1090,
+			prefetch: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := testCase.settings
+			settings.SetDefaults()
+			policy := New(settings)
+
+			prefetch := policy.ShouldPrefetch(testCase.hits, testCase.prefetching,
+				addedUnix, expUnix, testCase.nowUnix)
+
+			assert.Equal(t, testCase.prefetch, prefetch)
+		})
+	}
+}
+
+func Test_Policy_Expired(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{StaleWindow: time.Minute}
+	settings.SetDefaults()
+	policy := New(settings)
+
+	const expUnix = 1000
+	assert.False(t, policy.Expired(expUnix, 1059))
+	assert.True(t, policy.Expired(expUnix, 1060))
+}
+
+func Test_Response(t *testing.T) {
+	t.Parallel()
+
+	cached := &dns.Msg{Answer: []dns.RR{
+		&dns.A{Hdr: dns.RR_Header{Ttl: 100}},
+	}}
+
+	const (
+		addedUnix = 1000
+		expUnix   = 1100
+	)
+
+	response := Response(cached, addedUnix, expUnix, 1040)
+	assert.Equal(t, uint32(60), response.Answer[0].Header().Ttl)
+
+	response = Response(cached, addedUnix, expUnix, 1200)
+	assert.Equal(t, uint32(30), response.Answer[0].Header().Ttl)
+
+	assert.Equal(t, uint32(100), cached.Answer[0].Header().Ttl)
+}
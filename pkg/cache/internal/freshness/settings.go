@@ -0,0 +1,98 @@
+package freshness
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// ExchangeFunc exchanges a request with the upstream server,
+// and is used to refresh cache entries in the background.
+type ExchangeFunc func(request *dns.Msg) (response *dns.Msg, err error)
+
+// Settings are the settings shared by the caches on how long
+// their entries are fresh, served stale and prefetched.
+type Settings struct {
+	// MinTTL is the minimum TTL of cached records, similar to
+	// the Unbound cache-min-ttl option. It defaults to 0.
+	MinTTL time.Duration
+	// MaxTTL is the maximum TTL of cached records, similar to
+	// the Unbound cache-max-ttl option. It defaults to 9000 seconds.
+	MaxTTL time.Duration
+	// MaxNegativeTTL is the maximum duration NXDOMAIN and NODATA
+	// responses are cached for. It defaults to 1 hour, and can be
+	// set to 0 for negative responses not to be cached.
+	MaxNegativeTTL *time.Duration
+	// ErrorTTL is the duration SERVFAIL and other error responses
+	// are cached for. It defaults to 0, meaning they are not cached.
+	ErrorTTL time.Duration
+	// StaleWindow is the duration expired entries are kept
+	// for after their expiry, to be served stale if the upstream
+	// fails as described in RFC 8767. It defaults to 0, meaning
+	// stale answers are not served.
+	StaleWindow time.Duration
+	// KeyWithECS makes the EDNS0 client subnet of requests part
+	// of the cache key, so clients from different subnets do not
+	// share cache entries. It defaults to false.
+	KeyWithECS bool
+	// PrefetchMinHits is the minimum number of cache hits for an
+	// entry to be refreshed in the background shortly before it
+	// expires. It defaults to 0, meaning entries are not prefetched.
+	PrefetchMinHits int
+	// PrefetchConcurrency is the maximum number of prefetch exchanges
+	// running at the same time. It defaults to 10.
+	PrefetchConcurrency int
+	// Exchange is the function used to prefetch entries, and is
+	// usually set by the DNS server using the cache.
+	// Prefetching is disabled if it is nil.
+	Exchange ExchangeFunc
+}
+
+func (s *Settings) SetDefaults() {
+	if s.MaxTTL == 0 {
+		const defaultMaxTTL = 9000 * time.Second
+		s.MaxTTL = defaultMaxTTL
+	}
+
+	if s.MaxNegativeTTL == nil {
+		maxNegativeTTL := time.Hour
+		s.MaxNegativeTTL = &maxNegativeTTL
+	}
+
+	if s.PrefetchConcurrency == 0 {
+		const defaultPrefetchConcurrency = 10
+		s.PrefetchConcurrency = defaultPrefetchConcurrency
+	}
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Min TTL: "+s.MinTTL.String())
+	lines = append(lines, subSection+"Max TTL: "+s.MaxTTL.String())
+	if *s.MaxNegativeTTL > 0 {
+		lines = append(lines, subSection+"Max negative TTL: "+s.MaxNegativeTTL.String())
+	} else {
+		lines = append(lines, subSection+"Max negative TTL: negative responses are not cached")
+	}
+	if s.ErrorTTL > 0 {
+		lines = append(lines, subSection+"Error TTL: "+s.ErrorTTL.String())
+	} else {
+		lines = append(lines, subSection+"Error TTL: errors are not cached")
+	}
+	if s.StaleWindow > 0 {
+		lines = append(lines, subSection+"Serve stale window: "+s.StaleWindow.String())
+	} else {
+		lines = append(lines, subSection+"Serve stale: disabled")
+	}
+	if s.KeyWithECS {
+		lines = append(lines, subSection+"Client subnet in cache key: yes")
+	}
+	if s.PrefetchMinHits > 0 {
+		lines = append(lines, subSection+"Prefetch: entries with at least "+
+			strconv.Itoa(s.PrefetchMinHits)+" hits, "+
+			strconv.Itoa(s.PrefetchConcurrency)+" at a time")
+	} else {
+		lines = append(lines, subSection+"Prefetch: disabled")
+	}
+	return lines
+}
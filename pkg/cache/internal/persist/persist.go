@@ -0,0 +1,133 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/miekg/dns"
+)
+
+// File format, all integers being big endian:
+// - the file header, followed by the format version byte
+// - for each entry:
+//   - the key length as uint16 followed by the key
+//   - the added unix time as int64
+//   - the expiry unix time as int64
+//   - the response wire length as uint16 followed by the response wire
+
+var (
+	ErrFileHeader  = errors.New("file header is not valid")
+	ErrFileVersion = errors.New("file version is not supported")
+	ErrFileCorrupt = errors.New("file is corrupt")
+)
+
+// Entry is a cache entry as stored in a file.
+type Entry struct {
+	Key       string
+	AddedUnix int64
+	ExpUnix   int64
+	Response  *dns.Msg
+	// Size is the key length plus the response wire length,
+	// and is only set by ReadEntry.
+	Size int
+}
+
+// WriteHeader writes the file header and version to the writer.
+func WriteHeader(writer io.Writer, header string, version byte) (err error) {
+	_, err = writer.Write(append([]byte(header), version))
+	return err
+}
+
+// ReadHeader reads the file header and version from the reader
+// and checks they match the header and version given.
+func ReadHeader(reader io.Reader, header string, version byte) (err error) {
+	data := make([]byte, len(header)+1)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return fmt.Errorf("%w: %s", ErrFileHeader, err)
+	}
+	if !bytes.Equal(data[:len(header)], []byte(header)) {
+		return ErrFileHeader
+	}
+	if fileVersion := data[len(header)]; fileVersion != version {
+		return fmt.Errorf("%w: version %d", ErrFileVersion, fileVersion)
+	}
+	return nil
+}
+
+// WriteEntry writes the entry to the writer. Entries with
+// a response which cannot be packed are silently skipped.
+func WriteEntry(writer io.Writer, entry Entry) (err error) {
+	wire, err := entry.Response.Pack()
+	if err != nil {
+		return nil //nolint:nilerr // should not happen for a response we received
+	}
+
+	for _, field := range []interface{}{
+		uint16(len(entry.Key)), []byte(entry.Key),
+		entry.AddedUnix, entry.ExpUnix,
+		uint16(len(wire)), wire,
+	} {
+		if err := binary.Write(writer, binary.BigEndian, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadEntry reads an entry from the reader. It returns io.EOF
+// only if the reader is at its end before the entry starts.
+func ReadEntry(reader io.Reader) (entry Entry, err error) {
+	var keyLength uint16
+	err = binary.Read(reader, binary.BigEndian, &keyLength)
+	if err != nil {
+		return entry, err
+	}
+
+	key := make([]byte, keyLength)
+	var wireLength uint16
+	for _, field := range []interface{}{
+		key, &entry.AddedUnix, &entry.ExpUnix, &wireLength,
+	} {
+		if err := binary.Read(reader, binary.BigEndian, field); err != nil {
+			return entry, noEOF(err)
+		}
+	}
+	entry.Key = string(key)
+	entry.Size = len(key) + int(wireLength)
+
+	wire := make([]byte, wireLength)
+	if _, err := io.ReadFull(reader, wire); err != nil {
+		return entry, noEOF(err)
+	}
+
+	entry.Response = new(dns.Msg)
+	if err := entry.Response.Unpack(wire); err != nil {
+		return entry, err
+	}
+
+	return entry, nil
+}
+
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ReadEntries reads all the entries from the reader, after the
+// header has been read, and wraps any error with ErrFileCorrupt.
+func ReadEntries(reader io.Reader) (entries []Entry, err error) {
+	for {
+		entry, err := ReadEntry(reader)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %s", ErrFileCorrupt, len(entries)+1, err)
+		}
+		entries = append(entries, entry)
+	}
+}
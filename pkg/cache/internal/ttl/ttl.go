@@ -0,0 +1,111 @@
+package ttl
+
+import (
+	"github.com/miekg/dns"
+)
+
+// Stale is the TTL in seconds set on stale answers,
+// as recommended by RFC 8767 section 4.
+const Stale = 30
+
+// ExpUnix returns the expiry unix time for the response and
+// whether the response can be cached at all.
+// Positive responses expire with their lowest answer TTL.
+// Negative responses (NXDOMAIN and NODATA) are cached as described
+// in RFC 2308, using the SOA record of the authority section, capped
+// to maxNegativeTTL seconds, and are not cached if maxNegativeTTL
+// is zero. Other error responses are cached for
+// errorTTL seconds, and are not cached if errorTTL is zero.
+func ExpUnix(response *dns.Msg, nowUnix int64,
+	maxNegativeTTL, errorTTL uint32) (expUnix int64, ok bool) {
+	var secondsLeft uint32
+	switch {
+	case response.Rcode == dns.RcodeSuccess && len(response.Answer) > 0:
+		secondsLeft = getAnswerTTL(response.Answer)
+	case response.Rcode == dns.RcodeSuccess, response.Rcode == dns.RcodeNameError:
+		if maxNegativeTTL == 0 {
+			return 0, false
+		}
+		secondsLeft, ok = getNegativeTTL(response.Ns)
+		if !ok {
+			return 0, false
+		}
+		if secondsLeft > maxNegativeTTL {
+			secondsLeft = maxNegativeTTL
+		}
+	default:
+		if errorTTL == 0 {
+			return 0, false
+		}
+		secondsLeft = errorTTL
+	}
+	return nowUnix + int64(secondsLeft), true
+}
+
+func getAnswerTTL(answer []dns.RR) (ttl uint32) {
+	ttl = ^uint32(0)
+	for _, rr := range answer {
+		rrTTL := rr.Header().Ttl
+		if rrTTL < ttl {
+			ttl = rrTTL
+		}
+	}
+	return ttl
+}
+
+// getNegativeTTL returns the negative caching TTL from the SOA
+// record of the authority section, which is the minimum of the
+// SOA record TTL and of its MINIMUM field. It returns false if no
+// SOA record is found, in which case the response should not be
+// cached as specified in RFC 2308 section 5.
+func getNegativeTTL(authority []dns.RR) (ttl uint32, ok bool) {
+	for _, rr := range authority {
+		soa, isSOA := rr.(*dns.SOA)
+		if !isSOA {
+			continue
+		}
+		ttl = soa.Hdr.Ttl
+		if soa.Minttl < ttl {
+			ttl = soa.Minttl
+		}
+		return ttl, true
+	}
+	return 0, false
+}
+
+// Clamp sets the TTL of each record of the message
+// to be between minTTL and maxTTL seconds.
+func Clamp(msg *dns.Msg, minTTL, maxTTL uint32) {
+	for _, rrs := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range rrs {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT { // TTL field holds flags
+				continue
+			}
+			switch {
+			case header.Ttl > maxTTL:
+				header.Ttl = maxTTL
+			case header.Ttl < minTTL:
+				header.Ttl = minTTL
+			}
+		}
+	}
+}
+
+// Decrement decrements the TTL of each record of the message
+// by the number of seconds given, down to a minimum of zero.
+func Decrement(msg *dns.Msg, seconds uint32) {
+	for _, rrs := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range rrs {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT { // TTL field holds flags
+				continue
+			}
+			if header.Ttl > seconds {
+				header.Ttl -= seconds
+			} else {
+				header.Ttl = 0
+			}
+		}
+	}
+}
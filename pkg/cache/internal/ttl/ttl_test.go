@@ -0,0 +1,104 @@
+package ttl
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExpUnix(t *testing.T) {
+	t.Parallel()
+
+	const (
+		nowUnix        = 1000
+		maxNegativeTTL = 3600
+	)
+
+	soa := func(ttl, minTTL uint32) dns.RR {
+		return &dns.SOA{Hdr: dns.RR_Header{Ttl: ttl}, Minttl: minTTL}
+	}
+
+	testCases := map[string]struct {
+		response          *dns.Msg
+		noNegativeCaching bool
+		errorTTL          uint32
+		expUnix           int64
+		ok                bool
+	}{
+		"lowest answer TTL": {
+			response: &dns.Msg{Answer: []dns.RR{
+				&dns.A{Hdr: dns.RR_Header{Ttl: 300}},
+				&dns.A{Hdr: dns.RR_Header{Ttl: 100}},
+			}},
+			expUnix: 1100,
+			ok:      true,
+		},
+		"NXDOMAIN with SOA minimum": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+				Ns:     []dns.RR{soa(900, 60)},
+			},
+			expUnix: 1060,
+			ok:      true,
+		},
+		"NODATA with SOA TTL": {
+			response: &dns.Msg{
+				Ns: []dns.RR{&dns.NS{}, soa(30, 60)},
+			},
+			expUnix: 1030,
+			ok:      true,
+		},
+		"negative TTL capped": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+				Ns:     []dns.RR{soa(86400, 86400)},
+			},
+			expUnix: nowUnix + maxNegativeTTL,
+			ok:      true,
+		},
+		"negative caching disabled": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+				Ns:     []dns.RR{soa(900, 60)},
+			},
+			noNegativeCaching: true,
+		},
+		"negative without SOA": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeNameError},
+			},
+		},
+		"SERVFAIL not cached": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+			},
+		},
+		"SERVFAIL cached briefly": {
+			response: &dns.Msg{
+				MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+			},
+			errorTTL: 5,
+			expUnix:  1005,
+			ok:       true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			maxNegativeTTL := uint32(maxNegativeTTL)
+			if testCase.noNegativeCaching {
+				maxNegativeTTL = 0
+			}
+
+			expUnix, ok := ExpUnix(testCase.response, nowUnix,
+				maxNegativeTTL, testCase.errorTTL)
+
+			assert.Equal(t, testCase.expUnix, expUnix)
+			assert.Equal(t, testCase.ok, ok)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package key
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Make returns a key for the request, made from its lower cased
+// question name, question type and class, and the DNSSEC OK and
+// Checking Disabled bits which change the response content.
+// If withECS is true, the EDNS0 client subnet of the request, if any,
+// is also part of the key.
+// It returns false if the request has not exactly one question,
+// in which case it should not be cached.
+func Make(request *dns.Msg, withECS bool) (key string, ok bool) {
+	if len(request.Question) != 1 {
+		return "", false
+	}
+
+	question := request.Question[0]
+	var builder strings.Builder
+	builder.WriteString(strings.ToLower(question.Name))
+	builder.WriteByte('|')
+	builder.WriteString(strconv.Itoa(int(question.Qtype)))
+	builder.WriteByte('|')
+	builder.WriteString(strconv.Itoa(int(question.Qclass)))
+	builder.WriteByte('|')
+
+	if request.CheckingDisabled {
+		builder.WriteString("cd")
+	}
+
+	opt := request.IsEdns0()
+	if opt == nil {
+		return builder.String(), true
+	}
+
+	if opt.Do() {
+		builder.WriteString("do")
+	}
+
+	if !withECS {
+		return builder.String(), true
+	}
+
+	for _, option := range opt.Option {
+		subnet, ok := option.(*dns.EDNS0_SUBNET)
+		if !ok {
+			continue
+		}
+		builder.WriteByte('|')
+		builder.WriteString(subnetString(subnet))
+		break
+	}
+
+	return builder.String(), true
+}
+
+// subnetString returns the client subnet as a CIDR string
+// with the address masked to its source prefix length.
+func subnetString(subnet *dns.EDNS0_SUBNET) string {
+	const ipv4Family, ipv4Bits, ipv6Bits = 1, 32, 128
+	bits := ipv6Bits
+	if subnet.Family == ipv4Family {
+		bits = ipv4Bits
+	}
+
+	prefixLength := int(subnet.SourceNetmask)
+	if prefixLength > bits {
+		prefixLength = bits
+	}
+
+	ip := subnet.Address
+	if bits == ipv4Bits {
+		ip = ip.To4()
+	} else {
+		ip = ip.To16()
+	}
+	if ip == nil {
+		return "invalid"
+	}
+
+	mask := net.CIDRMask(prefixLength, bits)
+	return ip.Mask(mask).String() + "/" + strconv.Itoa(prefixLength)
+}
+
+// Prefix returns the prefix of the keys made
+// for requests with the name and type given.
+func Prefix(name string, qType uint16) string {
+	return strings.ToLower(dns.Fqdn(name)) + "|" + strconv.Itoa(int(qType)) + "|"
+}
+
+// InDomain returns true if the name of the key
+// is the domain given or one of its subdomains.
+func InDomain(key, domain string) bool {
+	name := key
+	if i := strings.IndexByte(key, '|'); i >= 0 {
+		name = key[:i]
+	}
+	return dns.IsSubDomain(dns.Fqdn(domain), name)
+}
@@ -0,0 +1,108 @@
+package key
+
+import (
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Make(t *testing.T) {
+	t.Parallel()
+
+	newRequest := func(name string) *dns.Msg {
+		return new(dns.Msg).SetQuestion(name, dns.TypeA)
+	}
+
+	withECS := func(request *dns.Msg, ip net.IP, family, bits uint16) *dns.Msg {
+		request.SetEdns0(dns.DefaultMsgSize, false)
+		opt := request.IsEdns0()
+		opt.Option = append(opt.Option, &dns.EDNS0_SUBNET{
+			Code:          dns.EDNS0SUBNET,
+			Family:        family,
+			SourceNetmask: uint8(bits),
+			Address:       ip,
+		})
+		return request
+	}
+
+	testCases := map[string]struct {
+		request *dns.Msg
+		withECS bool
+		key     string
+		ok      bool
+	}{
+		"no question": {
+			request: new(dns.Msg),
+		},
+		"multiple questions": {
+			request: &dns.Msg{Question: []dns.Question{
+				{Name: "a.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+				{Name: "b.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+			}},
+		},
+		"lower cased name": {
+			request: newRequest("ExAmple.COM."),
+			key:     "example.com.|1|1|",
+			ok:      true,
+		},
+		"checking disabled": {
+			request: func() *dns.Msg {
+				request := newRequest("example.com.")
+				request.CheckingDisabled = true
+				return request
+			}(),
+			key: "example.com.|1|1|cd",
+			ok:  true,
+		},
+		"DNSSEC OK": {
+			request: newRequest("example.com.").SetEdns0(dns.DefaultMsgSize, true),
+			key:     "example.com.|1|1|do",
+			ok:      true,
+		},
+		"client subnet ignored": {
+			request: withECS(newRequest("example.com."), net.IPv4(1, 2, 3, 4), 1, 24),
+			key:     "example.com.|1|1|",
+			ok:      true,
+		},
+		"IPv4 client subnet": {
+			request: withECS(newRequest("example.com."), net.IPv4(1, 2, 3, 4), 1, 24),
+			withECS: true,
+			key:     "example.com.|1|1||1.2.3.0/24",
+			ok:      true,
+		},
+		"IPv6 client subnet": {
+			request: withECS(newRequest("example.com."), net.ParseIP("2001:db8::1"), 2, 56),
+			withECS: true,
+			key:     "example.com.|1|1||2001:db8::/56",
+			ok:      true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			key, ok := Make(testCase.request, testCase.withECS)
+
+			assert.Equal(t, testCase.key, key)
+			assert.Equal(t, testCase.ok, ok)
+		})
+	}
+}
+
+func Test_InDomain(t *testing.T) {
+	t.Parallel()
+
+	key, ok := Make(new(dns.Msg).SetQuestion("a.Example.com.", dns.TypeA), false)
+	assert.True(t, ok)
+
+	assert.True(t, InDomain(key, "example.com"))
+	assert.True(t, InDomain(key, "a.example.com."))
+	assert.True(t, InDomain(key, "."))
+	assert.False(t, InDomain(key, "b.example.com"))
+	assert.False(t, InDomain(key, "ample.com"))
+	assert.Equal(t, "a.example.com.|1|", Prefix("A.example.com", dns.TypeA))
+}
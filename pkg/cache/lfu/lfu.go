@@ -0,0 +1,397 @@
+package lfu
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache/internal/freshness"
+	"github.com/qdm12/dns/pkg/cache/key"
+	"github.com/qdm12/dns/pkg/cache/stats"
+)
+
+// LFU is a least frequently used cache. Entries used the least
+// are evicted first, and the least recently used among them in
+// case of a tie. It resists to bursts of unique requests, since
+// these do not evict entries requested more than once. Frequencies
+// are halved periodically so entries popular in the past do not
+// stay in the cache forever once they are no longer requested.
+type LFU struct {
+	// Configuration
+	policy     *freshness.Policy
+	maxEntries int
+	maxBytes   int
+	agingHits  int
+
+	// Statistics
+	counters *stats.Counters
+
+	// State
+	kv            map[string]*list.Element
+	frequencies   map[int]*list.List // frequency to entries list
+	minFrequency  int
+	bytes         int
+	hitsSinceAged int
+	mutex         sync.Mutex
+
+	// Mock fields
+	timeNow func() time.Time
+}
+
+type entry struct {
+	key         string // from the DNS request
+	addedUnix   int64  // to decrement the response TTLs
+	expUnix     int64  // from the DNS response
+	response    *dns.Msg
+	size        int // in bytes, for statistics and the memory budget
+	frequency   int
+	hits        int  // to prefetch popular entries
+	prefetching bool // to prefetch only once at a time
+}
+
+func New(settings Settings) *LFU {
+	settings.SetDefaults()
+	return &LFU{
+		policy:      freshness.New(settings.Settings),
+		maxEntries:  settings.MaxEntries,
+		maxBytes:    settings.MaxBytes,
+		agingHits:   settings.AgingHits,
+		counters:    new(stats.Counters),
+		kv:          make(map[string]*list.Element, settings.MaxEntries),
+		frequencies: make(map[int]*list.List),
+		timeNow:     time.Now,
+	}
+}
+
+func (l *LFU) Add(request, response *dns.Msg) {
+	requestKey, ok := l.policy.Key(request)
+	if !ok {
+		return
+	}
+
+	nowUnix := l.timeNow().Unix()
+	responseCopy, expUnix, ok := l.policy.Prepare(response, nowUnix)
+	if !ok {
+		return
+	}
+
+	size := len(requestKey) + responseCopy.Len()
+	if l.maxBytes > 0 && size > l.maxBytes {
+		return
+	}
+
+	newEntry := &entry{
+		key:       requestKey,
+		addedUnix: nowUnix,
+		expUnix:   expUnix,
+		response:  responseCopy,
+		size:      size,
+		frequency: 1,
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if listElement, ok := l.kv[requestKey]; ok {
+		// keep the frequency and hits count of the entry replaced,
+		// to keep it in the cache and keep prefetching it.
+		oldEntry := listElement.Value.(*entry)
+		newEntry.frequency = oldEntry.frequency
+		newEntry.hits = oldEntry.hits
+		l.remove(listElement)
+	}
+
+	l.insert(newEntry)
+}
+
+func (l *LFU) Get(request *dns.Msg) (response *dns.Msg) {
+	requestKey, ok := l.policy.Key(request)
+	if !ok {
+		return nil
+	}
+	nowUnix := l.timeNow().Unix()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	listElement, ok := l.kv[requestKey]
+	if !ok {
+		l.counters.Miss()
+		return nil
+	}
+
+	entryPtr := listElement.Value.(*entry)
+
+	if nowUnix >= entryPtr.expUnix {
+		// expired record
+		l.counters.Miss()
+		if l.policy.Expired(entryPtr.expUnix, nowUnix) {
+			l.remove(listElement)
+			l.counters.Expire()
+		}
+		return nil
+	}
+
+	l.counters.Hit()
+	l.increment(listElement)
+
+	entryPtr.hits++
+	if l.policy.ShouldPrefetch(entryPtr.hits, entryPtr.prefetching,
+		entryPtr.addedUnix, entryPtr.expUnix, nowUnix) {
+		l.prefetch(request.Copy(), entryPtr)
+	}
+
+	return freshness.Response(entryPtr.response, entryPtr.addedUnix, entryPtr.expUnix, nowUnix)
+}
+
+// prefetch refreshes the entry in the background if there is
+// a prefetch slot available, and does nothing otherwise.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) prefetch(request *dns.Msg, entryPtr *entry) {
+	failed := func() {
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+		if listElement, ok := l.kv[entryPtr.key]; ok {
+			listElement.Value.(*entry).prefetching = false
+		}
+	}
+	entryPtr.prefetching = l.policy.Prefetch(request, l.Add, failed)
+}
+
+// GetStale returns the cached response for the request, even if it
+// has expired as long as it expired less than the stale window ago.
+// The TTLs of an expired response are set to 30 seconds.
+// It returns nil if no response is found.
+func (l *LFU) GetStale(request *dns.Msg) (response *dns.Msg) {
+	requestKey, ok := l.policy.Key(request)
+	if !ok {
+		return nil
+	}
+	nowUnix := l.timeNow().Unix()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	listElement, ok := l.kv[requestKey]
+	if !ok {
+		return nil
+	}
+
+	entryPtr := listElement.Value.(*entry)
+
+	if l.policy.Expired(entryPtr.expUnix, nowUnix) {
+		l.remove(listElement)
+		l.counters.Expire()
+		return nil
+	}
+
+	l.increment(listElement)
+	return freshness.Response(entryPtr.response, entryPtr.addedUnix, entryPtr.expUnix, nowUnix)
+}
+
+// RemoveExpired removes all the entries expired
+// for longer than the stale window.
+func (l *LFU) RemoveExpired() {
+	nowUnix := l.timeNow().Unix()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, listElement := range l.kv {
+		if l.policy.Expired(listElement.Value.(*entry).expUnix, nowUnix) {
+			l.remove(listElement)
+			l.counters.Expire()
+		}
+	}
+}
+
+// Stats returns statistics on the cache.
+func (l *LFU) Stats() stats.Stats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.counters.Snapshot(len(l.kv), l.bytes)
+}
+
+// Remove removes the entries for the name and type given.
+func (l *LFU) Remove(name string, qType uint16) {
+	prefix := key.Prefix(name, qType)
+	l.removeIf(func(requestKey string) bool {
+		return strings.HasPrefix(requestKey, prefix)
+	})
+}
+
+// RemoveDomain removes the entries for the domain
+// given and all its subdomains.
+func (l *LFU) RemoveDomain(domain string) {
+	l.removeIf(func(requestKey string) bool {
+		return key.InDomain(requestKey, domain)
+	})
+}
+
+func (l *LFU) removeIf(match func(requestKey string) bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for requestKey, listElement := range l.kv {
+		if match(requestKey) {
+			l.remove(listElement)
+		}
+	}
+}
+
+// Flush removes all the entries from the cache.
+func (l *LFU) Flush() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.kv = make(map[string]*list.Element, l.maxEntries)
+	l.frequencies = make(map[int]*list.List)
+	l.minFrequency = 0
+	l.bytes = 0
+	l.hitsSinceAged = 0
+}
+
+// insert inserts a new entry, evicting the least frequently
+// used entries until there is room for it in the cache.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) insert(entryPtr *entry) {
+	for len(l.kv) > 0 && l.full(entryPtr.size) {
+		l.evict()
+	}
+
+	l.kv[entryPtr.key] = l.frequencyList(entryPtr.frequency).PushFront(entryPtr)
+	l.bytes += entryPtr.size
+	if len(l.kv) == 1 || entryPtr.frequency < l.minFrequency {
+		l.minFrequency = entryPtr.frequency
+	}
+}
+
+// full returns true if an entry of the size given
+// cannot be added without exceeding the cache limits.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) full(size int) bool {
+	return (l.maxEntries > 0 && len(l.kv) >= l.maxEntries) ||
+		(l.maxBytes > 0 && l.bytes+size > l.maxBytes)
+}
+
+// increment moves the list element to the list of
+// the next frequency.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) increment(listElement *list.Element) {
+	entryPtr := listElement.Value.(*entry)
+	l.unlink(listElement)
+	if l.minFrequency == entryPtr.frequency && l.frequencies[entryPtr.frequency] == nil {
+		l.minFrequency++
+	}
+	entryPtr.frequency++
+	l.kv[entryPtr.key] = l.frequencyList(entryPtr.frequency).PushFront(entryPtr)
+
+	l.hitsSinceAged++
+	if l.agingHits > 0 && l.hitsSinceAged >= l.agingHits {
+		l.age()
+	}
+}
+
+// age halves the frequency of all the entries, keeping a
+// minimum frequency of 1, and keeps the order of entries,
+// such that entries requested a lot in the past but no
+// longer requested can eventually be evicted.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) age() {
+	l.hitsSinceAged = 0
+
+	frequencies := make([]int, 0, len(l.frequencies))
+	for frequency := range l.frequencies {
+		frequencies = append(frequencies, frequency)
+	}
+	sort.Ints(frequencies)
+
+	agedFrequencies := make(map[int]*list.List, len(l.frequencies))
+	for _, frequency := range frequencies {
+		agedFrequency := (frequency + 1) / 2 //nolint:gomnd
+		agedList, ok := agedFrequencies[agedFrequency]
+		if !ok {
+			agedList = list.New()
+			agedFrequencies[agedFrequency] = agedList
+		}
+
+		// Entries of higher frequencies are pushed last, so they
+		// are evicted after entries of lower frequencies merged
+		// in the same aged frequency list.
+		frequencyList := l.frequencies[frequency]
+		for listElement := frequencyList.Back(); listElement != nil; listElement = listElement.Prev() {
+			entryPtr := listElement.Value.(*entry)
+			entryPtr.frequency = agedFrequency
+			l.kv[entryPtr.key] = agedList.PushFront(entryPtr)
+		}
+	}
+
+	l.frequencies = agedFrequencies
+	l.minFrequency = (l.minFrequency + 1) / 2 //nolint:gomnd
+}
+
+// evict removes the least recently used entry
+// among the least frequently used entries.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) evict() {
+	frequencyList, ok := l.frequencies[l.minFrequency]
+	if !ok { // minimum frequency is outdated after removals
+		l.minFrequency = 0
+		for frequency := range l.frequencies {
+			if l.minFrequency == 0 || frequency < l.minFrequency {
+				l.minFrequency = frequency
+			}
+		}
+		frequencyList, ok = l.frequencies[l.minFrequency]
+		if !ok { // empty cache
+			return
+		}
+	}
+	l.remove(frequencyList.Back())
+	l.counters.Evict()
+}
+
+// remove removes a list element from the cache.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) remove(listElement *list.Element) {
+	l.unlink(listElement)
+	entryPtr := listElement.Value.(*entry)
+	delete(l.kv, entryPtr.key)
+	l.bytes -= entryPtr.size
+}
+
+// unlink removes a list element from its frequency list,
+// and removes the frequency list if it becomes empty.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) unlink(listElement *list.Element) {
+	frequency := listElement.Value.(*entry).frequency
+	frequencyList := l.frequencies[frequency]
+	frequencyList.Remove(listElement)
+	if frequencyList.Len() == 0 {
+		delete(l.frequencies, frequency)
+	}
+}
+
+// frequencyList returns the list for the frequency given,
+// creating it if needed.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (l *LFU) frequencyList(frequency int) *list.List {
+	frequencyList, ok := l.frequencies[frequency]
+	if !ok {
+		frequencyList = list.New()
+		l.frequencies[frequency] = frequencyList
+	}
+	return frequencyList
+}
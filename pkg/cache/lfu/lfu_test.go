@@ -0,0 +1,222 @@
+package lfu
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMsgs(name string, ttl uint32) (request, response *dns.Msg) {
+	request = new(dns.Msg).SetQuestion(name, dns.TypeA)
+	response = new(dns.Msg).SetReply(request)
+	response.Answer = []dns.RR{&dns.A{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+		A:   net.IPv4(1, 2, 3, 4),
+	}}
+	return request, response
+}
+
+func Test_LFU_scanResistance(t *testing.T) {
+	t.Parallel()
+
+	lfu := New(Settings{MaxEntries: 3})
+
+	hotRequests := make([]*dns.Msg, 2)
+	for i := range hotRequests {
+		request, response := newTestMsgs("hot"+strconv.Itoa(i)+".", 300)
+		lfu.Add(request, response)
+		require.NotNil(t, lfu.Get(request))
+		hotRequests[i] = request
+	}
+
+	// Burst of unique names
+	for i := 0; i < 100; i++ {
+		request, response := newTestMsgs("scan"+strconv.Itoa(i)+".", 300)
+		lfu.Add(request, response)
+	}
+
+	for _, request := range hotRequests {
+		assert.NotNil(t, lfu.Get(request))
+	}
+	lastScanRequest, _ := newTestMsgs("scan99.", 300)
+	assert.NotNil(t, lfu.Get(lastScanRequest))
+	assert.Len(t, lfu.kv, 3)
+}
+
+func Test_LFU_aging(t *testing.T) {
+	t.Parallel()
+
+	lfu := New(Settings{MaxEntries: 2, AgingHits: 4})
+
+	oldRequest, oldResponse := newTestMsgs("old.", 300)
+	lfu.Add(oldRequest, oldResponse)
+	for i := 0; i < 50; i++ {
+		require.NotNil(t, lfu.Get(oldRequest))
+	}
+
+	newRequest, newResponse := newTestMsgs("new.", 300)
+	lfu.Add(newRequest, newResponse)
+	for i := 0; i < 12; i++ {
+		require.NotNil(t, lfu.Get(newRequest))
+	}
+
+	// The old entry was requested more in total, but its
+	// frequency decayed since it is no longer requested.
+	request, response := newTestMsgs("other.", 300)
+	lfu.Add(request, response)
+
+	assert.Nil(t, lfu.Get(oldRequest))
+	assert.NotNil(t, lfu.Get(newRequest))
+	assert.NotNil(t, lfu.Get(request))
+}
+
+func Test_LFU_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	requestA, responseA := newTestMsgs("A.", 300)
+	requestB, responseB := newTestMsgs("B.", 300)
+	requestC, responseC := newTestMsgs("C.", 300)
+	entrySize := len("A.|1|1|") + responseA.Len()
+
+	lfu := New(Settings{MaxBytes: 2 * entrySize})
+
+	lfu.Add(requestA, responseA)
+	require.NotNil(t, lfu.Get(requestA))
+	lfu.Add(requestB, responseB)
+	lfu.Add(requestC, responseC)
+
+	assert.NotNil(t, lfu.Get(requestA))
+	assert.Nil(t, lfu.Get(requestB))
+	assert.NotNil(t, lfu.Get(requestC))
+	assert.Equal(t, 2*entrySize, lfu.bytes)
+
+	requestBig, responseBig := newTestMsgs("big.", 300)
+	responseBig.Answer = append(responseBig.Answer, &dns.TXT{
+		Hdr: dns.RR_Header{Name: "big.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 300},
+		Txt: []string{strings.Repeat("x", 3*entrySize)},
+	})
+	lfu.Add(requestBig, responseBig)
+	assert.Nil(t, lfu.Get(requestBig))
+	assert.Len(t, lfu.kv, 2)
+}
+
+func Test_LFU_prefetch(t *testing.T) {
+	t.Parallel()
+
+	request, response := newTestMsgs("prefetch.", 100)
+	_, refreshed := newTestMsgs("prefetch.", 200)
+	exchanged := make(chan struct{})
+
+	settings := Settings{}
+	settings.PrefetchMinHits = 2
+	settings.Exchange = func(request *dns.Msg) (*dns.Msg, error) {
+		defer close(exchanged)
+		return refreshed, nil
+	}
+
+	lfu := New(settings)
+	var mutex sync.Mutex
+	now := time.Unix(1000, 0)
+	lfu.timeNow = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return now
+	}
+
+	lfu.Add(request, response)
+
+	// First hit does not trigger a prefetch
+	mutex.Lock()
+	now = now.Add(95 * time.Second)
+	mutex.Unlock()
+	cached := lfu.Get(request)
+	assert.Equal(t, uint32(5), cached.Answer[0].Header().Ttl)
+
+	// Second hit triggers a prefetch
+	cached = lfu.Get(request)
+	assert.Equal(t, uint32(5), cached.Answer[0].Header().Ttl)
+
+	<-exchanged
+	assert.Eventually(t, func() bool {
+		cached = lfu.Get(request)
+		return cached.Answer[0].Header().Ttl == 200
+	}, time.Second, time.Millisecond)
+}
+
+func Test_LFU_expiry(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{}
+	settings.StaleWindow = time.Minute
+	lfu := New(settings)
+	now := time.Unix(1000, 0)
+	lfu.timeNow = func() time.Time { return now }
+
+	request, response := newTestMsgs("a.", 100)
+	lfu.Add(request, response)
+
+	now = now.Add(40 * time.Second)
+	cached := lfu.Get(request)
+	require.NotNil(t, cached)
+	assert.Equal(t, uint32(60), cached.Answer[0].Header().Ttl)
+
+	now = now.Add(time.Minute)
+	assert.Nil(t, lfu.Get(request))
+	stale := lfu.GetStale(request)
+	require.NotNil(t, stale)
+	assert.Equal(t, uint32(30), stale.Answer[0].Header().Ttl)
+
+	now = now.Add(time.Minute)
+	lfu.RemoveExpired()
+	assert.Empty(t, lfu.kv)
+	assert.Empty(t, lfu.frequencies)
+}
+
+func Test_LFU_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	lfu := New(Settings{})
+	requestA, responseA := newTestMsgs("a.", 100)
+	requestB, responseB := newTestMsgs("b.", 100)
+	lfu.Add(requestA, responseA)
+	lfu.Add(requestB, responseB)
+	_ = lfu.Get(requestB)
+
+	buffer := bytes.NewBuffer(nil)
+	require.NoError(t, lfu.Save(buffer))
+
+	loaded := New(Settings{MaxEntries: 1})
+	require.NoError(t, loaded.Load(buffer))
+
+	assert.Nil(t, loaded.Get(requestA))
+	assert.NotNil(t, loaded.Get(requestB))
+}
+
+func Test_LFU_management(t *testing.T) {
+	t.Parallel()
+
+	lfu := New(Settings{})
+
+	for _, name := range []string{"a.example.com.", "example.com.", "example.org."} {
+		request, response := newTestMsgs(name, 100)
+		lfu.Add(request, response)
+	}
+	assert.Equal(t, 3, lfu.Stats().Entries)
+
+	lfu.Remove("example.org.", dns.TypeA)
+	assert.Equal(t, 2, lfu.Stats().Entries)
+
+	lfu.RemoveDomain("example.com.")
+	stats := lfu.Stats()
+	assert.Zero(t, stats.Entries)
+	assert.Zero(t, stats.Bytes)
+	assert.Empty(t, lfu.frequencies)
+}
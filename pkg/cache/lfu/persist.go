@@ -0,0 +1,108 @@
+package lfu
+
+import (
+	"bufio"
+	"io"
+	"sort"
+
+	"github.com/qdm12/dns/pkg/cache/internal/persist"
+)
+
+// The file contains the entries from the least to the most
+// frequently used. Frequencies are not saved, so loaded
+// entries all start with a frequency of 1.
+const (
+	fileHeader  = "qdm12/dns lfu cache"
+	fileVersion = 1
+)
+
+var (
+	ErrFileHeader  = persist.ErrFileHeader
+	ErrFileVersion = persist.ErrFileVersion
+	ErrFileCorrupt = persist.ErrFileCorrupt
+)
+
+// Save writes all the cache entries to the writer,
+// with their absolute expiry times.
+func (l *LFU) Save(writer io.Writer) (err error) {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	err = persist.WriteHeader(bufferedWriter, fileHeader, fileVersion)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	frequencies := make([]int, 0, len(l.frequencies))
+	for frequency := range l.frequencies {
+		frequencies = append(frequencies, frequency)
+	}
+	sort.Ints(frequencies)
+
+	for _, frequency := range frequencies {
+		frequencyList := l.frequencies[frequency]
+		for listElement := frequencyList.Back(); listElement != nil; listElement = listElement.Prev() {
+			entryPtr := listElement.Value.(*entry)
+			err = persist.WriteEntry(bufferedWriter, persist.Entry{
+				Key:       entryPtr.key,
+				AddedUnix: entryPtr.addedUnix,
+				ExpUnix:   entryPtr.expUnix,
+				Response:  entryPtr.response,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
+
+// Load reads cache entries from the reader and adds them to the
+// cache, discarding entries expired beyond the stale window.
+// If the reader content is not valid, the cache is left unchanged.
+func (l *LFU) Load(reader io.Reader) (err error) {
+	bufferedReader := bufio.NewReader(reader)
+
+	err = persist.ReadHeader(bufferedReader, fileHeader, fileVersion)
+	if err != nil {
+		return err
+	}
+
+	entries, err := persist.ReadEntries(bufferedReader)
+	if err != nil {
+		return err
+	}
+
+	nowUnix := l.timeNow().Unix()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for _, fileEntry := range entries {
+		if l.policy.Expired(fileEntry.ExpUnix, nowUnix) {
+			continue
+		}
+
+		if l.maxBytes > 0 && fileEntry.Size > l.maxBytes {
+			continue
+		}
+
+		if listElement, ok := l.kv[fileEntry.Key]; ok {
+			l.remove(listElement)
+		}
+
+		l.insert(&entry{
+			key:       fileEntry.Key,
+			addedUnix: fileEntry.AddedUnix,
+			expUnix:   fileEntry.ExpUnix,
+			response:  fileEntry.Response,
+			size:      fileEntry.Size,
+			frequency: 1,
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package lfu
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/qdm12/dns/pkg/cache/internal/freshness"
+)
+
+// ExchangeFunc exchanges a request with the upstream server,
+// and is used to refresh cache entries in the background.
+type ExchangeFunc = freshness.ExchangeFunc
+
+type Settings struct {
+	MaxEntries int
+	// MaxBytes is the memory budget in bytes for the cache entries,
+	// based on the size of their packed DNS response.
+	// It defaults to 0, meaning there is no memory budget.
+	MaxBytes int
+	// AgingHits is the number of cache hits after which the
+	// frequencies of all the entries are halved, so entries no
+	// longer requested can eventually be evicted.
+	// It defaults to 10 times MaxEntries.
+	AgingHits int
+	// Settings are the TTL, stale answers and prefetch settings,
+	// shared with the other caches.
+	freshness.Settings
+}
+
+func (s *Settings) SetDefaults() {
+	if s.MaxEntries == 0 {
+		s.MaxEntries = 10e4
+	}
+
+	if s.AgingHits == 0 {
+		const agingHitsPerEntry = 10
+		s.AgingHits = agingHitsPerEntry * s.MaxEntries
+	}
+
+	s.Settings.SetDefaults()
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	lines = append(lines, subSection+"Max entries: "+strconv.Itoa(s.MaxEntries))
+	if s.MaxBytes > 0 {
+		lines = append(lines, subSection+"Max bytes: "+strconv.Itoa(s.MaxBytes))
+	}
+	lines = append(lines, subSection+"Frequencies halved every: "+strconv.Itoa(s.AgingHits)+" hits")
+	lines = append(lines, s.Settings.Lines(indent, subSection)...)
+	return lines
+}
@@ -2,20 +2,28 @@ package lru
 
 import (
 	"container/list"
-	"sync"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache/internal/freshness"
+	"github.com/qdm12/dns/pkg/cache/key"
+	"github.com/qdm12/dns/pkg/cache/stats"
 )
 
 type LRU struct {
 	// Configuration
-	maxEntries int
+	policy   *freshness.Policy
+	maxBytes int
+
+	// Statistics
+	counters   *stats.Counters
+	totalBytes *int64
 
 	// State
-	kv         map[string]*list.Element
-	linkedList *list.List
-	mutex      sync.Mutex
+	shards []*shard
 
 	// Mock fields
 	timeNow func() time.Time
@@ -23,92 +31,260 @@ type LRU struct {
 
 func New(settings Settings) *LRU {
 	settings.SetDefaults()
+
+	counters := new(stats.Counters)
+	totalBytes := new(int64)
+	shardsCount := settings.Shards
+	// Sharding a small cache only degrades its least recently
+	// used eviction order, so each shard holds a minimum of entries.
+	const minShardEntries = 100
+	if maxShards := settings.MaxEntries / minShardEntries; maxShards < shardsCount {
+		shardsCount = maxShards
+	}
+	if shardsCount == 0 {
+		shardsCount = 1
+	}
+	shards := make([]*shard, shardsCount)
+	for i := range shards {
+		maxEntries := split(settings.MaxEntries, shardsCount, i)
+		shards[i] = newShard(maxEntries, counters, totalBytes)
+	}
+
 	return &LRU{
-		maxEntries: settings.MaxEntries,
-		kv:         make(map[string]*list.Element, settings.MaxEntries),
-		linkedList: list.New(),
+		policy:     freshness.New(settings.Settings),
+		maxBytes:   settings.MaxBytes,
+		counters:   counters,
+		totalBytes: totalBytes,
+		shards:     shards,
 		timeNow:    time.Now,
 	}
 }
 
-func (l *LRU) Add(request, response *dns.Msg) {
-	if len(request.Question) == 0 {
-		// cannot make key if there is no question
-		return
+// split returns the part of total for the i-th of n parts, where the
+// remainder of the division is spread over the first parts, such
+// that the sum of all the parts is exactly total.
+func split(total, n, i int) (part int) {
+	part = total / n
+	if i < total%n {
+		part++
 	}
+	return part
+}
 
-	key := makeKey(request)
-	expUnix := getExpUnix(response, l.timeNow().Unix())
-	responseCopy := response.Copy()
+// getShard returns the shard responsible for the key given.
+func (l *LRU) getShard(requestKey string) *shard {
+	if len(l.shards) == 1 {
+		return l.shards[0]
+	}
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(requestKey))
+	return l.shards[hasher.Sum32()%uint32(len(l.shards))]
+}
 
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
+func (l *LRU) Add(request, response *dns.Msg) {
+	requestKey, ok := l.policy.Key(request)
+	if !ok {
+		return
+	}
 
-	if listElement, ok := l.kv[key]; ok {
-		l.linkedList.MoveToFront(listElement)
-		entryPtr := listElement.Value.(*entry)
-		entryPtr.expUnix = expUnix
-		entryPtr.response = responseCopy
+	nowUnix := l.timeNow().Unix()
+	responseCopy, expUnix, ok := l.policy.Prepare(response, nowUnix)
+	if !ok {
 		return
 	}
 
-	entry := &entry{
-		key:      key,
-		expUnix:  expUnix,
-		response: responseCopy,
+	size := len(requestKey) + responseCopy.Len()
+	if l.maxBytes > 0 && size > l.maxBytes {
+		return
 	}
 
-	listElement := l.linkedList.PushFront(entry)
-	l.kv[key] = listElement
+	newEntry := &entry{
+		key:       requestKey,
+		addedUnix: nowUnix,
+		expUnix:   expUnix,
+		response:  responseCopy,
+		size:      size,
+	}
 
-	if l.maxEntries > 0 && l.linkedList.Len() > l.maxEntries {
-		l.removeOldest()
+	shard := l.getShard(requestKey)
+	shard.mutex.Lock()
+	if listElement, ok := shard.kv[requestKey]; ok {
+		// keep the hits count to keep prefetching popular entries
+		newEntry.hits = listElement.Value.(*entry).hits
 	}
+	listElement := shard.set(newEntry)
+	shard.mutex.Unlock()
+
+	l.evictBytes(shard, listElement)
 }
 
-func (l *LRU) Get(request *dns.Msg) (response *dns.Msg) {
-	if len(request.Question) == 0 {
-		// cannot make key if there is no question
+// evictBytes evicts entries while the cache size exceeds its
+// maximum bytes. The least recently used entries of the shard
+// given are evicted first, except the list element to keep,
+// followed by the ones of the other shards, one shard at a time.
+func (l *LRU) evictBytes(first *shard, keep *list.Element) {
+	if l.maxBytes == 0 {
 		return
 	}
 
-	key := makeKey(request)
+	first.mutex.Lock()
+	first.evictBytes(l.maxBytes, keep)
+	first.mutex.Unlock()
+
+	for _, shard := range l.shards {
+		if atomic.LoadInt64(l.totalBytes) <= int64(l.maxBytes) {
+			return
+		} else if shard == first {
+			continue
+		}
+		shard.mutex.Lock()
+		shard.evictBytes(l.maxBytes, nil)
+		shard.mutex.Unlock()
+	}
+}
+
+func (l *LRU) Get(request *dns.Msg) (response *dns.Msg) {
+	requestKey, ok := l.policy.Key(request)
+	if !ok {
+		return nil
+	}
 	nowUnix := l.timeNow().Unix()
 
-	l.mutex.Lock()
-	defer l.mutex.Unlock()
+	shard := l.getShard(requestKey)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
 
-	listElement, ok := l.kv[key]
+	listElement, ok := shard.kv[requestKey]
 	if !ok {
+		l.counters.Miss()
 		return nil
 	}
 
-	l.linkedList.MoveToFront(listElement)
+	shard.linkedList.MoveToFront(listElement)
 	entryPtr := listElement.Value.(*entry)
 
 	if nowUnix >= entryPtr.expUnix {
 		// expired record
-		l.remove(listElement)
+		l.counters.Miss()
+		if l.policy.Expired(entryPtr.expUnix, nowUnix) {
+			shard.remove(listElement)
+			l.counters.Expire()
+		}
 		return nil
 	}
 
-	return entryPtr.response.Copy()
+	l.counters.Hit()
+	entryPtr.hits++
+	if l.policy.ShouldPrefetch(entryPtr.hits, entryPtr.prefetching,
+		entryPtr.addedUnix, entryPtr.expUnix, nowUnix) {
+		l.prefetch(request.Copy(), shard, entryPtr)
+	}
+
+	return freshness.Response(entryPtr.response, entryPtr.addedUnix, entryPtr.expUnix, nowUnix)
 }
 
-// remove removes a list element
+// prefetch refreshes the entry in the background if there is
+// a prefetch slot available, and does nothing otherwise.
 // It is NOT thread safe and its parent should have
-// a locking mechanism to stay thread safe.
-func (l *LRU) remove(listElement *list.Element) {
-	l.linkedList.Remove(listElement)
+// a locking mechanism on the shard to stay thread safe.
+func (l *LRU) prefetch(request *dns.Msg, shard *shard, entryPtr *entry) {
+	failed := func() {
+		shard.mutex.Lock()
+		defer shard.mutex.Unlock()
+		if listElement, ok := shard.kv[entryPtr.key]; ok {
+			listElement.Value.(*entry).prefetching = false
+		}
+	}
+	entryPtr.prefetching = l.policy.Prefetch(request, l.Add, failed)
+}
+
+// GetStale returns the cached response for the request, even if it
+// has expired as long as it expired less than the stale window ago.
+// The TTLs of an expired response are set to 30 seconds.
+// It returns nil if no response is found.
+func (l *LRU) GetStale(request *dns.Msg) (response *dns.Msg) {
+	requestKey, ok := l.policy.Key(request)
+	if !ok {
+		return nil
+	}
+	nowUnix := l.timeNow().Unix()
+
+	shard := l.getShard(requestKey)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	listElement, ok := shard.kv[requestKey]
+	if !ok {
+		return nil
+	}
+
 	entryPtr := listElement.Value.(*entry)
-	delete(l.kv, entryPtr.key)
+
+	if l.policy.Expired(entryPtr.expUnix, nowUnix) {
+		shard.remove(listElement)
+		l.counters.Expire()
+		return nil
+	}
+
+	shard.linkedList.MoveToFront(listElement)
+	return freshness.Response(entryPtr.response, entryPtr.addedUnix, entryPtr.expUnix, nowUnix)
 }
 
-// It is NOT thread safe and its parent should have
-// a locking mechanism to stay thread safe.
-func (l *LRU) removeOldest() {
-	listElement := l.linkedList.Back()
-	if listElement != nil {
-		l.remove(listElement)
+// RemoveExpired removes all the entries expired
+// for longer than the stale window.
+func (l *LRU) RemoveExpired() {
+	nowUnix := l.timeNow().Unix()
+	expired := func(expUnix int64) bool {
+		return l.policy.Expired(expUnix, nowUnix)
+	}
+	for _, shard := range l.shards {
+		shard.mutex.Lock()
+		shard.removeExpired(expired)
+		shard.mutex.Unlock()
+	}
+}
+
+// Stats returns statistics on the cache.
+func (l *LRU) Stats() stats.Stats {
+	var entries, bytes int
+	for _, shard := range l.shards {
+		shard.mutex.Lock()
+		entries += shard.linkedList.Len()
+		bytes += shard.bytes
+		shard.mutex.Unlock()
+	}
+	return l.counters.Snapshot(entries, bytes)
+}
+
+// Remove removes the entries for the name and type given.
+func (l *LRU) Remove(name string, qType uint16) {
+	prefix := key.Prefix(name, qType)
+	l.removeIf(func(requestKey string) bool {
+		return strings.HasPrefix(requestKey, prefix)
+	})
+}
+
+// RemoveDomain removes the entries for the domain
+// given and all its subdomains.
+func (l *LRU) RemoveDomain(domain string) {
+	l.removeIf(func(requestKey string) bool {
+		return key.InDomain(requestKey, domain)
+	})
+}
+
+func (l *LRU) removeIf(match func(requestKey string) bool) {
+	for _, shard := range l.shards {
+		shard.mutex.Lock()
+		shard.removeIf(match)
+		shard.mutex.Unlock()
+	}
+}
+
+// Flush removes all the entries from the cache.
+func (l *LRU) Flush() {
+	for _, shard := range l.shards {
+		shard.mutex.Lock()
+		shard.flush()
+		shard.mutex.Unlock()
 	}
 }
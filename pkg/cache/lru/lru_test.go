@@ -1,6 +1,9 @@
 package lru
 
 import (
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -8,21 +11,27 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func newTestMsgs(name string, expUnix uint32) (request, response *dns.Msg) {
+func newTestMsgs(name string, ttl uint32) (request, response *dns.Msg) {
 	request = &dns.Msg{Question: []dns.Question{{Name: name}}}
 	response = &dns.Msg{Answer: []dns.RR{&dns.TXT{
 		Txt: []string{name},
-		Hdr: dns.RR_Header{Ttl: expUnix},
+		Hdr: dns.RR_Header{Ttl: ttl},
 	}}}
 	response = response.Copy() // transform nil slices -> empty slices
 	return request, response
 }
 
+func countEntries(l *LRU) (count int) {
+	for _, shard := range l.shards {
+		count += shard.linkedList.Len()
+	}
+	return count
+}
+
 func Test_lru_e2e(t *testing.T) {
 	t.Parallel()
 
-	nowUnix := uint32(time.Now().Unix())
-	expUnix := nowUnix + 1000
+	const ttl = 1000
 
 	const (
 		maxEntries = 2
@@ -31,11 +40,13 @@ func Test_lru_e2e(t *testing.T) {
 		MaxEntries: maxEntries,
 	}
 
-	requestA, responseA := newTestMsgs("A", expUnix)
-	requestB, responseB := newTestMsgs("B", expUnix)
-	requestC, responseC := newTestMsgs("C", expUnix)
+	requestA, responseA := newTestMsgs("A", ttl)
+	requestB, responseB := newTestMsgs("B", ttl)
+	requestC, responseC := newTestMsgs("C", ttl)
 
 	lru := New(settings)
+	now := time.Now()
+	lru.timeNow = func() time.Time { return now }
 
 	lru.Add(requestA, responseA)
 	lru.Add(requestB, responseB)
@@ -51,3 +62,286 @@ func Test_lru_e2e(t *testing.T) {
 	response = lru.Get(requestC)
 	assert.Equal(t, responseC, response)
 }
+
+func Test_lru_TTL(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{}
+	settings.MinTTL = 60 * time.Second
+	settings.MaxTTL = time.Hour
+
+	lru := New(settings)
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time { return now }
+
+	request, response := newTestMsgs("A", 86400)
+	response.Answer = append(response.Answer, &dns.TXT{
+		Hdr: dns.RR_Header{Ttl: 10},
+	})
+	lru.Add(request, response)
+
+	now = now.Add(40 * time.Second)
+	cached := lru.Get(request)
+	assert.Equal(t, uint32(3560), cached.Answer[0].Header().Ttl)
+	assert.Equal(t, uint32(20), cached.Answer[1].Header().Ttl)
+	assert.Equal(t, uint32(86400), response.Answer[0].Header().Ttl)
+
+	now = now.Add(20 * time.Second)
+	cached = lru.Get(request)
+	assert.Nil(t, cached)
+}
+
+func Test_lru_GetStale(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{}
+	settings.StaleWindow = time.Hour
+
+	lru := New(settings)
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time { return now }
+
+	request, response := newTestMsgs("A", 100)
+	lru.Add(request, response)
+
+	now = now.Add(10 * time.Second)
+	stale := lru.GetStale(request)
+	assert.Equal(t, uint32(90), stale.Answer[0].Header().Ttl)
+
+	now = now.Add(100 * time.Second)
+	assert.Nil(t, lru.Get(request))
+	stale = lru.GetStale(request)
+	assert.Equal(t, uint32(30), stale.Answer[0].Header().Ttl)
+
+	now = now.Add(time.Hour)
+	assert.Nil(t, lru.GetStale(request))
+	assert.Zero(t, countEntries(lru))
+}
+
+func Test_lru_prefetch(t *testing.T) {
+	t.Parallel()
+
+	request, response := newTestMsgs("A", 100)
+	_, refreshed := newTestMsgs("A", 200)
+
+	exchanged := make(chan struct{})
+	settings := Settings{}
+	settings.PrefetchMinHits = 2
+	settings.Exchange = func(request *dns.Msg) (*dns.Msg, error) {
+		defer close(exchanged)
+		return refreshed, nil
+	}
+
+	lru := New(settings)
+	var mutex sync.Mutex
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return now
+	}
+
+	lru.Add(request, response)
+
+	// First hit does not trigger a prefetch
+	mutex.Lock()
+	now = now.Add(95 * time.Second)
+	mutex.Unlock()
+	cached := lru.Get(request)
+	assert.Equal(t, uint32(5), cached.Answer[0].Header().Ttl)
+
+	// Second hit triggers a prefetch
+	cached = lru.Get(request)
+	assert.Equal(t, uint32(5), cached.Answer[0].Header().Ttl)
+
+	<-exchanged
+	assert.Eventually(t, func() bool {
+		cached = lru.Get(request)
+		return cached.Answer[0].Header().Ttl == 200
+	}, time.Second, time.Millisecond)
+}
+
+func Test_lru_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	requestA, responseA := newTestMsgs("A", 100)
+	requestB, responseB := newTestMsgs("B", 100)
+	requestC, responseC := newTestMsgs("C", 100)
+	entrySize := len("A|0|0|") + responseA.Len()
+
+	settings := Settings{
+		MaxBytes: 2 * entrySize,
+	}
+	lru := New(settings)
+
+	lru.Add(requestA, responseA)
+	lru.Add(requestB, responseB)
+	lru.Add(requestC, responseC)
+
+	// The entry added last is kept, and one of the
+	// two other entries is evicted.
+	assert.NotNil(t, lru.Get(requestC))
+	stats := lru.Stats()
+	assert.Equal(t, 2*entrySize, stats.Bytes)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Evictions)
+
+	requestBig, responseBig := newTestMsgs("big", 100)
+	responseBig.Answer[0].(*dns.TXT).Txt = []string{strings.Repeat("x", 3*entrySize)}
+	lru.Add(requestBig, responseBig)
+	assert.Nil(t, lru.Get(requestBig))
+	assert.Equal(t, 2, countEntries(lru))
+}
+
+func Test_lru_capacity(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		settings     Settings
+		entries      int
+		responseSize int
+		maxEntries   int
+		maxBytes     int
+	}{
+		"max entries smaller than shards": {
+			settings:   Settings{MaxEntries: 2},
+			entries:    10,
+			maxEntries: 2,
+		},
+		"max entries not divisible by shards": {
+			settings:   Settings{MaxEntries: 1650},
+			entries:    2000,
+			maxEntries: 1650,
+		},
+		"max bytes": {
+			settings:   Settings{MaxBytes: 1000},
+			entries:    100,
+			maxEntries: 100,
+			maxBytes:   1000,
+		},
+		"response larger than a shard share of max bytes": {
+			settings:     Settings{MaxBytes: 1000},
+			entries:      100,
+			responseSize: 250,
+			maxEntries:   100,
+			maxBytes:     1000,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lru := New(testCase.settings)
+
+			for i := 0; i < testCase.entries; i++ {
+				request, response := newTestMsgs(strconv.Itoa(i)+".", 100)
+				if testCase.responseSize > 0 {
+					txt := response.Answer[0].(*dns.TXT)
+					txt.Txt = []string{strings.Repeat("x", testCase.responseSize-response.Len())}
+				}
+				lru.Add(request, response)
+
+				// The entry added last is always cached.
+				assert.NotNil(t, lru.Get(request))
+
+				stats := lru.Stats()
+				assert.LessOrEqual(t, stats.Entries, testCase.maxEntries)
+				if testCase.maxBytes > 0 {
+					assert.LessOrEqual(t, stats.Bytes, testCase.maxBytes)
+				}
+			}
+
+			if testCase.maxBytes == 0 {
+				assert.Equal(t, testCase.maxEntries, lru.Stats().Entries)
+			}
+		})
+	}
+}
+
+func Test_lru_RemoveExpired(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{}
+	settings.StaleWindow = 10 * time.Second
+	lru := New(settings)
+	now := time.Unix(1000, 0)
+	lru.timeNow = func() time.Time { return now }
+
+	requestA, responseA := newTestMsgs("A", 10)
+	requestB, responseB := newTestMsgs("B", 100)
+	lru.Add(requestA, responseA)
+	lru.Add(requestB, responseB)
+
+	now = now.Add(15 * time.Second)
+	lru.RemoveExpired()
+	assert.Equal(t, 2, countEntries(lru))
+
+	now = now.Add(5 * time.Second)
+	lru.RemoveExpired()
+	assert.Equal(t, 1, countEntries(lru))
+	assert.NotNil(t, lru.Get(requestB))
+}
+
+func Benchmark_lru_Get(b *testing.B) {
+	for _, shards := range []int{1, 16} {
+		shards := shards
+		b.Run(strconv.Itoa(shards)+" shards", func(b *testing.B) {
+			lru := New(Settings{Shards: shards})
+			const entries = 1000
+			requests := make([]*dns.Msg, entries)
+			for i := range requests {
+				request, response := newTestMsgs(strconv.Itoa(i), 3600)
+				lru.Add(request, response)
+				requests[i] = request
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					_ = lru.Get(requests[i%entries])
+					i++
+				}
+			})
+		})
+	}
+}
+
+func Test_lru_management(t *testing.T) {
+	t.Parallel()
+
+	lru := New(Settings{MaxEntries: 3, Shards: 1})
+
+	requests := make([]*dns.Msg, 4)
+	for i, name := range []string{"a.example.com.", "b.example.com.", "example.org.", "c.example.com."} {
+		request, response := newTestMsgs(name, 100)
+		request.Question[0].Qtype = dns.TypeA
+		lru.Add(request, response)
+		requests[i] = request
+	}
+
+	assert.Nil(t, lru.Get(requests[0])) // evicted
+	assert.NotNil(t, lru.Get(requests[1]))
+
+	stats := lru.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 3, stats.Entries)
+
+	lru.Remove("B.example.com", dns.TypeAAAA)
+	assert.Equal(t, 3, lru.Stats().Entries)
+	lru.Remove("B.example.com", dns.TypeA)
+	assert.Equal(t, 2, lru.Stats().Entries)
+
+	lru.RemoveDomain("example.com")
+	assert.Equal(t, 1, lru.Stats().Entries)
+	assert.NotNil(t, lru.Get(requests[2]))
+
+	lru.Flush()
+	stats = lru.Stats()
+	assert.Zero(t, stats.Entries)
+	assert.Zero(t, stats.Bytes)
+}
@@ -0,0 +1,101 @@
+package lru
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/qdm12/dns/pkg/cache/internal/persist"
+)
+
+// The file contains the entries of each shard,
+// from the least to the most recently used.
+const (
+	fileHeader  = "qdm12/dns lru cache"
+	fileVersion = 2
+)
+
+var (
+	ErrFileHeader  = persist.ErrFileHeader
+	ErrFileVersion = persist.ErrFileVersion
+	ErrFileCorrupt = persist.ErrFileCorrupt
+)
+
+// Save writes all the cache entries to the writer,
+// with their absolute expiry times.
+func (l *LRU) Save(writer io.Writer) (err error) {
+	bufferedWriter := bufio.NewWriter(writer)
+
+	err = persist.WriteHeader(bufferedWriter, fileHeader, fileVersion)
+	if err != nil {
+		return err
+	}
+
+	for _, shard := range l.shards {
+		err = saveShard(bufferedWriter, shard)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bufferedWriter.Flush()
+}
+
+func saveShard(writer io.Writer, shard *shard) (err error) {
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	for listElement := shard.linkedList.Back(); listElement != nil; listElement = listElement.Prev() {
+		entryPtr := listElement.Value.(*entry)
+		err = persist.WriteEntry(writer, persist.Entry{
+			Key:       entryPtr.key,
+			AddedUnix: entryPtr.addedUnix,
+			ExpUnix:   entryPtr.expUnix,
+			Response:  entryPtr.response,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load reads cache entries from the reader and adds them to the
+// cache, discarding entries expired beyond the stale window.
+// If the reader content is not valid, the cache is left unchanged.
+func (l *LRU) Load(reader io.Reader) (err error) {
+	bufferedReader := bufio.NewReader(reader)
+
+	err = persist.ReadHeader(bufferedReader, fileHeader, fileVersion)
+	if err != nil {
+		return err
+	}
+
+	entries, err := persist.ReadEntries(bufferedReader)
+	if err != nil {
+		return err
+	}
+
+	nowUnix := l.timeNow().Unix()
+	for _, fileEntry := range entries {
+		if l.policy.Expired(fileEntry.ExpUnix, nowUnix) ||
+			(l.maxBytes > 0 && fileEntry.Size > l.maxBytes) {
+			continue
+		}
+
+		shard := l.getShard(fileEntry.Key)
+		shard.mutex.Lock()
+		listElement := shard.set(&entry{
+			key:       fileEntry.Key,
+			addedUnix: fileEntry.AddedUnix,
+			expUnix:   fileEntry.ExpUnix,
+			response:  fileEntry.Response,
+			size:      fileEntry.Size,
+		})
+		shard.mutex.Unlock()
+
+		l.evictBytes(shard, listElement)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package lru
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWireMsgs(name string, ttl uint32) (request, response *dns.Msg) {
+	request = new(dns.Msg).SetQuestion(name, dns.TypeA)
+	response = new(dns.Msg).SetReply(request)
+	response.Answer = []dns.RR{&dns.A{
+		Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+		A:   net.IPv4(1, 2, 3, 4),
+	}}
+	return request, response
+}
+
+func Test_LRU_SaveLoad(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1000, 0)
+	timeNow := func() time.Time { return now }
+
+	lru := New(Settings{Shards: 1})
+	lru.timeNow = timeNow
+
+	requestA, responseA := newTestWireMsgs("a.", 100)
+	requestB, responseB := newTestWireMsgs("b.", 10)
+	requestC, responseC := newTestWireMsgs("c.", 100)
+	lru.Add(requestA, responseA)
+	lru.Add(requestB, responseB)
+	lru.Add(requestC, responseC)
+
+	buffer := bytes.NewBuffer(nil)
+	err := lru.Save(buffer)
+	require.NoError(t, err)
+
+	now = now.Add(50 * time.Second)
+	loaded := New(Settings{MaxEntries: 1, Shards: 1})
+	loaded.timeNow = timeNow
+	err = loaded.Load(buffer)
+	require.NoError(t, err)
+
+	// B is expired and only C, the most recently
+	// used, fits in the cache.
+	assert.Nil(t, loaded.Get(requestA))
+	assert.Nil(t, loaded.Get(requestB))
+	response := loaded.Get(requestC)
+	require.NotNil(t, response)
+	assert.Equal(t, uint32(50), response.Answer[0].Header().Ttl)
+}
+
+func Test_LRU_Load(t *testing.T) {
+	t.Parallel()
+
+	valid := bytes.NewBuffer(nil)
+	lru := New(Settings{})
+	request, response := newTestWireMsgs("a.", 100)
+	lru.Add(request, response)
+	require.NoError(t, lru.Save(valid))
+
+	testCases := map[string]struct {
+		content    []byte
+		errWrapped error
+	}{
+		"empty": {
+			errWrapped: ErrFileHeader,
+		},
+		"bad header": {
+			content:    []byte("something else entirely"),
+			errWrapped: ErrFileHeader,
+		},
+		"bad version": {
+			content:    append([]byte(fileHeader), 255),
+			errWrapped: ErrFileVersion,
+		},
+		"truncated": {
+			content:    valid.Bytes()[:valid.Len()-1],
+			errWrapped: ErrFileCorrupt,
+		},
+		"header only": {
+			content: append([]byte(fileHeader), fileVersion),
+		},
+		"valid": {
+			content: valid.Bytes(),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			lru := New(Settings{})
+			err := lru.Load(bytes.NewReader(testCase.content))
+
+			if testCase.errWrapped != nil {
+				assert.True(t, errors.Is(err, testCase.errWrapped))
+				assert.Zero(t, countEntries(lru))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
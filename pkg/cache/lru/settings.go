@@ -3,16 +3,42 @@ package lru
 import (
 	"strconv"
 	"strings"
+
+	"github.com/qdm12/dns/pkg/cache/internal/freshness"
 )
 
+// ExchangeFunc exchanges a request with the upstream server,
+// and is used to refresh cache entries in the background.
+type ExchangeFunc = freshness.ExchangeFunc
+
 type Settings struct {
 	MaxEntries int
+	// MaxBytes is the memory budget in bytes for all the cache
+	// entries, based on the size of their packed DNS response.
+	// Responses larger than it are not cached.
+	// It defaults to 0, meaning there is no memory budget.
+	MaxBytes int
+	// Shards is the number of parts the cache is split into, each
+	// with its own lock, to reduce lock contention. Each shard gets
+	// an equal part of MaxEntries, and the number of shards is reduced
+	// for each shard to hold at least 100 entries. It defaults to 16.
+	Shards int
+	// Settings are the TTL, stale answers and prefetch settings,
+	// shared with the other caches.
+	freshness.Settings
 }
 
 func (s *Settings) SetDefaults() {
 	if s.MaxEntries == 0 {
 		s.MaxEntries = 10e4
 	}
+
+	if s.Shards == 0 {
+		const defaultShards = 16
+		s.Shards = defaultShards
+	}
+
+	s.Settings.SetDefaults()
 }
 
 func (s *Settings) String() string {
@@ -25,5 +51,10 @@ func (s *Settings) String() string {
 
 func (s *Settings) Lines(indent, subSection string) (lines []string) {
 	lines = append(lines, subSection+"Max entries: "+strconv.Itoa(s.MaxEntries))
+	if s.MaxBytes > 0 {
+		lines = append(lines, subSection+"Max bytes: "+strconv.Itoa(s.MaxBytes))
+	}
+	lines = append(lines, subSection+"Shards: "+strconv.Itoa(s.Shards))
+	lines = append(lines, s.Settings.Lines(indent, subSection)...)
 	return lines
 }
@@ -0,0 +1,137 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qdm12/dns/pkg/cache/stats"
+)
+
+// shard is a part of the cache with its own lock and entries limit,
+// so concurrent operations on different shards do not contend.
+type shard struct {
+	// Configuration
+	maxEntries int
+
+	// Statistics
+	counters *stats.Counters
+	// totalBytes is the size of the entries of all the shards,
+	// shared by the shards and only accessed atomically.
+	totalBytes *int64
+
+	// State
+	kv         map[string]*list.Element
+	linkedList *list.List
+	bytes      int
+	mutex      sync.Mutex
+}
+
+func newShard(maxEntries int, counters *stats.Counters, totalBytes *int64) *shard {
+	return &shard{
+		maxEntries: maxEntries,
+		counters:   counters,
+		totalBytes: totalBytes,
+		kv:         make(map[string]*list.Element, maxEntries),
+		linkedList: list.New(),
+	}
+}
+
+// set inserts or replaces the entry and evicts the least recently
+// used entries if the shard maximum number of entries is exceeded.
+// It returns the list element of the entry.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) set(entryPtr *entry) (listElement *list.Element) {
+	if listElement, ok := s.kv[entryPtr.key]; ok {
+		s.remove(listElement)
+	}
+
+	listElement = s.linkedList.PushFront(entryPtr)
+	s.kv[entryPtr.key] = listElement
+	s.bytes += entryPtr.size
+	atomic.AddInt64(s.totalBytes, int64(entryPtr.size))
+
+	for s.maxEntries > 0 && s.linkedList.Len() > s.maxEntries {
+		s.removeOldest()
+		s.counters.Evict()
+	}
+	return listElement
+}
+
+// evictBytes evicts the least recently used entries of the shard,
+// except the list element to keep, while the size of the entries
+// of all the shards exceeds maxBytes.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) evictBytes(maxBytes int, keep *list.Element) {
+	for atomic.LoadInt64(s.totalBytes) > int64(maxBytes) {
+		listElement := s.linkedList.Back()
+		if listElement == nil || listElement == keep {
+			return
+		}
+		s.remove(listElement)
+		s.counters.Evict()
+	}
+}
+
+// removeExpired removes all the entries for which
+// the expired function returns true given their expiry time.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) removeExpired(expired func(expUnix int64) bool) {
+	listElement := s.linkedList.Front()
+	for listElement != nil {
+		next := listElement.Next()
+		if expired(listElement.Value.(*entry).expUnix) {
+			s.remove(listElement)
+			s.counters.Expire()
+		}
+		listElement = next
+	}
+}
+
+// removeIf removes all the entries with a key matching
+// the function given.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) removeIf(match func(key string) bool) {
+	listElement := s.linkedList.Front()
+	for listElement != nil {
+		next := listElement.Next()
+		if match(listElement.Value.(*entry).key) {
+			s.remove(listElement)
+		}
+		listElement = next
+	}
+}
+
+// flush removes all the entries.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) flush() {
+	s.kv = make(map[string]*list.Element, s.maxEntries)
+	s.linkedList.Init()
+	atomic.AddInt64(s.totalBytes, -int64(s.bytes))
+	s.bytes = 0
+}
+
+// remove removes a list element
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) remove(listElement *list.Element) {
+	s.linkedList.Remove(listElement)
+	entryPtr := listElement.Value.(*entry)
+	delete(s.kv, entryPtr.key)
+	s.bytes -= entryPtr.size
+	atomic.AddInt64(s.totalBytes, -int64(entryPtr.size))
+}
+
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (s *shard) removeOldest() {
+	listElement := s.linkedList.Back()
+	if listElement != nil {
+		s.remove(listElement)
+	}
+}
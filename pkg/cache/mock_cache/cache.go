@@ -5,10 +5,12 @@
 package mock_cache
 
 import (
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
 	dns "github.com/miekg/dns"
+	stats "github.com/qdm12/dns/pkg/cache/stats"
 )
 
 // MockCache is a mock of Cache interface.
@@ -46,6 +48,18 @@ func (mr *MockCacheMockRecorder) Add(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockCache)(nil).Add), arg0, arg1)
 }
 
+// Flush mocks base method.
+func (m *MockCache) Flush() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Flush")
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockCacheMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockCache)(nil).Flush))
+}
+
 // Get mocks base method.
 func (m *MockCache) Get(arg0 *dns.Msg) *dns.Msg {
 	m.ctrl.T.Helper()
@@ -59,3 +73,95 @@ func (mr *MockCacheMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCache)(nil).Get), arg0)
 }
+
+// GetStale mocks base method.
+func (m *MockCache) GetStale(arg0 *dns.Msg) *dns.Msg {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStale", arg0)
+	ret0, _ := ret[0].(*dns.Msg)
+	return ret0
+}
+
+// GetStale indicates an expected call of GetStale.
+func (mr *MockCacheMockRecorder) GetStale(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStale", reflect.TypeOf((*MockCache)(nil).GetStale), arg0)
+}
+
+// Load mocks base method.
+func (m *MockCache) Load(arg0 io.Reader) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockCacheMockRecorder) Load(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockCache)(nil).Load), arg0)
+}
+
+// Remove mocks base method.
+func (m *MockCache) Remove(arg0 string, arg1 uint16) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Remove", arg0, arg1)
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockCacheMockRecorder) Remove(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockCache)(nil).Remove), arg0, arg1)
+}
+
+// RemoveDomain mocks base method.
+func (m *MockCache) RemoveDomain(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RemoveDomain", arg0)
+}
+
+// RemoveDomain indicates an expected call of RemoveDomain.
+func (mr *MockCacheMockRecorder) RemoveDomain(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDomain", reflect.TypeOf((*MockCache)(nil).RemoveDomain), arg0)
+}
+
+// RemoveExpired mocks base method.
+func (m *MockCache) RemoveExpired() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RemoveExpired")
+}
+
+// RemoveExpired indicates an expected call of RemoveExpired.
+func (mr *MockCacheMockRecorder) RemoveExpired() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpired", reflect.TypeOf((*MockCache)(nil).RemoveExpired))
+}
+
+// Save mocks base method.
+func (m *MockCache) Save(arg0 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockCacheMockRecorder) Save(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockCache)(nil).Save), arg0)
+}
+
+// Stats mocks base method.
+func (m *MockCache) Stats() stats.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(stats.Stats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockCacheMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockCache)(nil).Stats))
+}
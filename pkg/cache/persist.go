@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// LoadFile loads the cache entries from the file at the path given.
+// It returns no error if the file does not exist.
+func LoadFile(cache Cache, path string) (err error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = cache.Load(file)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// SaveFile saves the cache entries to the file at the path given.
+// The file is written to a temporary file first, and then renamed,
+// so an existing file is never left partially written.
+func SaveFile(cache Cache, path string) (err error) {
+	const perms = 0600
+	tempPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	file, err := os.OpenFile(tempPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perms)
+	if err != nil {
+		return err
+	}
+
+	err = cache.Save(file)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	return os.Rename(tempPath, path)
+}
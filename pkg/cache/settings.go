@@ -2,13 +2,26 @@ package cache
 
 import (
 	"strings"
+	"time"
 
+	"github.com/qdm12/dns/pkg/cache/lfu"
 	"github.com/qdm12/dns/pkg/cache/lru"
 )
 
 type Settings struct {
 	Type Type
 	LRU  lru.Settings
+	LFU  lfu.Settings
+	// SweepPeriod is the period to remove expired entries from
+	// the cache. It defaults to 1 minute.
+	SweepPeriod time.Duration
+	// PersistPath is the file path to save the cache entries to,
+	// and to load them from at start. It defaults to the empty
+	// string, meaning the cache is not persisted.
+	PersistPath string
+	// PersistPeriod is the period to save the cache entries
+	// to the file. It defaults to 10 minutes.
+	PersistPeriod time.Duration
 }
 
 func (s *Settings) SetDefaults() {
@@ -20,6 +33,18 @@ func (s *Settings) SetDefaults() {
 	case Disabled:
 	case LRU:
 		s.LRU.SetDefaults()
+	case LFU:
+		s.LFU.SetDefaults()
+	}
+
+	if s.SweepPeriod == 0 {
+		const defaultSweepPeriod = time.Minute
+		s.SweepPeriod = defaultSweepPeriod
+	}
+
+	if s.PersistPeriod == 0 {
+		const defaultPersistPeriod = 10 * time.Minute
+		s.PersistPeriod = defaultPersistPeriod
 	}
 }
 
@@ -38,10 +63,23 @@ func (s *Settings) Lines(indent, subSection string) (lines []string) {
 	case LRU:
 		lruLines := s.LRU.Lines(indent, subSection)
 		lines = append(lines, lruLines...)
+	case LFU:
+		lfuLines := s.LFU.Lines(indent, subSection)
+		lines = append(lines, lfuLines...)
 	case Disabled:
+		return lines
 	default:
 		lines = append(lines, subSection+"MISSING CODE PATH, PLEASE ADD ME!!")
 	}
 
+	lines = append(lines, subSection+"Sweep period: "+s.SweepPeriod.String())
+
+	if s.PersistPath == "" {
+		lines = append(lines, subSection+"Persistence: disabled")
+	} else {
+		lines = append(lines, subSection+"Persistence file: "+s.PersistPath)
+		lines = append(lines, subSection+"Persistence period: "+s.PersistPeriod.String())
+	}
+
 	return lines
 }
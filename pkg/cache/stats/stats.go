@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Stats are statistics of a cache.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64 // entries removed to make room for new entries
+	Expired   uint64 // entries removed because they expired
+	Entries   int
+	Bytes     int
+}
+
+func (s Stats) String() string {
+	return strings.Join([]string{
+		"hits: " + strconv.FormatUint(s.Hits, 10),
+		"misses: " + strconv.FormatUint(s.Misses, 10),
+		"evictions: " + strconv.FormatUint(s.Evictions, 10),
+		"expired: " + strconv.FormatUint(s.Expired, 10),
+		"entries: " + strconv.Itoa(s.Entries),
+		"bytes: " + strconv.Itoa(s.Bytes),
+	}, ", ")
+}
+
+// Counters are thread safe counters for the cache statistics.
+// It should be created with new to have its fields 64 bit aligned.
+type Counters struct {
+	hits      uint64
+	misses    uint64
+	evictions uint64
+	expired   uint64
+}
+
+func (c *Counters) Hit()    { atomic.AddUint64(&c.hits, 1) }
+func (c *Counters) Miss()   { atomic.AddUint64(&c.misses, 1) }
+func (c *Counters) Evict()  { atomic.AddUint64(&c.evictions, 1) }
+func (c *Counters) Expire() { atomic.AddUint64(&c.expired, 1) }
+
+// Snapshot returns the statistics with the
+// current number of entries and bytes given.
+func (c *Counters) Snapshot(entries, bytes int) Stats {
+	return Stats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Expired:   atomic.LoadUint64(&c.expired),
+		Entries:   entries,
+		Bytes:     bytes,
+	}
+}
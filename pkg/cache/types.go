@@ -10,12 +10,14 @@ type Type string
 
 const (
 	LRU      Type = "lru"
+	LFU      Type = "lfu"
 	Disabled Type = "disabled"
 )
 
 func ListTypes() (types []Type) {
 	return []Type{
 		LRU,
+		LFU,
 		Disabled,
 	}
 }
@@ -0,0 +1,115 @@
+package coalesce
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache/key"
+)
+
+var ErrExchangePanicked = errors.New("exchange panicked")
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Coalescer
+
+// Coalescer deduplicates identical requests being exchanged at
+// the same time, so they share a single upstream exchange.
+type Coalescer interface {
+	Exchange(request *dns.Msg, exchange ExchangeFunc) (
+		response *dns.Msg, upstream string, err error)
+}
+
+// ExchangeFunc exchanges a request with an upstream server,
+// and returns the address of the upstream server used.
+type ExchangeFunc func(request *dns.Msg) (response *dns.Msg, upstream string, err error)
+
+type coalescer struct {
+	calls map[string]*call
+	mutex sync.Mutex
+}
+
+type call struct {
+	done     chan struct{}
+	waiters  int // number of duplicate requests waiting
+	response *dns.Msg
+	upstream string
+	err      error
+}
+
+func New() Coalescer {
+	return &coalescer{
+		calls: make(map[string]*call),
+	}
+}
+
+// Exchange runs the exchange function for the request, unless an
+// identical request is already being exchanged, in which case it
+// waits for and returns the result of this other exchange.
+// Requests are identical if their cache keys, including their
+// EDNS0 client subnet, are equal. Each caller gets its own copy
+// of the response, with the ID of its request.
+func (c *coalescer) Exchange(request *dns.Msg, exchange ExchangeFunc) (
+	response *dns.Msg, upstream string, err error) {
+	requestKey, ok := key.Make(request, true)
+	if !ok {
+		return exchange(request)
+	}
+
+	c.mutex.Lock()
+	inFlight, ok := c.calls[requestKey]
+	if ok {
+		inFlight.waiters++
+	} else {
+		inFlight = &call{done: make(chan struct{})}
+		c.calls[requestKey] = inFlight
+	}
+	c.mutex.Unlock()
+
+	if ok {
+		<-inFlight.done
+		return inFlight.result(request.Id)
+	}
+
+	waiters := c.run(requestKey, request, inFlight, exchange)
+	if waiters > 0 {
+		return inFlight.result(request.Id)
+	}
+
+	// No other caller shares the response, so it is not copied.
+	if inFlight.err != nil {
+		return nil, inFlight.upstream, inFlight.err
+	}
+	inFlight.response.Id = request.Id
+	return inFlight.response, inFlight.upstream, nil
+}
+
+// run runs the exchange of the call and returns the number of
+// duplicate requests waiting for its result. The call is removed
+// and its waiters are released even if the exchange panics.
+func (c *coalescer) run(requestKey string, request *dns.Msg,
+	inFlight *call, exchange ExchangeFunc) (waiters int) {
+	defer func() {
+		c.mutex.Lock()
+		delete(c.calls, requestKey)
+		waiters = inFlight.waiters
+		c.mutex.Unlock()
+		close(inFlight.done)
+	}()
+
+	// The error is only left for the waiters if the exchange panics.
+	inFlight.err = ErrExchangePanicked
+	inFlight.response, inFlight.upstream, inFlight.err = exchange(request)
+	return waiters
+}
+
+// result returns a copy of the response of the call
+// with the request ID given.
+func (c *call) result(id uint16) (response *dns.Msg, upstream string, err error) {
+	if c.err != nil {
+		return nil, c.upstream, c.err
+	}
+
+	response = c.response.Copy()
+	response.Id = id
+	return response, c.upstream, nil
+}
@@ -0,0 +1,150 @@
+package coalesce
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_coalescer_Exchange(t *testing.T) {
+	t.Parallel()
+
+	errTest := errors.New("test error")
+
+	testCases := map[string]struct {
+		err error
+	}{
+		"success": {},
+		"error":   {err: errTest},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := New().(*coalescer)
+
+			const waiters = 10
+			release := make(chan struct{})
+			var exchanges int
+			const upstream = "1.1.1.1:853"
+			exchange := func(request *dns.Msg) (*dns.Msg, string, error) {
+				exchanges++ // only one exchange should run at a time
+				<-release
+				if testCase.err != nil {
+					return nil, upstream, testCase.err
+				}
+				return new(dns.Msg).SetReply(request), upstream, nil
+			}
+
+			first := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+			first.Id = 0
+			firstDone := make(chan struct{})
+			go func() {
+				defer close(firstDone)
+				_, _, _ = c.Exchange(first, exchange)
+			}()
+
+			// Wait for the first exchange to be in flight
+			require.Eventually(t, func() bool {
+				c.mutex.Lock()
+				defer c.mutex.Unlock()
+				return len(c.calls) == 1
+			}, time.Second, time.Millisecond)
+
+			responses := make([]*dns.Msg, waiters)
+			upstreams := make([]string, waiters)
+			errs := make([]error, waiters)
+			wg := sync.WaitGroup{}
+			for i := 0; i < waiters; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					request := new(dns.Msg).SetQuestion("EXAMPLE.com.", dns.TypeA)
+					request.Id = uint16(i + 1)
+					responses[i], upstreams[i], errs[i] = c.Exchange(request, exchange)
+				}(i)
+			}
+
+			// Wait for the waiters to block on the in flight call
+			require.Eventually(t, func() bool {
+				c.mutex.Lock()
+				defer c.mutex.Unlock()
+				for _, inFlight := range c.calls {
+					return inFlight.waiters == waiters
+				}
+				return false
+			}, time.Second, time.Millisecond)
+			close(release)
+			wg.Wait()
+			<-firstDone
+
+			assert.Equal(t, 1, exchanges)
+			for i := 0; i < waiters; i++ {
+				assert.Equal(t, upstream, upstreams[i])
+				if testCase.err != nil {
+					assert.True(t, errors.Is(errs[i], testCase.err))
+					assert.Nil(t, responses[i])
+					continue
+				}
+				require.NoError(t, errs[i])
+				assert.Equal(t, uint16(i+1), responses[i].Id)
+			}
+		})
+	}
+}
+
+func Test_coalescer_Exchange_panic(t *testing.T) {
+	t.Parallel()
+
+	c := New().(*coalescer)
+
+	release := make(chan struct{})
+	exchange := func(request *dns.Msg) (*dns.Msg, string, error) {
+		<-release
+		panic("test panic")
+	}
+
+	request := new(dns.Msg).SetQuestion("example.com.", dns.TypeA)
+	panicked := make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		_, _, _ = c.Exchange(request, exchange)
+	}()
+
+	// Wait for the first exchange to be in flight
+	require.Eventually(t, func() bool {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return len(c.calls) == 1
+	}, time.Second, time.Millisecond)
+
+	var waiterErr error
+	waiterDone := make(chan struct{})
+	go func() {
+		defer close(waiterDone)
+		_, _, waiterErr = c.Exchange(request, exchange)
+	}()
+
+	// Wait for the waiter to block on the in flight call
+	require.Eventually(t, func() bool {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for _, inFlight := range c.calls {
+			return inFlight.waiters == 1
+		}
+		return false
+	}, time.Second, time.Millisecond)
+	close(release)
+
+	assert.Equal(t, "test panic", <-panicked)
+	<-waiterDone
+	assert.True(t, errors.Is(waiterErr, ErrExchangePanicked))
+	assert.Empty(t, c.calls)
+}
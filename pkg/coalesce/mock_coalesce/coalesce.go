@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/coalesce (interfaces: Coalescer)
+
+// Package mock_coalesce is a generated GoMock package.
+package mock_coalesce
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dns "github.com/miekg/dns"
+	coalesce "github.com/qdm12/dns/pkg/coalesce"
+)
+
+// MockCoalescer is a mock of Coalescer interface.
+type MockCoalescer struct {
+	ctrl     *gomock.Controller
+	recorder *MockCoalescerMockRecorder
+}
+
+// MockCoalescerMockRecorder is the mock recorder for MockCoalescer.
+type MockCoalescerMockRecorder struct {
+	mock *MockCoalescer
+}
+
+// NewMockCoalescer creates a new mock instance.
+func NewMockCoalescer(ctrl *gomock.Controller) *MockCoalescer {
+	mock := &MockCoalescer{ctrl: ctrl}
+	mock.recorder = &MockCoalescerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCoalescer) EXPECT() *MockCoalescerMockRecorder {
+	return m.recorder
+}
+
+// Exchange mocks base method.
+func (m *MockCoalescer) Exchange(arg0 *dns.Msg, arg1 coalesce.ExchangeFunc) (*dns.Msg, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exchange", arg0, arg1)
+	ret0, _ := ret[0].(*dns.Msg)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Exchange indicates an expected call of Exchange.
+func (mr *MockCoalescerMockRecorder) Exchange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exchange", reflect.TypeOf((*MockCoalescer)(nil).Exchange), arg0, arg1)
+}
@@ -0,0 +1,233 @@
+// Package dnstap sends dnstap messages describing the queries
+// and responses of a DNS server, over Frame Streams to a unix
+// socket or to a file.
+package dnstap
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	dnstappb "github.com/dnstap/golang-dnstap"
+	"github.com/miekg/dns"
+	"google.golang.org/protobuf/proto"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Tapper
+
+type Tapper interface {
+	ClientQuery(client net.Addr, query *dns.Msg, queryTime time.Time)
+	ClientResponse(client net.Addr, response *dns.Msg, queryTime, responseTime time.Time)
+	ForwarderQuery(upstream net.Addr, query *dns.Msg, queryTime time.Time)
+	ForwarderResponse(upstream net.Addr, response *dns.Msg, queryTime, responseTime time.Time)
+	Close()
+}
+
+// Protocol is the protocol used to exchange with upstream servers.
+type Protocol uint8
+
+const (
+	DoT Protocol = iota
+	DoH
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case DoT:
+		return "DoT"
+	case DoH:
+		return "DoH"
+	default:
+		return "unknown"
+	}
+}
+
+const version = "qdm12/dns"
+
+type tapper struct {
+	outputs  []dnstappb.Output
+	identity []byte
+	protocol dnstappb.SocketProtocol
+}
+
+// New creates a tapper sending messages to the socket and the file set
+// in the settings. The upstream protocol is the protocol used by the
+// server to exchange with its upstream servers. Messages are dropped
+// if an output cannot keep up, so the DNS server is never blocked.
+// It returns a nil Tapper if neither a socket nor a file is set.
+func New(settings Settings, upstreamProtocol Protocol) (t Tapper, err error) {
+	if !settings.Enabled() {
+		return nil, nil //nolint:nilnil
+	}
+
+	dnsTapper := &tapper{
+		protocol: dnstappb.SocketProtocol_DOT,
+	}
+	if upstreamProtocol == DoH {
+		dnsTapper.protocol = dnstappb.SocketProtocol_DOH
+	}
+	if settings.Identity != "" {
+		dnsTapper.identity = []byte(settings.Identity)
+	}
+
+	if settings.Socket != "" {
+		address := &net.UnixAddr{Name: settings.Socket, Net: "unix"}
+		output, err := dnstappb.NewFrameStreamSockOutput(address)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create dnstap socket output: %w", err)
+		}
+		dnsTapper.outputs = append(dnsTapper.outputs, output)
+	}
+
+	if settings.File != "" {
+		output, err := dnstappb.NewFrameStreamOutputFromFilename(settings.File)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create dnstap file output: %w", err)
+		}
+		dnsTapper.outputs = append(dnsTapper.outputs, output)
+	}
+
+	for _, output := range dnsTapper.outputs {
+		go output.RunOutputLoop()
+	}
+
+	return dnsTapper, nil
+}
+
+// Close flushes the pending messages and closes the outputs.
+func (t *tapper) Close() {
+	for _, output := range t.outputs {
+		output.Close()
+	}
+}
+
+func (t *tapper) ClientQuery(client net.Addr, query *dns.Msg, queryTime time.Time) {
+	message := newMessage(dnstappb.Message_CLIENT_QUERY, client, socketProtocol(client))
+	message.QueryMessage, _ = query.Pack()
+	message.QueryTimeSec, message.QueryTimeNsec = timeFields(queryTime)
+	t.send(message)
+}
+
+func (t *tapper) ClientResponse(client net.Addr, response *dns.Msg,
+	queryTime, responseTime time.Time) {
+	message := newMessage(dnstappb.Message_CLIENT_RESPONSE, client, socketProtocol(client))
+	message.ResponseMessage, _ = response.Pack()
+	message.QueryTimeSec, message.QueryTimeNsec = timeFields(queryTime)
+	message.ResponseTimeSec, message.ResponseTimeNsec = timeFields(responseTime)
+	t.send(message)
+}
+
+func (t *tapper) ForwarderQuery(upstream net.Addr, query *dns.Msg, queryTime time.Time) {
+	message := newMessage(dnstappb.Message_FORWARDER_QUERY, nil, t.upstreamProtocol(upstream))
+	setResponseAddress(message, upstream)
+	message.QueryMessage, _ = query.Pack()
+	message.QueryTimeSec, message.QueryTimeNsec = timeFields(queryTime)
+	t.send(message)
+}
+
+func (t *tapper) ForwarderResponse(upstream net.Addr, response *dns.Msg,
+	queryTime, responseTime time.Time) {
+	message := newMessage(dnstappb.Message_FORWARDER_RESPONSE, nil, t.upstreamProtocol(upstream))
+	setResponseAddress(message, upstream)
+	message.ResponseMessage, _ = response.Pack()
+	message.QueryTimeSec, message.QueryTimeNsec = timeFields(queryTime)
+	message.ResponseTimeSec, message.ResponseTimeNsec = timeFields(responseTime)
+	t.send(message)
+}
+
+func (t *tapper) send(message *dnstappb.Message) {
+	dnstapType := dnstappb.Dnstap_MESSAGE
+	frame, err := proto.Marshal(&dnstappb.Dnstap{
+		Identity: t.identity,
+		Version:  []byte(version),
+		Type:     &dnstapType,
+		Message:  message,
+	})
+	if err != nil { // should not happen
+		return
+	}
+
+	for _, output := range t.outputs {
+		select {
+		case output.GetOutputChannel() <- frame:
+		default: // drop the message rather than blocking the DNS server
+		}
+	}
+}
+
+// upstreamProtocol returns UDP if the server fell back on plain
+// DNS to reach the upstream server, and the protocol of the server
+// otherwise.
+func (t *tapper) upstreamProtocol(upstream net.Addr) dnstappb.SocketProtocol {
+	if _, ok := upstream.(*net.UDPAddr); ok {
+		return dnstappb.SocketProtocol_UDP
+	}
+	return t.protocol
+}
+
+func newMessage(messageType dnstappb.Message_Type, client net.Addr,
+	protocol dnstappb.SocketProtocol) (message *dnstappb.Message) {
+	message = &dnstappb.Message{
+		Type:           &messageType,
+		SocketProtocol: &protocol,
+	}
+
+	ip, port := addrIPPort(client)
+	if ip != nil {
+		setFamily(message, ip)
+		message.QueryAddress = ip
+		message.QueryPort = &port
+	}
+
+	return message
+}
+
+func setResponseAddress(message *dnstappb.Message, upstream net.Addr) {
+	ip, port := addrIPPort(upstream)
+	if ip == nil {
+		return
+	}
+	setFamily(message, ip)
+	message.ResponseAddress = ip
+	message.ResponsePort = &port
+}
+
+func setFamily(message *dnstappb.Message, ip net.IP) {
+	family := dnstappb.SocketFamily_INET6
+	if ipv4 := ip.To4(); ipv4 != nil {
+		family = dnstappb.SocketFamily_INET
+	}
+	message.SocketFamily = &family
+}
+
+// addrIPPort returns the IP address and port of the address,
+// or a nil IP address if the address is not an UDP or TCP address,
+// for example for a DoH server URL.
+func addrIPPort(addr net.Addr) (ip net.IP, port uint32) {
+	switch typedAddr := addr.(type) {
+	case *net.UDPAddr:
+		ip, port = typedAddr.IP, uint32(typedAddr.Port)
+	case *net.TCPAddr:
+		ip, port = typedAddr.IP, uint32(typedAddr.Port)
+	default:
+		return nil, 0
+	}
+
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip = ipv4
+	}
+	return ip, port
+}
+
+func socketProtocol(client net.Addr) dnstappb.SocketProtocol {
+	if _, ok := client.(*net.TCPAddr); ok {
+		return dnstappb.SocketProtocol_TCP
+	}
+	return dnstappb.SocketProtocol_UDP
+}
+
+func timeFields(t time.Time) (seconds *uint64, nanoseconds *uint32) {
+	sec := uint64(t.Unix())
+	nsec := uint32(t.Nanosecond())
+	return &sec, &nsec
+}
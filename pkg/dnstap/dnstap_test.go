@@ -0,0 +1,94 @@
+package dnstap
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dnstappb "github.com/dnstap/golang-dnstap"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_tapper_File(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "dnstap.fstrm")
+	settings := Settings{File: path, Identity: "test"}
+
+	tapper, err := New(settings, DoT)
+	require.NoError(t, err)
+
+	client := &net.UDPAddr{IP: net.IP{192, 168, 1, 2}, Port: 5353}
+	upstream := &net.TCPAddr{IP: net.IP{1, 1, 1, 1}, Port: 853}
+	query := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	response := new(dns.Msg).SetReply(query)
+	queryTime := time.Unix(1626950000, 1000)
+	responseTime := queryTime.Add(time.Millisecond)
+
+	tapper.ClientQuery(client, query, queryTime)
+	tapper.ForwarderQuery(upstream, query, queryTime)
+	tapper.ForwarderResponse(upstream, response, queryTime, responseTime)
+	tapper.ClientResponse(client, response, queryTime, responseTime)
+	tapper.Close()
+
+	input, err := dnstappb.NewFrameStreamInputFromFilename(path)
+	require.NoError(t, err)
+
+	frames := make(chan []byte, 10)
+	go func() {
+		input.ReadInto(frames)
+		close(frames)
+	}()
+
+	var messages []*dnstappb.Message
+	for frame := range frames {
+		var decoded dnstappb.Dnstap
+		err := proto.Unmarshal(frame, &decoded)
+		require.NoError(t, err)
+		assert.Equal(t, "test", string(decoded.Identity))
+		assert.Equal(t, version, string(decoded.Version))
+		messages = append(messages, decoded.Message)
+	}
+
+	require.Len(t, messages, 4)
+
+	expectedTypes := []dnstappb.Message_Type{
+		dnstappb.Message_CLIENT_QUERY,
+		dnstappb.Message_FORWARDER_QUERY,
+		dnstappb.Message_FORWARDER_RESPONSE,
+		dnstappb.Message_CLIENT_RESPONSE,
+	}
+	for i, message := range messages {
+		assert.Equal(t, expectedTypes[i], message.GetType())
+		assert.Equal(t, uint64(1626950000), message.GetQueryTimeSec())
+		assert.Equal(t, uint32(1000), message.GetQueryTimeNsec())
+		assert.Equal(t, dnstappb.SocketFamily_INET, message.GetSocketFamily())
+	}
+
+	clientQuery := messages[0]
+	assert.Equal(t, dnstappb.SocketProtocol_UDP, clientQuery.GetSocketProtocol())
+	assert.Equal(t, []byte{192, 168, 1, 2}, clientQuery.QueryAddress)
+	assert.Equal(t, uint32(5353), clientQuery.GetQueryPort())
+	packedQuery, err := query.Pack()
+	require.NoError(t, err)
+	assert.Equal(t, packedQuery, clientQuery.QueryMessage)
+
+	forwarderResponse := messages[2]
+	assert.Equal(t, dnstappb.SocketProtocol_DOT, forwarderResponse.GetSocketProtocol())
+	assert.Equal(t, []byte{1, 1, 1, 1}, forwarderResponse.ResponseAddress)
+	assert.Equal(t, uint32(853), forwarderResponse.GetResponsePort())
+	assert.Equal(t, uint32(1000000+1000), forwarderResponse.GetResponseTimeNsec())
+}
+
+func Test_New_disabled(t *testing.T) {
+	t.Parallel()
+
+	tapper, err := New(Settings{}, DoH)
+
+	assert.NoError(t, err)
+	assert.Nil(t, tapper)
+}
@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/dnstap (interfaces: Tapper)
+
+// Package mock_dnstap is a generated GoMock package.
+package mock_dnstap
+
+import (
+	net "net"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	dns "github.com/miekg/dns"
+)
+
+// MockTapper is a mock of Tapper interface.
+type MockTapper struct {
+	ctrl     *gomock.Controller
+	recorder *MockTapperMockRecorder
+}
+
+// MockTapperMockRecorder is the mock recorder for MockTapper.
+type MockTapperMockRecorder struct {
+	mock *MockTapper
+}
+
+// NewMockTapper creates a new mock instance.
+func NewMockTapper(ctrl *gomock.Controller) *MockTapper {
+	mock := &MockTapper{ctrl: ctrl}
+	mock.recorder = &MockTapperMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTapper) EXPECT() *MockTapperMockRecorder {
+	return m.recorder
+}
+
+// ClientQuery mocks base method.
+func (m *MockTapper) ClientQuery(arg0 net.Addr, arg1 *dns.Msg, arg2 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClientQuery", arg0, arg1, arg2)
+}
+
+// ClientQuery indicates an expected call of ClientQuery.
+func (mr *MockTapperMockRecorder) ClientQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientQuery", reflect.TypeOf((*MockTapper)(nil).ClientQuery), arg0, arg1, arg2)
+}
+
+// ClientResponse mocks base method.
+func (m *MockTapper) ClientResponse(arg0 net.Addr, arg1 *dns.Msg, arg2, arg3 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClientResponse", arg0, arg1, arg2, arg3)
+}
+
+// ClientResponse indicates an expected call of ClientResponse.
+func (mr *MockTapperMockRecorder) ClientResponse(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClientResponse", reflect.TypeOf((*MockTapper)(nil).ClientResponse), arg0, arg1, arg2, arg3)
+}
+
+// Close mocks base method.
+func (m *MockTapper) Close() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Close")
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockTapperMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockTapper)(nil).Close))
+}
+
+// ForwarderQuery mocks base method.
+func (m *MockTapper) ForwarderQuery(arg0 net.Addr, arg1 *dns.Msg, arg2 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ForwarderQuery", arg0, arg1, arg2)
+}
+
+// ForwarderQuery indicates an expected call of ForwarderQuery.
+func (mr *MockTapperMockRecorder) ForwarderQuery(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForwarderQuery", reflect.TypeOf((*MockTapper)(nil).ForwarderQuery), arg0, arg1, arg2)
+}
+
+// ForwarderResponse mocks base method.
+func (m *MockTapper) ForwarderResponse(arg0 net.Addr, arg1 *dns.Msg, arg2, arg3 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ForwarderResponse", arg0, arg1, arg2, arg3)
+}
+
+// ForwarderResponse indicates an expected call of ForwarderResponse.
+func (mr *MockTapperMockRecorder) ForwarderResponse(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForwarderResponse", reflect.TypeOf((*MockTapper)(nil).ForwarderResponse), arg0, arg1, arg2, arg3)
+}
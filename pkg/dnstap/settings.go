@@ -0,0 +1,54 @@
+package dnstap
+
+import (
+	"strings"
+)
+
+type Settings struct {
+	// Socket is the path of a unix socket to send dnstap messages
+	// to, using the Frame Streams protocol. It defaults to the empty
+	// string, meaning no message is sent to a socket.
+	Socket string
+	// File is the path of a file to write dnstap messages to, using
+	// the Frame Streams protocol. It defaults to the empty string,
+	// meaning no message is written to a file.
+	File string
+	// Identity is the identity of the server sent in dnstap messages.
+	// It defaults to the empty string, meaning no identity is sent.
+	Identity string
+}
+
+// Enabled returns true if a socket or a file is set.
+func (s *Settings) Enabled() bool {
+	return s.Socket != "" || s.File != ""
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled() {
+		return []string{subSection + "Dnstap: disabled"}
+	}
+
+	lines = append(lines, subSection+"Dnstap:")
+
+	if s.Socket != "" {
+		lines = append(lines, indent+subSection+"Socket: "+s.Socket)
+	}
+
+	if s.File != "" {
+		lines = append(lines, indent+subSection+"File: "+s.File)
+	}
+
+	if s.Identity != "" {
+		lines = append(lines, indent+subSection+"Identity: "+s.Identity)
+	}
+
+	return lines
+}
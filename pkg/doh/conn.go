@@ -98,9 +98,17 @@ func (c *dohConn) LocalAddr() net.Addr {
 }
 
 func (c *dohConn) RemoteAddr() net.Addr {
-	return nil
+	return &urlAddr{url: c.dohURL}
+}
+
+// urlAddr is the address of a DoH server.
+type urlAddr struct {
+	url *url.URL
 }
 
+func (u *urlAddr) Network() string { return "https" }
+func (u *urlAddr) String() string  { return u.url.String() }
+
 func (c *dohConn) SetDeadline(t time.Time) error {
 	if err := c.SetReadDeadline(t); err != nil {
 		return err
@@ -7,12 +7,11 @@ import (
 	"sync"
 
 	"github.com/qdm12/dns/pkg/dot"
+	"github.com/qdm12/dns/pkg/internal/handler"
 	"github.com/qdm12/dns/pkg/provider"
 )
 
-type dialFunc func(ctx context.Context, _, _ string) (net.Conn, error)
-
-func newDoHDial(settings ResolverSettings) dialFunc {
+func newDoHDial(settings ResolverSettings) handler.DialFunc {
 	dohServers := make([]provider.DoHServer, len(settings.DoHProviders))
 	for i := range settings.DoHProviders {
 		dohServers[i] = settings.DoHProviders[i].DoH()
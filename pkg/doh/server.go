@@ -7,6 +7,11 @@ import (
 	"time"
 
 	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/internal/handler"
+	"github.com/qdm12/dns/pkg/local"
+	"github.com/qdm12/dns/pkg/querylog"
 	"github.com/qdm12/golibs/logging"
 )
 
@@ -14,29 +19,43 @@ import (
 
 type Server interface {
 	Run(ctx context.Context, stopped chan<- error)
+	Cache() cache.Cache
+	Ready() (ready bool)
+	QueryLog() querylog.Logger
+	Blacklist() blacklist.Pausable
+	Reload(settings ServerSettings) (err error)
 }
 
 type server struct {
 	dnsServer dns.Server
+	handler   *handler.Handler
 	logger    logging.Logger
 }
 
 func NewServer(ctx context.Context, logger logging.Logger,
-	settings ServerSettings) Server {
+	settings ServerSettings) (s Server, err error) {
 	if runtime.GOOS == "windows" {
 		logger.Warn("The Windows host cannot use the DoH server as its DNS")
 	}
 
 	settings.setDefaults()
 
+	dnsHandler, err := handler.New(ctx, logger, settings.handlerSettings())
+	if err != nil {
+		return nil, err
+	}
+
 	return &server{
 		dnsServer: dns.Server{
-			Addr:    ":" + strconv.Itoa(int(settings.Port)),
-			Net:     "udp",
-			Handler: newDNSHandler(ctx, logger, settings),
+			Addr:          ":" + strconv.Itoa(int(settings.Port)),
+			Net:           "udp",
+			Handler:       dnsHandler,
+			TsigSecret:    settings.Local.TSIGSecrets(),
+			MsgAcceptFunc: local.AcceptUpdates,
 		},
-		logger: logger,
-	}
+		handler: dnsHandler,
+		logger:  logger,
+	}, nil
 }
 
 func (s *server) Run(ctx context.Context, stopped chan<- error) {
@@ -51,6 +70,51 @@ func (s *server) Run(ctx context.Context, stopped chan<- error) {
 		}
 	}()
 
+	cacheCtx, cacheCancel := context.WithCancel(ctx)
+	cacheDone := make(chan struct{})
+	go func() {
+		defer close(cacheDone)
+		s.handler.MaintainCache(cacheCtx)
+	}()
+
 	s.logger.Info("DNS server listening on " + s.dnsServer.Addr)
-	stopped <- s.dnsServer.ListenAndServe()
+	err := s.dnsServer.ListenAndServe()
+	cacheCancel()
+	<-cacheDone
+	s.handler.Close()
+	stopped <- err
+}
+
+// Cache returns the cache of the server to get its statistics
+// or manage its entries. It returns nil if caching is disabled.
+func (s *server) Cache() cache.Cache {
+	return s.handler.Cache()
+}
+
+// Ready returns false while the cache is warming up, which
+// happens at start and after each cache flush, and true otherwise.
+// It can be used to report the server as healthy only once ready.
+func (s *server) Ready() (ready bool) {
+	return s.handler.Ready()
+}
+
+// QueryLog returns the query logger of the server to search
+// recent queries. It returns nil if the query log is disabled.
+func (s *server) QueryLog() querylog.Logger {
+	return s.handler.QueryLog()
+}
+
+// Blacklist returns the black lister of the server, to pause
+// blocking temporarily for all or some block list categories.
+func (s *server) Blacklist() blacklist.Pausable {
+	return s.handler.Blacklist()
+}
+
+// Reload replaces the block lists, the cache settings and the upstream
+// servers of the running server with the ones from the settings given.
+// The cache is replaced only if its settings changed, and the other
+// settings such as the listening port are not reloaded.
+func (s *server) Reload(settings ServerSettings) (err error) {
+	settings.setDefaults()
+	return s.handler.Reload(settings.handlerSettings())
 }
@@ -6,7 +6,10 @@ import (
 
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/metrics"
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/warmup"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -30,12 +33,23 @@ func Test_ServerSettings_setDefaults(t *testing.T) {
 				Timeout:      5 * time.Second,
 				IPv6:         false,
 			},
-			Timeout: 5 * time.Second,
+			Timeout:            5 * time.Second,
+			StaleAnswerTimeout: 1800 * time.Millisecond,
 		},
 		Port: 53,
 		Cache: cache.Settings{
-			Type: cache.Disabled,
+			Type:          cache.Disabled,
+			SweepPeriod:   time.Minute,
+			PersistPeriod: 10 * time.Minute,
 		},
+		WarmUp: warmup.Settings{
+			Concurrency: 10,
+		},
+		QueryLog: querylog.Settings{
+			FileMaxBytes:   10000000,
+			FileMaxBackups: 3,
+		},
+		Metrics: metrics.NewNoop(),
 	}
 	assert.Equal(t, expectedSettings, s)
 }
@@ -59,6 +73,7 @@ func Test_ServerSettings_Lines(t *testing.T) {
 		" |--Listening port: 53",
 		" |--Resolver:",
 		"     |--Query timeout: 5s",
+		"     |--Stale answer timeout: 1.8s",
 		"     |--DNS over HTTPS providers:",
 		"         |--Cloudflare",
 		"     |--Internal DNS:",
@@ -69,8 +84,22 @@ func Test_ServerSettings_Lines(t *testing.T) {
 		" |--Caching:",
 		"     |--Type: lru",
 		"     |--Max entries: 100000",
+		"     |--Shards: 16",
+		"     |--Min TTL: 0s",
+		"     |--Max TTL: 2h30m0s",
+		"     |--Max negative TTL: 1h0m0s",
+		"     |--Error TTL: errors are not cached",
+		"     |--Serve stale: disabled",
+		"     |--Prefetch: disabled",
+		"     |--Sweep period: 1m0s",
+		"     |--Persistence: disabled",
+		"     |--Cache warm-up: disabled",
 		" |--Blacklist:",
 		"     |--Hostnames blocked: 1",
+		" |--Local:",
+		"     |--Local zones: disabled",
+		" |--Query log: disabled",
+		" |--Dnstap: disabled",
 	}
 	assert.Equal(t, expectedLines, lines)
 }
@@ -0,0 +1,11 @@
+package doh
+
+// newUpstreamNames returns a map of upstream URLs
+// to the name of their provider, to label the metrics.
+func newUpstreamNames(settings ResolverSettings) (names map[string]string) {
+	names = make(map[string]string, len(settings.DoHProviders))
+	for _, provider := range settings.DoHProviders {
+		names[provider.DoH().URL.String()] = provider.String()
+	}
+	return names
+}
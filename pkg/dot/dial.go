@@ -6,12 +6,11 @@ import (
 	"net"
 	"strconv"
 
+	"github.com/qdm12/dns/pkg/internal/handler"
 	"github.com/qdm12/dns/pkg/provider"
 )
 
-type dialFunc func(ctx context.Context, _, _ string) (net.Conn, error)
-
-func newDoTDial(settings ResolverSettings) dialFunc {
+func newDoTDial(settings ResolverSettings) handler.DialFunc {
 	dotServers := make([]provider.DoTServer, len(settings.DoTProviders))
 	for i := range settings.DoTProviders {
 		dotServers[i] = settings.DoTProviders[i].DoT()
@@ -38,7 +38,8 @@ func Test_Server(t *testing.T) {
 	logger := mock_logging.NewMockLogger(ctrl)
 	logger.EXPECT().Info("DNS server listening on :53")
 
-	server := NewServer(ctx, logger, ServerSettings{})
+	server, err := NewServer(ctx, logger, ServerSettings{})
+	require.NoError(t, err)
 
 	go server.Run(ctx, stopped)
 
@@ -9,6 +9,10 @@ import (
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	blacklist "github.com/qdm12/dns/pkg/blacklist"
+	cache "github.com/qdm12/dns/pkg/cache"
+	dot "github.com/qdm12/dns/pkg/dot"
+	querylog "github.com/qdm12/dns/pkg/querylog"
 )
 
 // MockServer is a mock of Server interface.
@@ -34,6 +38,76 @@ func (m *MockServer) EXPECT() *MockServerMockRecorder {
 	return m.recorder
 }
 
+// Blacklist mocks base method.
+func (m *MockServer) Blacklist() blacklist.Pausable {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Blacklist")
+	ret0, _ := ret[0].(blacklist.Pausable)
+	return ret0
+}
+
+// Blacklist indicates an expected call of Blacklist.
+func (mr *MockServerMockRecorder) Blacklist() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Blacklist", reflect.TypeOf((*MockServer)(nil).Blacklist))
+}
+
+// Cache mocks base method.
+func (m *MockServer) Cache() cache.Cache {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Cache")
+	ret0, _ := ret[0].(cache.Cache)
+	return ret0
+}
+
+// Cache indicates an expected call of Cache.
+func (mr *MockServerMockRecorder) Cache() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cache", reflect.TypeOf((*MockServer)(nil).Cache))
+}
+
+// QueryLog mocks base method.
+func (m *MockServer) QueryLog() querylog.Logger {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryLog")
+	ret0, _ := ret[0].(querylog.Logger)
+	return ret0
+}
+
+// QueryLog indicates an expected call of QueryLog.
+func (mr *MockServerMockRecorder) QueryLog() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryLog", reflect.TypeOf((*MockServer)(nil).QueryLog))
+}
+
+// Ready mocks base method.
+func (m *MockServer) Ready() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ready")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Ready indicates an expected call of Ready.
+func (mr *MockServerMockRecorder) Ready() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ready", reflect.TypeOf((*MockServer)(nil).Ready))
+}
+
+// Reload mocks base method.
+func (m *MockServer) Reload(arg0 dot.ServerSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reload", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reload indicates an expected call of Reload.
+func (mr *MockServerMockRecorder) Reload(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reload", reflect.TypeOf((*MockServer)(nil).Reload), arg0)
+}
+
 // Run mocks base method.
 func (m *MockServer) Run(arg0 context.Context, arg1 chan<- error) {
 	m.ctrl.T.Helper()
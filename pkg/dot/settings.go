@@ -1,13 +1,20 @@
 package dot
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/qdm12/dns/pkg/blacklist"
 	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dnstap"
+	"github.com/qdm12/dns/pkg/internal/handler"
+	"github.com/qdm12/dns/pkg/local"
+	"github.com/qdm12/dns/pkg/metrics"
 	"github.com/qdm12/dns/pkg/provider"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/warmup"
 )
 
 type ServerSettings struct {
@@ -15,13 +22,32 @@ type ServerSettings struct {
 	Port      uint16
 	Cache     cache.Settings
 	Blacklist blacklist.Settings
+	// BlacklistCategories are block lists by category, keyed by
+	// the blacklist Category constants, so that blocking can be
+	// paused for some categories only. The Blacklist block lists
+	// are added to the custom category.
+	BlacklistCategories map[string]blacklist.Settings
+	// BlacklistMonitorOnly are the block list categories, or
+	// blacklist.AllCategories, for which matching queries are
+	// only logged and counted instead of being blocked.
+	BlacklistMonitorOnly []string
+	Local                local.Settings
+	WarmUp               warmup.Settings
+	QueryLog             querylog.Settings
+	Dnstap               dnstap.Settings
+	// Metrics records the server metrics. It defaults
+	// to a no-op implementation recording nothing.
+	Metrics metrics.Metrics
 }
 
 type ResolverSettings struct {
 	DoTProviders []provider.Provider
 	DNSProviders []provider.Provider
 	Timeout      time.Duration
-	IPv6         bool
+	// StaleAnswerTimeout is the duration to wait for the upstream
+	// before answering with a stale cached response, if any.
+	StaleAnswerTimeout time.Duration
+	IPv6               bool
 }
 
 func (s *ServerSettings) setDefaults() {
@@ -34,6 +60,14 @@ func (s *ServerSettings) setDefaults() {
 
 	// Cache defaults to disabled, see pkg/cache/settings.go
 	s.Cache.SetDefaults()
+
+	s.WarmUp.SetDefaults()
+
+	s.QueryLog.SetDefaults()
+
+	if s.Metrics == nil {
+		s.Metrics = metrics.NewNoop()
+	}
 }
 
 func (s *ResolverSettings) setDefaults() {
@@ -45,6 +79,12 @@ func (s *ResolverSettings) setDefaults() {
 		const defaultTimeout = 5 * time.Second
 		s.Timeout = defaultTimeout
 	}
+
+	if s.StaleAnswerTimeout == 0 {
+		// See RFC 8767 section 5
+		const defaultStaleAnswerTimeout = 1800 * time.Millisecond
+		s.StaleAnswerTimeout = defaultStaleAnswerTimeout
+	}
 }
 
 const (
@@ -73,11 +113,37 @@ func (s *ServerSettings) Lines(indent, subSection string) (lines []string) {
 	for _, line := range s.Cache.Lines(indent, subSection) {
 		lines = append(lines, indent+line)
 	}
+	for _, line := range s.WarmUp.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
 
 	lines = append(lines, subSection+"Blacklist:")
 	for _, line := range s.Blacklist.Lines(indent, subSection) {
 		lines = append(lines, indent+line)
 	}
+	categories := make([]string, 0, len(s.BlacklistCategories))
+	for category := range s.BlacklistCategories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	if len(s.BlacklistMonitorOnly) > 0 {
+		lines = append(lines, indent+subSection+"Monitor only: "+
+			strings.Join(s.BlacklistMonitorOnly, ", "))
+	}
+	for _, category := range categories {
+		lines = append(lines, indent+subSection+"Category "+category+":")
+		blacklistSettings := s.BlacklistCategories[category]
+		for _, line := range blacklistSettings.Lines(indent, subSection) {
+			lines = append(lines, indent+indent+line)
+		}
+	}
+	lines = append(lines, subSection+"Local:")
+	for _, line := range s.Local.Lines(indent, subSection) {
+		lines = append(lines, indent+line)
+	}
+
+	lines = append(lines, s.QueryLog.Lines(indent, subSection)...)
+	lines = append(lines, s.Dnstap.Lines(indent, subSection)...)
 
 	return lines
 }
@@ -95,6 +161,8 @@ func (s *ResolverSettings) Lines(indent, subSection string) (lines []string) {
 
 	lines = append(lines,
 		subSection+"Query timeout: "+s.Timeout.String())
+	lines = append(lines,
+		subSection+"Stale answer timeout: "+s.StaleAnswerTimeout.String())
 
 	connectOver := "IPv4"
 	if s.IPv6 {
@@ -104,3 +172,35 @@ func (s *ResolverSettings) Lines(indent, subSection string) (lines []string) {
 
 	return lines
 }
+
+// handlerSettings returns the settings of the DNS handler.
+func (s *ServerSettings) handlerSettings() handler.Settings {
+	return handler.Settings{
+		Protocol: dnstap.DoT,
+		Upstream: handler.UpstreamSettings{
+			Dial:               newDoTDial(s.Resolver),
+			StaleAnswerTimeout: s.Resolver.StaleAnswerTimeout,
+			Names:              newUpstreamNames(s.Resolver),
+		},
+		Cache:                s.Cache,
+		BlacklistCategories:  s.blacklistCategories(),
+		BlacklistMonitorOnly: s.BlacklistMonitorOnly,
+		Local:                s.Local,
+		WarmUp:               s.WarmUp,
+		QueryLog:             s.QueryLog,
+		Dnstap:               s.Dnstap,
+		Metrics:              s.Metrics,
+	}
+}
+
+// blacklistCategories returns the block lists by category,
+// with the Blacklist block lists added to the custom category.
+func (s *ServerSettings) blacklistCategories() (categories map[string]blacklist.Settings) {
+	categories = make(map[string]blacklist.Settings, len(s.BlacklistCategories)+1)
+	for category, settings := range s.BlacklistCategories {
+		categories[category] = settings
+	}
+	categories[blacklist.CategoryCustom] = blacklist.Merge(
+		categories[blacklist.CategoryCustom], s.Blacklist)
+	return categories
+}
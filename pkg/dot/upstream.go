@@ -0,0 +1,29 @@
+package dot
+
+import (
+	"net"
+	"strconv"
+)
+
+// newUpstreamNames returns a map of upstream addresses
+// to the name of their provider, to label the metrics.
+func newUpstreamNames(settings ResolverSettings) (names map[string]string) {
+	names = make(map[string]string)
+
+	for _, provider := range settings.DoTProviders {
+		server := provider.DoT()
+		port := strconv.Itoa(int(server.Port))
+		for _, ip := range append(server.IPv4, server.IPv6...) {
+			names[net.JoinHostPort(ip.String(), port)] = provider.String()
+		}
+	}
+
+	for _, provider := range settings.DNSProviders {
+		server := provider.DNS()
+		for _, ip := range append(server.IPv4, server.IPv6...) {
+			names[net.JoinHostPort(ip.String(), "53")] = provider.String()
+		}
+	}
+
+	return names
+}
@@ -0,0 +1,113 @@
+package hosts
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// Record is a local hostname with its IP addresses.
+type Record struct {
+	FqdnHostname string
+	IPs          []netaddr.IP
+}
+
+// Read reads all the hosts and leases files from the settings and
+// returns the records found, sorted by hostname. Hostnames without
+// a dot get the domain suffix appended if one is set. An error is
+// returned for each file which cannot be read, and the other files
+// are still read.
+func Read(settings Settings) (records []Record, errs []error) {
+	var entries []entry
+
+	for _, path := range settings.HostsFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fileEntries, err := parseHosts(file)
+		_ = file.Close()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("hosts file %s: %w", path, err))
+			continue
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	for _, path := range settings.LeasesFiles {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entries = append(entries, parseLeases(string(content))...)
+	}
+
+	return makeRecords(entries, settings.Domain), errs
+}
+
+func makeRecords(entries []entry, domain string) (records []Record) {
+	domain = strings.Trim(strings.ToLower(domain), ".")
+
+	hostnameToIPs := make(map[string]map[netaddr.IP]struct{})
+	for _, entry := range entries {
+		hostname := strings.TrimSuffix(entry.hostname, ".")
+		if domain != "" && !strings.Contains(hostname, ".") {
+			hostname += "." + domain
+		}
+		fqdnHostname := dns.Fqdn(hostname)
+
+		ips, ok := hostnameToIPs[fqdnHostname]
+		if !ok {
+			ips = make(map[netaddr.IP]struct{})
+			hostnameToIPs[fqdnHostname] = ips
+		}
+		ips[entry.ip] = struct{}{}
+	}
+
+	records = make([]Record, 0, len(hostnameToIPs))
+	for fqdnHostname, ipsSet := range hostnameToIPs {
+		ips := make([]netaddr.IP, 0, len(ipsSet))
+		for ip := range ipsSet {
+			ips = append(ips, ip)
+		}
+		sort.Slice(ips, func(i, j int) bool {
+			return ips[i].Compare(ips[j]) < 0
+		})
+		records = append(records, Record{
+			FqdnHostname: fqdnHostname,
+			IPs:          ips,
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].FqdnHostname < records[j].FqdnHostname
+	})
+
+	return records
+}
+
+// Equal returns true if both record slices are equal.
+func Equal(a, b []Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].FqdnHostname != b[i].FqdnHostname ||
+			len(a[i].IPs) != len(b[i].IPs) {
+			return false
+		}
+		for j := range a[i].IPs {
+			if a[i].IPs[j] != b[i].IPs[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package hosts
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"inet.af/netaddr"
+)
+
+// entry is a single hostname to IP address association
+// found in a hosts or leases file.
+type entry struct {
+	hostname string
+	ip       netaddr.IP
+}
+
+var regexHostname = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*\.?$`)
+
+// normalizeHostname lower cases the hostname and returns it,
+// or returns the empty string if the hostname is not valid.
+func normalizeHostname(hostname string) string {
+	hostname = strings.ToLower(hostname)
+	if !regexHostname.MatchString(hostname) {
+		return ""
+	}
+	return hostname
+}
+
+// parseHosts parses a hosts formatted content, where each line
+// is an IP address followed by one or more hostnames.
+func parseHosts(reader io.Reader) (entries []entry, err error) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		const minFields = 2
+		if len(fields) < minFields {
+			continue
+		}
+
+		ip, err := netaddr.ParseIP(fields[0])
+		if err != nil {
+			continue
+		}
+
+		for _, hostname := range fields[1:] {
+			hostname = normalizeHostname(hostname)
+			if hostname == "" || hostname == "localhost" {
+				continue
+			}
+			entries = append(entries, entry{hostname: hostname, ip: ip})
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// parseLeases parses the content of a dnsmasq or
+// ISC DHCP server leases file, detecting its format.
+func parseLeases(content string) (entries []entry) {
+	if strings.Contains(content, "lease ") && strings.Contains(content, "{") {
+		return parseISCLeases(content)
+	}
+	return parseDnsmasqLeases(content)
+}
+
+// parseDnsmasqLeases parses a dnsmasq leases file content, where
+// each line is in the format `expiry mac ip hostname client-id`.
+// IPv6 leases lines are in the format `expiry iaid ip hostname duid`.
+func parseDnsmasqLeases(content string) (entries []entry) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		const minFields = 4
+		if len(fields) < minFields || fields[0] == "duid" {
+			continue
+		}
+
+		ip, err := netaddr.ParseIP(fields[2])
+		if err != nil {
+			continue
+		}
+
+		hostname := normalizeHostname(fields[3]) // "*" if unknown
+		if hostname == "" {
+			continue
+		}
+
+		entries = append(entries, entry{hostname: hostname, ip: ip})
+	}
+	return entries
+}
+
+// parseISCLeases parses an ISC DHCP server leases file content,
+// made of `lease <ip> { ... }` blocks. Only leases with a client
+// hostname and, if specified, an active binding state are kept.
+// Later leases for the same IP address override earlier ones.
+func parseISCLeases(content string) (entries []entry) {
+	ipToHostname := make(map[netaddr.IP]string)
+	var ips []netaddr.IP // keep ordering
+
+	var (
+		inLease  bool
+		ip       netaddr.IP
+		hostname string
+		active   bool
+	)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "lease ") && strings.HasSuffix(line, "{"):
+			fields := strings.Fields(line)
+			parsedIP, err := netaddr.ParseIP(fields[1])
+			if err != nil {
+				continue
+			}
+			inLease, ip, hostname, active = true, parsedIP, "", true
+		case !inLease:
+		case line == "}":
+			inLease = false
+			if _, exists := ipToHostname[ip]; !exists {
+				ips = append(ips, ip)
+			}
+			if !active {
+				hostname = ""
+			}
+			ipToHostname[ip] = hostname
+		case strings.HasPrefix(line, "binding state "):
+			active = strings.TrimSuffix(strings.TrimPrefix(line, "binding state "), ";") == "active"
+		case strings.HasPrefix(line, "client-hostname "):
+			hostname = strings.TrimPrefix(line, "client-hostname ")
+			hostname = strings.TrimSuffix(hostname, ";")
+			hostname = normalizeHostname(strings.Trim(hostname, `"`))
+		}
+	}
+
+	for _, ip := range ips {
+		hostname := ipToHostname[ip]
+		if hostname == "" {
+			continue
+		}
+		entries = append(entries, entry{hostname: hostname, ip: ip})
+	}
+	return entries
+}
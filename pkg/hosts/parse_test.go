@@ -0,0 +1,118 @@
+package hosts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_parseHosts(t *testing.T) {
+	t.Parallel()
+
+	const content = `# comment
+127.0.0.1 localhost
+192.168.1.2  nas NAS.lan # storage
+fd00::2	printer
+not-an-ip host
+192.168.1.3
+`
+
+	entries, err := parseHosts(strings.NewReader(content))
+
+	require.NoError(t, err)
+	expected := []entry{
+		{hostname: "nas", ip: netaddr.IPv4(192, 168, 1, 2)},
+		{hostname: "nas.lan", ip: netaddr.IPv4(192, 168, 1, 2)},
+		{hostname: "printer", ip: netaddr.MustParseIP("fd00::2")},
+	}
+	assert.Equal(t, expected, entries)
+}
+
+func Test_parseLeases(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		content string
+		entries []entry
+	}{
+		"empty": {},
+		"dnsmasq": {
+			content: `1626950000 00:11:22:33:44:55 192.168.1.10 laptop 01:00:11:22:33:44:55
+1626950000 00:11:22:33:44:66 192.168.1.11 * *
+duid 00:01:00:01:28:5a:3c:b2:00:11:22:33:44:55
+1626950000 1234 fd00::10 Phone 00:01:00:01
+`,
+			entries: []entry{
+				{hostname: "laptop", ip: netaddr.IPv4(192, 168, 1, 10)},
+				{hostname: "phone", ip: netaddr.MustParseIP("fd00::10")},
+			},
+		},
+		"isc": {
+			content: `# The format of this file is documented in the dhcpd.leases(5) manual page.
+lease 192.168.1.10 {
+  starts 4 2021/07/22 10:00:00;
+  ends 4 2021/07/22 22:00:00;
+  binding state active;
+  hardware ethernet 00:11:22:33:44:55;
+  client-hostname "old-name";
+}
+lease 192.168.1.11 {
+  binding state free;
+  client-hostname "gone";
+}
+lease 192.168.1.12 {
+  binding state active;
+}
+lease 192.168.1.10 {
+  binding state active;
+  client-hostname "Laptop";
+}
+`,
+			entries: []entry{
+				{hostname: "laptop", ip: netaddr.IPv4(192, 168, 1, 10)},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			entries := parseLeases(testCase.content)
+			assert.Equal(t, testCase.entries, entries)
+		})
+	}
+}
+
+func Test_makeRecords(t *testing.T) {
+	t.Parallel()
+
+	entries := []entry{
+		{hostname: "nas", ip: netaddr.IPv4(192, 168, 1, 3)},
+		{hostname: "nas.lan", ip: netaddr.IPv4(192, 168, 1, 2)},
+		{hostname: "printer.home.arpa.", ip: netaddr.IPv4(192, 168, 1, 4)},
+		{hostname: "nas", ip: netaddr.IPv4(192, 168, 1, 3)},
+	}
+
+	records := makeRecords(entries, ".LAN")
+
+	expected := []Record{
+		{
+			FqdnHostname: "nas.lan.",
+			IPs: []netaddr.IP{
+				netaddr.IPv4(192, 168, 1, 2),
+				netaddr.IPv4(192, 168, 1, 3),
+			},
+		},
+		{
+			FqdnHostname: "printer.home.arpa.",
+			IPs:          []netaddr.IP{netaddr.IPv4(192, 168, 1, 4)},
+		},
+	}
+	assert.Equal(t, expected, records)
+	assert.True(t, Equal(expected, records))
+	assert.False(t, Equal(expected, records[:1]))
+}
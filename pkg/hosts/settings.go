@@ -0,0 +1,67 @@
+package hosts
+
+import (
+	"strings"
+	"time"
+)
+
+type Settings struct {
+	// HostsFiles are file paths to hosts formatted files,
+	// such as /etc/hosts.
+	HostsFiles []string
+	// LeasesFiles are file paths to DHCP leases files, either
+	// from dnsmasq or from the ISC DHCP server.
+	LeasesFiles []string
+	// Domain is the domain suffix to append to single label
+	// hostnames, such as "lan". It defaults to the empty string
+	// which leaves hostnames as they are.
+	Domain string
+	// UpdatePeriod is the period to read the files again.
+	// Set it to 0 to read the files only once.
+	UpdatePeriod time.Duration
+}
+
+// Enabled returns true if at least one file is set.
+func (s *Settings) Enabled() bool {
+	return len(s.HostsFiles) > 0 || len(s.LeasesFiles) > 0
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled() {
+		return []string{subSection + "Local names are disabled"}
+	}
+
+	if len(s.HostsFiles) > 0 {
+		lines = append(lines, subSection+"Hosts files:")
+		for _, path := range s.HostsFiles {
+			lines = append(lines, indent+subSection+path)
+		}
+	}
+
+	if len(s.LeasesFiles) > 0 {
+		lines = append(lines, subSection+"DHCP leases files:")
+		for _, path := range s.LeasesFiles {
+			lines = append(lines, indent+subSection+path)
+		}
+	}
+
+	if s.Domain != "" {
+		lines = append(lines, subSection+"Domain: "+s.Domain)
+	}
+
+	update := "disabled"
+	if s.UpdatePeriod > 0 {
+		update = "every " + s.UpdatePeriod.String()
+	}
+	lines = append(lines, subSection+"Update: "+update)
+
+	return lines
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/cache/stats"
+	"github.com/qdm12/dns/pkg/warmup"
+)
+
+// MaintainCache warms up the cache at start and after each flush,
+// removes expired entries from the cache and saves the cache to
+// its file periodically, and saves it a last time when the context
+// is canceled. It replaces the cache on reload, saving the current
+// cache to its file first, and maintains the new cache.
+func (h *Handler) MaintainCache(ctx context.Context) {
+	for {
+		if replaced := h.maintainCurrentCache(ctx); !replaced {
+			return
+		}
+	}
+}
+
+// maintainCurrentCache maintains the current cache until the context
+// is canceled, or until the cache is replaced in which case it returns
+// true. The current cache is saved to its file before being replaced,
+// so the new cache can load its entries from the same file.
+func (h *Handler) maintainCurrentCache(ctx context.Context) (replaced bool) {
+	h.mutex.RLock()
+	dnsCache, settings := h.cache, h.cacheSettings
+	h.mutex.RUnlock()
+
+	if dnsCache == nil {
+		select {
+		case newSettings := <-h.cacheReload:
+			h.setCache(newSettings)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	sweepTicker := time.NewTicker(settings.SweepPeriod)
+	defer sweepTicker.Stop()
+
+	var persistTickerCh <-chan time.Time
+	if settings.PersistPath != "" {
+		persistTicker := time.NewTicker(settings.PersistPeriod)
+		defer persistTicker.Stop()
+		persistTickerCh = persistTicker.C
+	}
+
+	for {
+		select {
+		case <-h.warmUpSignal:
+			h.warmUp(ctx)
+		case <-sweepTicker.C:
+			dnsCache.RemoveExpired()
+			h.logger.Debug("cache statistics: " + dnsCache.Stats().String())
+		case <-persistTickerCh:
+			h.saveCache(dnsCache, settings.PersistPath)
+		case newSettings := <-h.cacheReload:
+			if settings.PersistPath != "" {
+				h.saveCache(dnsCache, settings.PersistPath)
+			}
+			h.setCache(newSettings)
+			return true
+		case <-ctx.Done():
+			if settings.PersistPath != "" {
+				h.saveCache(dnsCache, settings.PersistPath)
+			}
+			return false
+		}
+	}
+}
+
+func (h *Handler) saveCache(dnsCache cache.Cache, path string) {
+	if err := cache.SaveFile(dnsCache, path); err != nil {
+		h.logger.Warn("cannot save cache to file: " + err.Error())
+	}
+}
+
+// signalWarmUp marks the handler as not ready and signals
+// the cache maintenance goroutine to warm up the cache.
+func (h *Handler) signalWarmUp() {
+	atomic.StoreInt32(&h.ready, 0)
+	select {
+	case h.warmUpSignal <- struct{}{}:
+	default: // warm up already pending
+	}
+}
+
+func (h *Handler) warmUp(ctx context.Context) {
+	h.logger.Info("warming up cache with " +
+		strconv.Itoa(len(h.warmUpQuestions)) + " questions")
+	errs := warmup.Run(ctx, h.warmUpQuestions, h.warmUpConcurrency, h.resolveIntoCache)
+	for _, err := range errs {
+		h.logger.Warn(err.Error())
+	}
+	atomic.StoreInt32(&h.ready, 1)
+}
+
+// resolveIntoCache exchanges the request with the upstream
+// server and adds the response to the cache, unless the
+// request or the response is blocked.
+func (h *Handler) resolveIntoCache(request *dns.Msg) (err error) {
+	if h.blist.FilterRequest(request) {
+		return nil
+	}
+
+	response, err := h.prefetch(request)
+	if err != nil {
+		return err
+	}
+
+	dnsCache := h.Cache()
+	if dnsCache == nil { // cache disabled on reload
+		return nil
+	}
+	dnsCache.Add(request, response)
+	return nil
+}
+
+// Ready returns false while the cache is warming up, which
+// happens at start and after each cache flush, and true otherwise.
+func (h *Handler) Ready() bool {
+	return atomic.LoadInt32(&h.ready) == 1
+}
+
+// Cache returns the current cache of the handler.
+// It returns nil if caching is disabled.
+func (h *Handler) Cache() cache.Cache {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.cache
+}
+
+// CacheStats returns the statistics of the current cache,
+// and false if caching is disabled.
+func (h *Handler) CacheStats() (stats stats.Stats, enabled bool) {
+	dnsCache := h.Cache()
+	if dnsCache == nil {
+		return stats, false
+	}
+	return dnsCache.Stats(), true
+}
+
+// setCache replaces the cache with a cache created from the settings
+// given, loading its entries from its persistence file if any, and
+// warming it up if cache warm-up questions are set.
+func (h *Handler) setCache(settings cache.Settings) {
+	cacheSettings := settings
+	settings.LRU.Exchange = h.prefetch
+	settings.LFU.Exchange = h.prefetch
+	dnsCache := cache.New(settings)
+
+	if dnsCache != nil && settings.PersistPath != "" {
+		err := cache.LoadFile(dnsCache, settings.PersistPath)
+		if err != nil {
+			h.logger.Warn("ignoring cache file: " + err.Error())
+		}
+	}
+
+	if dnsCache != nil && len(h.warmUpQuestions) > 0 {
+		dnsCache = cache.OnFlush(dnsCache, h.signalWarmUp)
+		h.signalWarmUp()
+	} else {
+		atomic.StoreInt32(&h.ready, 1)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.cache = dnsCache
+	h.cacheSettings = cacheSettings
+}
@@ -0,0 +1,462 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/coalesce"
+	"github.com/qdm12/dns/pkg/dnstap"
+	"github.com/qdm12/dns/pkg/local"
+	"github.com/qdm12/dns/pkg/metrics"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/warmup"
+	"github.com/qdm12/golibs/logging"
+)
+
+var ErrResponseBlocked = errors.New("response is blocked")
+
+// Handler answers DNS requests from the local zones, the cache or
+// the upstream servers, filtering them with the block lists. It is
+// shared by the DoT and DoH servers, which differ only by how they
+// dial their upstream servers.
+type Handler struct {
+	// External objects
+	ctx    context.Context
+	logger logging.Logger
+
+	// Internal objects
+	client    *dns.Client
+	blist     blacklist.Pausable
+	local     local.Local
+	coalescer coalesce.Coalescer
+	queryLog  querylog.Logger
+	tapper    dnstap.Tapper
+	metrics   metrics.Metrics
+
+	// Internal state
+	warmUpSignal chan struct{}
+	ready        int32 // 1 if the cache is warmed up, 0 otherwise
+	// cacheReload sends new cache settings to the cache
+	// maintenance goroutine, which replaces the cache.
+	cacheReload chan cache.Settings
+
+	// Configuration
+	protocol          string // for logs
+	metricsServer     string // server label value for the metrics
+	warmUpQuestions   []dns.Question
+	warmUpConcurrency int
+
+	// Objects and configuration replaced on reload,
+	// protected by the mutex.
+	mutex              sync.RWMutex
+	dial               DialFunc
+	cache              cache.Cache
+	cacheSettings      cache.Settings
+	staleAnswerTimeout time.Duration
+	// upstreamNames maps upstream addresses to provider names.
+	upstreamNames map[string]string
+}
+
+func New(ctx context.Context, logger logging.Logger,
+	settings Settings) (dnsHandler *Handler, err error) {
+	localZones, err := local.New(settings.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	warmUpQuestions, err := warmup.Questions(settings.WarmUp)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read cache warm-up domains: %w", err)
+	}
+
+	queryLog, err := querylog.New(settings.QueryLog)
+	if err != nil {
+		return nil, err
+	}
+
+	tapper, err := dnstap.New(settings.Dnstap, settings.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsHandler = &Handler{
+		ctx:       ctx,
+		logger:    logger,
+		client:    &dns.Client{},
+		blist:     blacklist.NewPausable(settings.BlacklistCategories),
+		local:     localZones,
+		coalescer: coalesce.New(),
+		queryLog:  queryLog,
+		tapper:    tapper,
+		metrics:   settings.Metrics,
+
+		warmUpSignal:      make(chan struct{}, 1),
+		cacheReload:       make(chan cache.Settings, 1),
+		protocol:          settings.Protocol.String(),
+		metricsServer:     strings.ToLower(settings.Protocol.String()),
+		warmUpQuestions:   warmUpQuestions,
+		warmUpConcurrency: settings.WarmUp.Concurrency,
+
+		dial:               settings.Upstream.Dial,
+		staleAnswerTimeout: settings.Upstream.StaleAnswerTimeout,
+		upstreamNames:      settings.Upstream.Names,
+	}
+
+	err = dnsHandler.blist.SetMonitorOnly(settings.BlacklistMonitorOnly, dnsHandler.monitorMatch)
+	if err != nil {
+		return nil, fmt.Errorf("cannot set monitor only block lists: %w", err)
+	}
+
+	dnsHandler.setCache(settings.Cache)
+	dnsHandler.metrics.CollectCache(dnsHandler.metricsServer, dnsHandler)
+
+	return dnsHandler, nil
+}
+
+// writeUpdateResponse writes the dynamic update response. A response
+// with a TSIG error is written without being signed, since the request
+// signature could not be verified, as described in RFC 2845 section 4.3.
+func writeUpdateResponse(w dns.ResponseWriter, response *dns.Msg) (err error) {
+	tsig := response.IsTsig()
+	if tsig == nil || tsig.Error == dns.RcodeSuccess {
+		return w.WriteMsg(response)
+	}
+
+	data, err := response.Pack()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (h *Handler) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	if r.Opcode == dns.OpcodeUpdate {
+		response, err := h.local.Update(r, w.TsigStatus())
+		if err != nil {
+			h.logger.Warn("dynamic update refused: " + err.Error())
+		}
+		if err := writeUpdateResponse(w, response); err != nil {
+			h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+		}
+		return
+	}
+
+	start := time.Now()
+	if h.tapper != nil {
+		h.tapper.ClientQuery(w.RemoteAddr(), r, start)
+	}
+
+	response, info := h.answer(r)
+	if err := w.WriteMsg(response); err != nil {
+		h.logger.Warn("cannot write DNS message back to client: " + err.Error())
+	}
+
+	qType := ""
+	if len(r.Question) > 0 {
+		qType = dns.TypeToString[r.Question[0].Qtype]
+	}
+	h.metrics.Query(h.metricsServer, qType, dns.RcodeToString[response.Rcode])
+
+	if h.tapper != nil {
+		h.tapper.ClientResponse(w.RemoteAddr(), response, start, time.Now())
+	}
+
+	if h.queryLog != nil {
+		entry := querylog.NewEntry(start, w.RemoteAddr(), r, response)
+		entry.Upstream = info.upstream
+		entry.Cached = info.cached
+		entry.Blocked = info.blocked
+		entry.BlockedCategory = info.match.Category
+		entry.BlockedRule = info.match.Rule
+		if err := h.queryLog.Log(entry); err != nil {
+			h.logger.Warn("cannot log query: " + err.Error())
+		}
+	}
+}
+
+// answerInfo describes how a request was answered.
+type answerInfo struct {
+	upstream string
+	cached   bool
+	blocked  bool
+	// match is the block list rule blocking the request, if blocked.
+	match blacklist.Match
+}
+
+// answer returns the response to the request, from the local
+// zones, the cache or the upstream server, in this order.
+// The block lists are applied to cached responses as well, since
+// these may have been cached while blocking was paused, or before
+// the block lists were updated.
+func (h *Handler) answer(r *dns.Msg) (response *dns.Msg, info answerInfo) {
+	if len(r.Question) != 1 {
+		// Requests with no or several questions are not
+		// cached nor supported by upstream servers.
+		return new(dns.Msg).SetRcode(r, dns.RcodeFormatError), answerInfo{}
+	}
+
+	if response := h.local.Answer(r); response != nil {
+		return response, answerInfo{upstream: "local"}
+	}
+
+	if match, blocked := h.blist.MatchRequest(r); blocked {
+		h.metrics.Blocked(h.metricsServer, match.Category)
+		return new(dns.Msg).SetRcode(r, dns.RcodeRefused), answerInfo{blocked: true, match: match}
+	}
+
+	dnsCache := h.Cache()
+	if dnsCache != nil {
+		if response := dnsCache.Get(r); response != nil {
+			h.metrics.CacheHit(h.metricsServer)
+			if match, blocked := h.blist.MatchResponse(response); blocked {
+				h.metrics.Blocked(h.metricsServer, match.Category)
+				info = answerInfo{cached: true, blocked: true, match: match}
+				return new(dns.Msg).SetRcode(r, dns.RcodeRefused), info
+			}
+			response.SetReply(r)
+			return response, answerInfo{cached: true}
+		}
+		h.metrics.CacheMiss(h.metricsServer)
+	}
+
+	response, upstream, stale, err := h.resolve(r)
+	if err != nil {
+		h.logger.Warn(err.Error())
+		return new(dns.Msg).SetRcode(r, dns.RcodeServerFailure), answerInfo{upstream: upstream}
+	}
+	info = answerInfo{upstream: upstream, cached: stale}
+
+	if match, blocked := h.blist.MatchResponse(response); blocked {
+		h.metrics.Blocked(h.metricsServer, match.Category)
+		info.blocked = true
+		info.match = match
+		return new(dns.Msg).SetRcode(r, dns.RcodeRefused), info
+	}
+
+	if !stale && dnsCache != nil {
+		dnsCache.Add(r, response)
+	}
+
+	response.SetReply(r)
+	return response, info
+}
+
+type exchangeResult struct {
+	response *dns.Msg
+	upstream string
+	err      error
+}
+
+// resolve exchanges the request with the upstream server. If a stale
+// response is cached for the request, it is returned if the exchange
+// fails or takes longer than the stale answer timeout. In the latter
+// case, the exchange carries on in the background to refresh the cache.
+// The upstream returned is empty if the stale response is returned.
+func (h *Handler) resolve(r *dns.Msg) (response *dns.Msg,
+	upstream string, stale bool, err error) {
+	h.mutex.RLock()
+	dnsCache, staleAnswerTimeout := h.cache, h.staleAnswerTimeout
+	h.mutex.RUnlock()
+
+	var staleResponse *dns.Msg
+	if dnsCache != nil {
+		staleResponse = dnsCache.GetStale(r)
+	}
+
+	if staleResponse == nil {
+		response, upstream, err = h.exchange(r)
+		return response, upstream, false, err
+	}
+
+	results := make(chan exchangeResult, 1)
+	go func() {
+		response, upstream, err := h.exchange(r)
+		results <- exchangeResult{response: response, upstream: upstream, err: err}
+	}()
+
+	timer := time.NewTimer(staleAnswerTimeout)
+	select {
+	case result := <-results:
+		if !timer.Stop() {
+			<-timer.C
+		}
+		if result.err != nil {
+			h.logger.Warn(result.err.Error() + ", serving stale answer")
+			return staleResponse, "", true, nil
+		}
+		return result.response, result.upstream, false, nil
+	case <-timer.C:
+		go h.refresh(dnsCache, r, results)
+		return staleResponse, "", true, nil
+	}
+}
+
+// refresh waits for the exchange result and adds
+// the response to the cache if the exchange succeeded.
+func (h *Handler) refresh(dnsCache cache.Cache, r *dns.Msg,
+	results <-chan exchangeResult) {
+	result := <-results
+	if result.err != nil {
+		h.logger.Warn("cannot refresh stale answer: " + result.err.Error())
+		return
+	}
+
+	if h.blist.FilterResponse(result.response) {
+		return
+	}
+
+	dnsCache.Add(r, result.response)
+}
+
+// exchange exchanges the request with the upstream server,
+// sharing the exchange with identical requests in flight.
+func (h *Handler) exchange(r *dns.Msg) (response *dns.Msg, upstream string, err error) {
+	return h.coalescer.Exchange(r, h.exchangeUpstream)
+}
+
+func (h *Handler) exchangeUpstream(r *dns.Msg) (response *dns.Msg, upstream string, err error) {
+	h.mutex.RLock()
+	dial := h.dial
+	h.mutex.RUnlock()
+
+	upstreamConn, err := dial(h.ctx, "", "")
+	if err != nil {
+		h.metrics.UpstreamError(h.metricsServer, "") // upstream unknown
+		return nil, "", fmt.Errorf("cannot dial: %w", err)
+	}
+	conn := &dns.Conn{Conn: upstreamConn}
+	upstreamAddr := conn.RemoteAddr()
+	upstream = upstreamAddr.String()
+
+	queryTime := time.Now()
+	if h.tapper != nil {
+		h.tapper.ForwarderQuery(upstreamAddr, r, queryTime)
+	}
+
+	response, _, err = h.client.ExchangeWithConn(r, conn)
+	responseTime := time.Now()
+
+	if err := conn.Close(); err != nil {
+		h.logger.Warn("cannot close the " + h.protocol + " connection: " + err.Error())
+	}
+
+	upstreamName := h.upstreamName(upstream)
+	if err != nil {
+		h.metrics.UpstreamError(h.metricsServer, upstreamName)
+		return nil, upstream, fmt.Errorf("cannot exchange over %s connection: %w", h.protocol, err)
+	}
+	h.metrics.UpstreamLatency(h.metricsServer, upstreamName, responseTime.Sub(queryTime))
+
+	if h.tapper != nil {
+		h.tapper.ForwarderResponse(upstreamAddr, response, queryTime, responseTime)
+	}
+
+	return response, upstream, nil
+}
+
+// prefetch exchanges the request with the upstream server
+// for the cache to refresh one of its entries.
+func (h *Handler) prefetch(request *dns.Msg) (response *dns.Msg, err error) {
+	response, _, err = h.exchange(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.blist.FilterResponse(response) {
+		return nil, ErrResponseBlocked
+	}
+
+	return response, nil
+}
+
+// QueryLog returns the query logger of the handler.
+// It returns nil if the query log is disabled.
+func (h *Handler) QueryLog() querylog.Logger {
+	return h.queryLog
+}
+
+// Blacklist returns the black lister of the handler.
+func (h *Handler) Blacklist() blacklist.Pausable {
+	return h.blist
+}
+
+// Close closes the query log and the dnstap outputs.
+func (h *Handler) Close() {
+	if h.queryLog != nil {
+		if err := h.queryLog.Close(); err != nil {
+			h.logger.Warn("cannot close query log: " + err.Error())
+		}
+	}
+
+	if h.tapper != nil {
+		h.tapper.Close()
+	}
+}
+
+// monitorMatch logs and counts a query matching a block list
+// in monitor only mode, which is answered normally.
+func (h *Handler) monitorMatch(match blacklist.Match) {
+	h.logger.Info("monitor only: " + match.Name + " would be blocked by " +
+		match.Category + " block list rule " + match.Rule)
+	h.metrics.MonitoredMatch(h.metricsServer, match.Category)
+}
+
+// Reload replaces the block lists, the upstream servers and the
+// cache if its settings changed, with the ones from the settings given.
+// The cache is replaced in the background by MaintainCache, once the
+// current cache is saved to its file. Other settings are not reloaded.
+func (h *Handler) Reload(settings Settings) (err error) {
+	err = h.blist.SetMonitorOnly(settings.BlacklistMonitorOnly, h.monitorMatch)
+	if err != nil {
+		return fmt.Errorf("cannot set monitor only block lists: %w", err)
+	}
+	h.blist.Update(settings.BlacklistCategories, nil)
+
+	h.mutex.Lock()
+	h.dial = settings.Upstream.Dial
+	h.staleAnswerTimeout = settings.Upstream.StaleAnswerTimeout
+	h.upstreamNames = settings.Upstream.Names
+	settings.Cache.LRU.Exchange = nil
+	settings.Cache.LFU.Exchange = nil
+	cacheChanged := !reflect.DeepEqual(settings.Cache, h.cacheSettings)
+	h.mutex.Unlock()
+
+	// The cache is kept if only the block lists changed, since
+	// cached responses are filtered before being answered.
+	if !cacheChanged {
+		return nil
+	}
+
+	for {
+		select {
+		case h.cacheReload <- settings.Cache:
+			return nil
+		default:
+		}
+		select {
+		case <-h.cacheReload: // replace the pending cache settings
+		default:
+		}
+	}
+}
+
+// upstreamName returns the provider name of the upstream
+// address given, or the address itself if it is unknown.
+func (h *Handler) upstreamName(upstream string) (name string) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	name, ok := h.upstreamNames[upstream]
+	if !ok {
+		return upstream
+	}
+	return name
+}
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dnstap"
+	"github.com/qdm12/dns/pkg/metrics"
+	"github.com/qdm12/golibs/logging/mock_logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+// newTestUpstream runs a plaintext DNS server answering 1.2.3.4
+// to all A requests, and returns a function dialing it.
+func newTestUpstream(t *testing.T) (dial DialFunc) {
+	t.Helper()
+
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn: packetConn,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			response := new(dns.Msg).SetReply(r)
+			response.Answer = []dns.RR{&dns.A{
+				Hdr: dns.RR_Header{
+					Name:   r.Question[0].Name,
+					Rrtype: dns.TypeA,
+					Class:  dns.ClassINET,
+					Ttl:    300,
+				},
+				A: net.IPv4(1, 2, 3, 4),
+			}}
+			_ = w.WriteMsg(response)
+		}),
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+
+	address := packetConn.LocalAddr().String()
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		dialer := &net.Dialer{}
+		return dialer.DialContext(ctx, "udp", address)
+	}
+}
+
+func newTestSettings(t *testing.T) (settings Settings) {
+	t.Helper()
+
+	settings = Settings{
+		Protocol: dnstap.DoT,
+		Upstream: UpstreamSettings{
+			Dial:               newTestUpstream(t),
+			StaleAnswerTimeout: time.Second,
+		},
+		Cache: cache.Settings{Type: cache.LRU},
+		BlacklistCategories: map[string]blacklist.Settings{
+			blacklist.CategoryCustom: {
+				FqdnHostnames: []string{"blocked.com."},
+			},
+		},
+		Metrics: metrics.NewNoop(),
+	}
+	settings.Cache.SetDefaults()
+	settings.WarmUp.SetDefaults()
+	settings.QueryLog.SetDefaults()
+	return settings
+}
+
+func Test_Handler_answer_questions(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		questions []dns.Question
+		rcode     int
+	}{
+		"no question": {
+			rcode: dns.RcodeFormatError,
+		},
+		"one question": {
+			questions: []dns.Question{
+				{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+			},
+			rcode: dns.RcodeSuccess,
+		},
+		"two questions": {
+			questions: []dns.Question{
+				{Name: "example.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+				{Name: "example.org.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+			},
+			rcode: dns.RcodeFormatError,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			logger := mock_logging.NewMockLogger(ctrl)
+			handler, err := New(context.Background(), logger, newTestSettings(t))
+			require.NoError(t, err)
+
+			request := new(dns.Msg)
+			request.Id = dns.Id()
+			request.RecursionDesired = true
+			request.Question = testCase.questions
+
+			response, _ := handler.answer(request)
+
+			assert.Equal(t, testCase.rcode, response.Rcode)
+			assert.Equal(t, request.Id, response.Id)
+		})
+	}
+}
+
+func Test_Handler_answer_pausedThenResumed(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	logger := mock_logging.NewMockLogger(ctrl)
+	handler, err := New(context.Background(), logger, newTestSettings(t))
+	require.NoError(t, err)
+
+	request := new(dns.Msg).SetQuestion("blocked.com.", dns.TypeA)
+
+	err = handler.Blacklist().Pause(time.Hour)
+	require.NoError(t, err)
+
+	response, info := handler.answer(request)
+	assert.Equal(t, dns.RcodeSuccess, response.Rcode)
+	assert.False(t, info.blocked)
+	require.NotNil(t, handler.Cache().Get(request))
+
+	err = handler.Blacklist().Pause(0)
+	require.NoError(t, err)
+
+	response, info = handler.answer(request)
+	assert.Equal(t, dns.RcodeRefused, response.Rcode)
+	assert.True(t, info.blocked)
+	expectedMatch := blacklist.Match{
+		Name:     "blocked.com.",
+		Category: blacklist.CategoryCustom,
+		Rule:     "blocked.com.",
+	}
+	assert.Equal(t, expectedMatch, info.match)
+}
+
+func Test_Handler_Reload_blockLists(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		blacklist blacklist.Settings
+	}{
+		"hostname blocked": {
+			blacklist: blacklist.Settings{
+				FqdnHostnames: []string{"cached.com."},
+			},
+		},
+		"IP address blocked": {
+			blacklist: blacklist.Settings{
+				IPs: []netaddr.IP{netaddr.IPv4(1, 2, 3, 4)},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			logger := mock_logging.NewMockLogger(ctrl)
+			settings := newTestSettings(t)
+			handler, err := New(context.Background(), logger, settings)
+			require.NoError(t, err)
+
+			request := new(dns.Msg).SetQuestion("cached.com.", dns.TypeA)
+			response, _ := handler.answer(request)
+			require.Equal(t, dns.RcodeSuccess, response.Rcode)
+			dnsCache := handler.Cache()
+
+			settings.BlacklistCategories = map[string]blacklist.Settings{
+				blacklist.CategoryCustom: testCase.blacklist,
+			}
+			err = handler.Reload(settings)
+			require.NoError(t, err)
+
+			assert.Same(t, dnsCache, handler.Cache())
+			response, info := handler.answer(request)
+			assert.Equal(t, dns.RcodeRefused, response.Rcode)
+			assert.True(t, info.blocked)
+		})
+	}
+}
+
+func Test_Handler_Reload_cache(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+
+	logger := mock_logging.NewMockLogger(ctrl)
+	settings := newTestSettings(t)
+	settings.Cache.PersistPath = filepath.Join(t.TempDir(), "cache")
+	settings.Cache.PersistPeriod = time.Hour
+	handler, err := New(context.Background(), logger, settings)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	maintenanceDone := make(chan struct{})
+	go func() {
+		defer close(maintenanceDone)
+		handler.MaintainCache(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-maintenanceDone
+	}()
+
+	request := new(dns.Msg).SetQuestion("cached.com.", dns.TypeA)
+	response, _ := handler.answer(request)
+	require.Equal(t, dns.RcodeSuccess, response.Rcode)
+	oldCache := handler.Cache()
+
+	settings.Cache.LRU.MaxEntries++
+	err = handler.Reload(settings)
+	require.NoError(t, err)
+
+	// The entry added since the start is saved with the old
+	// cache and loaded from the file in the new cache.
+	require.Eventually(t, func() bool {
+		return handler.Cache() != oldCache
+	}, time.Second, time.Millisecond)
+	assert.NotNil(t, handler.Cache().Get(request))
+}
+
+type testResponseWriter struct {
+	dns.ResponseWriter
+	written *dns.Msg
+	signed  bool
+}
+
+func (w *testResponseWriter) WriteMsg(msg *dns.Msg) error {
+	w.written = msg
+	w.signed = true
+	return nil
+}
+
+func (w *testResponseWriter) Write(data []byte) (int, error) {
+	w.written = new(dns.Msg)
+	return len(data), w.written.Unpack(data)
+}
+
+func Test_writeUpdateResponse(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		tsigError uint16
+		signed    bool
+	}{
+		"signed response": {
+			signed: true,
+		},
+		"bad signature": {
+			tsigError: dns.RcodeBadSig,
+		},
+		"bad key": {
+			tsigError: dns.RcodeBadKey,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			response := new(dns.Msg).SetUpdate("lan.")
+			response.SetTsig("key.", dns.HmacSHA256, 300, time.Now().Unix())
+			response.IsTsig().Error = testCase.tsigError
+			w := &testResponseWriter{}
+
+			err := writeUpdateResponse(w, response)
+
+			require.NoError(t, err)
+			assert.Equal(t, testCase.signed, w.signed)
+			tsig := w.written.IsTsig()
+			require.NotNil(t, tsig)
+			assert.Equal(t, testCase.tsigError, tsig.Error)
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/cache"
+	"github.com/qdm12/dns/pkg/dnstap"
+	"github.com/qdm12/dns/pkg/local"
+	"github.com/qdm12/dns/pkg/metrics"
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/qdm12/dns/pkg/warmup"
+)
+
+// DialFunc dials a connection to an upstream server.
+type DialFunc func(ctx context.Context, _, _ string) (net.Conn, error)
+
+// Settings are the settings of the handler, built from
+// the settings of the DoT or DoH server using it.
+// They are expected to have their defaults already set.
+type Settings struct {
+	// Protocol is the protocol used to exchange with the
+	// upstream servers, for logs, metrics and dnstap.
+	Protocol dnstap.Protocol
+	Upstream UpstreamSettings
+	Cache    cache.Settings
+	// BlacklistCategories are block lists by category, keyed
+	// by the blacklist Category constants.
+	BlacklistCategories map[string]blacklist.Settings
+	// BlacklistMonitorOnly are the block list categories, or
+	// blacklist.AllCategories, for which matching queries are
+	// only logged and counted instead of being blocked.
+	BlacklistMonitorOnly []string
+	Local                local.Settings
+	WarmUp               warmup.Settings
+	QueryLog             querylog.Settings
+	Dnstap               dnstap.Settings
+	Metrics              metrics.Metrics
+}
+
+type UpstreamSettings struct {
+	Dial DialFunc
+	// StaleAnswerTimeout is the duration to wait for the upstream
+	// before answering with a stale cached response, if any.
+	StaleAnswerTimeout time.Duration
+	// Names maps upstream addresses to the name
+	// of their provider, to label the metrics.
+	Names map[string]string
+}
@@ -0,0 +1,19 @@
+package local
+
+import "github.com/miekg/dns"
+
+// AcceptUpdates is a dns.MsgAcceptFunc which accepts dynamic update
+// requests, and otherwise behaves like dns.DefaultMsgAcceptFunc.
+func AcceptUpdates(dh dns.Header) dns.MsgAcceptAction {
+	const (
+		responseBit = 1 << 15
+		opcodeShift = 11
+		opcodeMask  = 0xF
+	)
+	isResponse := dh.Bits&responseBit != 0
+	opcode := int(dh.Bits>>opcodeShift) & opcodeMask
+	if !isResponse && opcode == dns.OpcodeUpdate {
+		return dns.MsgAccept
+	}
+	return dns.DefaultMsgAcceptFunc(dh)
+}
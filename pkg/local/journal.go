@@ -0,0 +1,82 @@
+package local
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// journal is an append only file of the dynamic
+// update operations applied, one operation per line.
+type journal struct {
+	path string
+}
+
+func newJournal(path string) *journal {
+	return &journal{
+		path: path,
+	}
+}
+
+// replay reads all the operations from the journal file.
+// It returns no operation and no error if the file does not exist.
+func (j *journal) replay() (operations []operation, err error) {
+	file, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		operation, err := parseOperation(line)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		operations = append(operations, operation)
+	}
+
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return operations, file.Close()
+}
+
+// append writes the operations to the end of the
+// journal file and flushes it to disk.
+func (j *journal) append(operations []operation) (err error) {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]string, len(operations))
+	for i, operation := range operations {
+		lines[i] = operation.String() + "\n"
+	}
+
+	if _, err := file.WriteString(strings.Join(lines, "")); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
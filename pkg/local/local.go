@@ -0,0 +1,163 @@
+package local
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Local
+
+type Local interface {
+	// Answer returns a response to the request if the question
+	// can be answered locally, and nil otherwise.
+	Answer(request *dns.Msg) (response *dns.Msg)
+	// Update applies the dynamic update request given, if it is signed
+	// with one of the TSIG keys and targets one of the local zones
+	// the key is allowed to update.
+	// The tsigStatus argument should be set to the TsigStatus() of the
+	// dns.ResponseWriter. A non nil error is returned if the update
+	// is refused, together with the response to send back.
+	Update(request *dns.Msg, tsigStatus error) (response *dns.Msg, err error)
+}
+
+type zones struct {
+	zones    []string
+	keyZones map[string]map[string]struct{} // TSIG key name -> zones
+	journal  *journal
+
+	// State
+	records map[string]map[uint16][]dns.RR // canonical name -> type -> records
+	serials map[string]uint32              // zone -> SOA serial
+	mutex   sync.RWMutex
+}
+
+// New creates a local zones store. If a journal path is set, the
+// dynamic updates recorded in the journal are replayed.
+func New(settings Settings) (local Local, err error) {
+	settings.setDefaults()
+
+	z := &zones{
+		zones:    settings.Zones,
+		keyZones: make(map[string]map[string]struct{}, len(settings.TSIGKeys)),
+		records:  make(map[string]map[uint16][]dns.RR),
+		serials:  make(map[string]uint32, len(settings.Zones)),
+	}
+
+	for _, zone := range z.zones {
+		z.serials[zone] = 1
+	}
+
+	for name, key := range settings.TSIGKeys {
+		z.keyZones[name] = make(map[string]struct{}, len(key.Zones))
+		for _, zone := range key.Zones {
+			z.keyZones[name][zone] = struct{}{}
+		}
+	}
+
+	if settings.JournalPath != "" {
+		z.journal = newJournal(settings.JournalPath)
+		operations, err := z.journal.replay()
+		if err != nil {
+			return nil, fmt.Errorf("cannot replay journal: %w", err)
+		}
+		z.apply(operations)
+	}
+
+	return z, nil
+}
+
+func (z *zones) Answer(request *dns.Msg) (response *dns.Msg) {
+	if len(request.Question) != 1 {
+		return nil
+	}
+
+	question := request.Question[0]
+	name := dns.CanonicalName(question.Name)
+	zone := z.zoneOf(name)
+
+	z.mutex.RLock()
+	defer z.mutex.RUnlock()
+
+	rrsets, nameExists := z.records[name]
+	if !nameExists && zone == "" {
+		return nil
+	}
+
+	var answer []dns.RR
+	switch question.Qtype {
+	case dns.TypeANY:
+		for _, rrset := range rrsets {
+			answer = append(answer, rrset...)
+		}
+	default:
+		answer = rrsets[question.Qtype]
+		if len(answer) == 0 {
+			answer = rrsets[dns.TypeCNAME]
+		}
+	}
+
+	if len(answer) == 0 && zone == "" {
+		// Name is known locally but is not part of a local zone,
+		// such as a PTR record, so let the upstream answer it.
+		return nil
+	}
+
+	response = new(dns.Msg)
+	response.SetReply(request)
+	response.Authoritative = zone != ""
+	response.Answer = make([]dns.RR, len(answer))
+	for i := range answer {
+		response.Answer[i] = dns.Copy(answer[i])
+	}
+
+	if len(answer) == 0 {
+		if !nameExists {
+			response.Rcode = dns.RcodeNameError
+		}
+		response.Ns = []dns.RR{z.soa(zone)}
+	}
+
+	return response
+}
+
+// zoneOf returns the most specific local zone the
+// canonical name is part of, or the empty string if
+// the name is not part of any local zone.
+func (z *zones) zoneOf(name string) (zone string) {
+	for _, localZone := range z.zones {
+		if dns.IsSubDomain(localZone, name) && len(localZone) > len(zone) {
+			zone = localZone
+		}
+	}
+	return zone
+}
+
+// soa returns a SOA record for the zone.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) soa(zone string) *dns.SOA {
+	const (
+		ttl     = 3600
+		refresh = 3600
+		retry   = 600
+		expire  = 86400
+		minTTL  = 60
+	)
+	return &dns.SOA{
+		Hdr: dns.RR_Header{
+			Name:   zone,
+			Rrtype: dns.TypeSOA,
+			Class:  dns.ClassINET,
+			Ttl:    ttl,
+		},
+		Ns:      "ns." + zone,
+		Mbox:    "hostmaster." + zone,
+		Serial:  z.serials[zone],
+		Refresh: refresh,
+		Retry:   retry,
+		Expire:  expire,
+		Minttl:  minTTL,
+	}
+}
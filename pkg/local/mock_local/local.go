@@ -0,0 +1,64 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/local (interfaces: Local)
+
+// Package mock_local is a generated GoMock package.
+package mock_local
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	dns "github.com/miekg/dns"
+)
+
+// MockLocal is a mock of Local interface.
+type MockLocal struct {
+	ctrl     *gomock.Controller
+	recorder *MockLocalMockRecorder
+}
+
+// MockLocalMockRecorder is the mock recorder for MockLocal.
+type MockLocalMockRecorder struct {
+	mock *MockLocal
+}
+
+// NewMockLocal creates a new mock instance.
+func NewMockLocal(ctrl *gomock.Controller) *MockLocal {
+	mock := &MockLocal{ctrl: ctrl}
+	mock.recorder = &MockLocalMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLocal) EXPECT() *MockLocalMockRecorder {
+	return m.recorder
+}
+
+// Answer mocks base method.
+func (m *MockLocal) Answer(arg0 *dns.Msg) *dns.Msg {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Answer", arg0)
+	ret0, _ := ret[0].(*dns.Msg)
+	return ret0
+}
+
+// Answer indicates an expected call of Answer.
+func (mr *MockLocalMockRecorder) Answer(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Answer", reflect.TypeOf((*MockLocal)(nil).Answer), arg0)
+}
+
+// Update mocks base method.
+func (m *MockLocal) Update(arg0 *dns.Msg, arg1 error) (*dns.Msg, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", arg0, arg1)
+	ret0, _ := ret[0].(*dns.Msg)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockLocalMockRecorder) Update(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockLocal)(nil).Update), arg0, arg1)
+}
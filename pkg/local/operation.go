@@ -0,0 +1,194 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type operationKind string
+
+const (
+	operationAdd         operationKind = "add"
+	operationDelete      operationKind = "delete"
+	operationDeleteRRset operationKind = "delete-rrset"
+	operationDeleteName  operationKind = "delete-name"
+)
+
+// operation is a single change to apply to the records.
+// The rr field is set for add and delete kinds, and the
+// name and rrtype fields are set for the other kinds.
+type operation struct {
+	kind   operationKind
+	rr     dns.RR
+	name   string
+	rrtype uint16
+}
+
+func (o operation) String() string {
+	switch o.kind {
+	case operationAdd, operationDelete:
+		return string(o.kind) + " " + o.rr.String()
+	case operationDeleteRRset:
+		return string(o.kind) + " " + o.name + " " + dns.TypeToString[o.rrtype]
+	case operationDeleteName:
+		return string(o.kind) + " " + o.name
+	default:
+		panic("unknown operation kind: " + string(o.kind))
+	}
+}
+
+var (
+	ErrOperationMalformed = errors.New("operation is malformed")
+	ErrOperationUnknown   = errors.New("operation is unknown")
+)
+
+func parseOperation(s string) (o operation, err error) {
+	const expectedFields = 2
+	fields := strings.SplitN(s, " ", expectedFields)
+	if len(fields) != expectedFields {
+		return o, fmt.Errorf("%w: %q", ErrOperationMalformed, s)
+	}
+
+	o.kind = operationKind(fields[0])
+	switch o.kind {
+	case operationAdd, operationDelete:
+		o.rr, err = dns.NewRR(fields[1])
+		if err != nil {
+			return o, fmt.Errorf("%w: %q: %s", ErrOperationMalformed, s, err)
+		} else if o.rr == nil {
+			return o, fmt.Errorf("%w: %q: no record", ErrOperationMalformed, s)
+		}
+	case operationDeleteRRset:
+		fields = strings.Fields(fields[1])
+		if len(fields) != expectedFields {
+			return o, fmt.Errorf("%w: %q", ErrOperationMalformed, s)
+		}
+		o.name = dns.CanonicalName(fields[0])
+		rrtype, ok := dns.StringToType[fields[1]]
+		if !ok {
+			return o, fmt.Errorf("%w: %q: unknown type %s", ErrOperationMalformed, s, fields[1])
+		}
+		o.rrtype = rrtype
+	case operationDeleteName:
+		o.name = dns.CanonicalName(fields[1])
+	default:
+		return o, fmt.Errorf("%w: %q", ErrOperationUnknown, s)
+	}
+	return o, nil
+}
+
+// apply applies the operations to the records and increments
+// the serial of each zone modified.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) apply(operations []operation) {
+	for _, o := range operations {
+		var name string
+		switch o.kind {
+		case operationAdd:
+			name = dns.CanonicalName(o.rr.Header().Name)
+			z.add(name, o.rr)
+		case operationDelete:
+			name = dns.CanonicalName(o.rr.Header().Name)
+			z.delete(name, o.rr)
+		case operationDeleteRRset:
+			name = o.name
+			z.deleteRRset(name, o.rrtype)
+		case operationDeleteName:
+			name = o.name
+			z.deleteName(name)
+		}
+
+		if zone := z.zoneOf(name); zone != "" {
+			z.serials[zone]++
+		}
+	}
+}
+
+// add adds the record to the records, respecting the CNAME
+// rules from RFC 2136 section 3.4.2.2.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) add(name string, rr dns.RR) {
+	rrtype := rr.Header().Rrtype
+	if rrtype == dns.TypeSOA {
+		// SOA records are synthesized for local zones.
+		return
+	}
+
+	rrsets, ok := z.records[name]
+	if !ok {
+		rrsets = make(map[uint16][]dns.RR)
+		z.records[name] = rrsets
+	}
+
+	switch {
+	case rrtype == dns.TypeCNAME && len(rrsets) > 0 && len(rrsets[dns.TypeCNAME]) == 0:
+		// cannot add a CNAME to a name with other records
+		return
+	case rrtype != dns.TypeCNAME && len(rrsets[dns.TypeCNAME]) > 0:
+		// cannot add a record to a name with a CNAME
+		return
+	case rrtype == dns.TypeCNAME:
+		// a CNAME replaces the existing CNAME
+		rrsets[dns.TypeCNAME] = []dns.RR{rr}
+		return
+	}
+
+	existing := rrsets[rrtype]
+	for i := range existing {
+		if dns.IsDuplicate(existing[i], rr) {
+			// replace it to update its TTL
+			existing[i] = rr
+			return
+		}
+	}
+	rrsets[rrtype] = append(existing, rr)
+}
+
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) delete(name string, rr dns.RR) {
+	rrsets, ok := z.records[name]
+	if !ok {
+		return
+	}
+
+	rrtype := rr.Header().Rrtype
+	existing := rrsets[rrtype]
+	filtered := make([]dns.RR, 0, len(existing))
+	for _, existingRR := range existing {
+		if !dns.IsDuplicate(existingRR, rr) {
+			filtered = append(filtered, existingRR)
+		}
+	}
+
+	if len(filtered) > 0 {
+		rrsets[rrtype] = filtered
+		return
+	}
+	z.deleteRRset(name, rrtype)
+}
+
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) deleteRRset(name string, rrtype uint16) {
+	rrsets, ok := z.records[name]
+	if !ok {
+		return
+	}
+
+	delete(rrsets, rrtype)
+	if len(rrsets) == 0 {
+		delete(z.records, name)
+	}
+}
+
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) deleteName(name string) {
+	delete(z.records, name)
+}
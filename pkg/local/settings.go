@@ -0,0 +1,95 @@
+package local
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+type Settings struct {
+	// Zones are the zones served locally, for example "lan.".
+	// Records in these zones can be changed with signed
+	// dynamic DNS updates (RFC 2136).
+	Zones []string
+	// TSIGKeys maps TSIG key names to their key.
+	// Dynamic updates must be signed with one of these keys,
+	// and can only update the zones of the key.
+	TSIGKeys map[string]TSIGKey
+	// JournalPath is the file path to write applied dynamic updates
+	// to, such that they are replayed on the next start.
+	// It defaults to the empty string which disables the journal.
+	JournalPath string
+}
+
+// TSIGKey is a TSIG key allowed to sign dynamic updates.
+type TSIGKey struct {
+	// Secret is the base64 encoded secret of the key.
+	Secret string
+	// Zones are the local zones the key is allowed to update,
+	// for example "lan.".
+	Zones []string
+}
+
+func (s *Settings) setDefaults() {
+	s.Zones = canonicalNames(s.Zones)
+
+	keys := make(map[string]TSIGKey, len(s.TSIGKeys))
+	for name, key := range s.TSIGKeys {
+		key.Zones = canonicalNames(key.Zones)
+		keys[dns.CanonicalName(name)] = key
+	}
+	s.TSIGKeys = keys
+}
+
+func canonicalNames(names []string) (canonical []string) {
+	canonical = make([]string, len(names))
+	for i := range names {
+		canonical[i] = dns.CanonicalName(names[i])
+	}
+	return canonical
+}
+
+// TSIGSecrets returns the TSIG secrets keyed by canonical key name,
+// in the format expected by the TsigSecret field of dns.Server.
+// It returns nil if no TSIG key is set.
+func (s *Settings) TSIGSecrets() (secrets map[string]string) {
+	if len(s.TSIGKeys) == 0 {
+		return nil
+	}
+	secrets = make(map[string]string, len(s.TSIGKeys))
+	for name, key := range s.TSIGKeys {
+		secrets[dns.CanonicalName(name)] = key.Secret
+	}
+	return secrets
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if len(s.Zones) == 0 {
+		return []string{subSection + "Local zones: disabled"}
+	}
+
+	lines = append(lines, subSection+"Local zones:")
+	for _, zone := range s.Zones {
+		lines = append(lines, indent+subSection+zone)
+	}
+
+	lines = append(lines, subSection+"TSIG keys for dynamic updates: "+
+		strconv.Itoa(len(s.TSIGKeys)))
+
+	journal := "disabled"
+	if s.JournalPath != "" {
+		journal = s.JournalPath
+	}
+	lines = append(lines, subSection+"Dynamic updates journal: "+journal)
+
+	return lines
+}
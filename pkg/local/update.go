@@ -0,0 +1,280 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	ErrUpdateNotSigned     = errors.New("dynamic update is not signed")
+	ErrUpdateBadSignature  = errors.New("dynamic update signature is invalid")
+	ErrUpdateMalformed     = errors.New("dynamic update is malformed")
+	ErrUpdateZoneNotLocal  = errors.New("dynamic update zone is not a local zone")
+	ErrUpdateKeyNotAllowed = errors.New("dynamic update key is not allowed to update zone")
+	ErrUpdatePrerequisite  = errors.New("dynamic update prerequisite failed")
+	ErrUpdateNameNotInZone = errors.New("dynamic update name is not in zone")
+	ErrUpdateJournal       = errors.New("cannot write dynamic update to journal")
+)
+
+func (z *zones) Update(request *dns.Msg, tsigStatus error) (
+	response *dns.Msg, err error) {
+	response = new(dns.Msg)
+	response.SetReply(request)
+
+	const fudgeSeconds = 300
+	tsig := request.IsTsig()
+	switch {
+	case tsig == nil:
+		response.Rcode = dns.RcodeRefused
+		return response, ErrUpdateNotSigned
+	case tsigStatus != nil:
+		// The response has an unsigned TSIG record with the
+		// error set, as described in RFC 2845 section 4.3.
+		response.Rcode = dns.RcodeNotAuth
+		response.SetTsig(tsig.Hdr.Name, tsig.Algorithm,
+			fudgeSeconds, time.Now().Unix())
+		response.IsTsig().Error = dns.RcodeBadSig
+		if errors.Is(tsigStatus, dns.ErrSecret) {
+			response.IsTsig().Error = dns.RcodeBadKey
+		}
+		return response, fmt.Errorf("%w: key %s: %s",
+			ErrUpdateBadSignature, tsig.Hdr.Name, tsigStatus)
+	}
+
+	defer response.SetTsig(tsig.Hdr.Name, tsig.Algorithm,
+		fudgeSeconds, time.Now().Unix())
+
+	// The zone section uses the question section format.
+	if len(request.Question) != 1 || request.Question[0].Qtype != dns.TypeSOA {
+		response.Rcode = dns.RcodeFormatError
+		return response, fmt.Errorf("%w: zone section must contain exactly one SOA",
+			ErrUpdateMalformed)
+	}
+
+	zone := dns.CanonicalName(request.Question[0].Name)
+
+	z.mutex.Lock()
+	defer z.mutex.Unlock()
+
+	if _, ok := z.serials[zone]; !ok {
+		response.Rcode = dns.RcodeNotAuth
+		return response, fmt.Errorf("%w: %s", ErrUpdateZoneNotLocal, zone)
+	}
+
+	keyName := dns.CanonicalName(tsig.Hdr.Name)
+	if _, ok := z.keyZones[keyName][zone]; !ok {
+		response.Rcode = dns.RcodeNotAuth
+		return response, fmt.Errorf("%w: key %s for zone %s",
+			ErrUpdateKeyNotAllowed, keyName, zone)
+	}
+
+	// Prerequisite section uses the answer section format.
+	response.Rcode, err = z.checkPrerequisites(zone, request.Answer)
+	if err != nil {
+		return response, err
+	}
+
+	// Update section uses the authority section format.
+	operations, rcode, err := makeOperations(zone, request.Ns)
+	if err != nil {
+		response.Rcode = rcode
+		return response, err
+	}
+
+	if z.journal != nil {
+		if err := z.journal.append(operations); err != nil {
+			response.Rcode = dns.RcodeServerFailure
+			return response, fmt.Errorf("%w: %s", ErrUpdateJournal, err)
+		}
+	}
+
+	z.apply(operations)
+
+	return response, nil
+}
+
+// checkPrerequisites checks the prerequisites given against the
+// current records, as described in RFC 2136 section 3.2.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) checkPrerequisites(zone string, prerequisites []dns.RR) (
+	rcode int, err error) {
+	var valueDependent []dns.RR
+	for _, rr := range prerequisites {
+		header := rr.Header()
+		name := dns.CanonicalName(header.Name)
+
+		if header.Ttl != 0 {
+			return dns.RcodeFormatError, fmt.Errorf("%w: prerequisite TTL is not zero for %s",
+				ErrUpdateMalformed, name)
+		}
+
+		if !dns.IsSubDomain(zone, name) {
+			return dns.RcodeNotZone, fmt.Errorf("%w: %s", ErrUpdateNameNotInZone, name)
+		}
+
+		switch header.Class {
+		case dns.ClassANY:
+			if header.Rdlength != 0 {
+				return dns.RcodeFormatError, fmt.Errorf("%w: prerequisite has data for %s",
+					ErrUpdateMalformed, name)
+			}
+			rrsets, exists := z.records[name]
+			if header.Rrtype == dns.TypeANY {
+				if !exists {
+					return dns.RcodeNameError, fmt.Errorf("%w: name %s is not in use",
+						ErrUpdatePrerequisite, name)
+				}
+				continue
+			}
+			if len(rrsets[header.Rrtype]) == 0 {
+				return dns.RcodeNXRrset, fmt.Errorf("%w: RRset %s %s does not exist",
+					ErrUpdatePrerequisite, name, dns.TypeToString[header.Rrtype])
+			}
+		case dns.ClassNONE:
+			if header.Rdlength != 0 {
+				return dns.RcodeFormatError, fmt.Errorf("%w: prerequisite has data for %s",
+					ErrUpdateMalformed, name)
+			}
+			rrsets, exists := z.records[name]
+			if header.Rrtype == dns.TypeANY {
+				if exists {
+					return dns.RcodeYXDomain, fmt.Errorf("%w: name %s is in use",
+						ErrUpdatePrerequisite, name)
+				}
+				continue
+			}
+			if len(rrsets[header.Rrtype]) > 0 {
+				return dns.RcodeYXRrset, fmt.Errorf("%w: RRset %s %s exists",
+					ErrUpdatePrerequisite, name, dns.TypeToString[header.Rrtype])
+			}
+		case dns.ClassINET:
+			valueDependent = append(valueDependent, rr)
+		default:
+			return dns.RcodeFormatError, fmt.Errorf("%w: prerequisite class %s for %s",
+				ErrUpdateMalformed, dns.ClassToString[header.Class], name)
+		}
+	}
+
+	if !z.rrsetsMatch(valueDependent) {
+		return dns.RcodeNXRrset, fmt.Errorf("%w: RRsets do not match exactly",
+			ErrUpdatePrerequisite)
+	}
+
+	return dns.RcodeSuccess, nil
+}
+
+// rrsetsMatch returns true if the records given match exactly the
+// current RRsets with the same name and type.
+// It is NOT thread safe and its parent should have
+// a locking mechanism to stay thread safe.
+func (z *zones) rrsetsMatch(rrs []dns.RR) (match bool) {
+	type rrsetKey struct {
+		name   string
+		rrtype uint16
+	}
+	expected := make(map[rrsetKey][]dns.RR)
+	for _, rr := range rrs {
+		key := rrsetKey{
+			name:   dns.CanonicalName(rr.Header().Name),
+			rrtype: rr.Header().Rrtype,
+		}
+		expected[key] = append(expected[key], rr)
+	}
+
+	for key, expectedRRs := range expected {
+		existing := z.records[key.name][key.rrtype]
+		if len(existing) != len(expectedRRs) {
+			return false
+		}
+		for _, expectedRR := range expectedRRs {
+			if !containsRR(existing, expectedRR) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// makeOperations converts the update section records to operations,
+// after checking them as described in RFC 2136 section 3.4.1.
+func makeOperations(zone string, updates []dns.RR) (
+	operations []operation, rcode int, err error) {
+	operations = make([]operation, 0, len(updates))
+	for _, rr := range updates {
+		header := rr.Header()
+		name := dns.CanonicalName(header.Name)
+
+		if !dns.IsSubDomain(zone, name) {
+			return nil, dns.RcodeNotZone, fmt.Errorf("%w: %s", ErrUpdateNameNotInZone, name)
+		}
+
+		if isMetaType(header.Rrtype) && header.Rrtype != dns.TypeANY {
+			return nil, dns.RcodeFormatError, fmt.Errorf("%w: update type %s for %s",
+				ErrUpdateMalformed, dns.TypeToString[header.Rrtype], name)
+		}
+
+		switch header.Class {
+		case dns.ClassINET:
+			if header.Rrtype == dns.TypeANY {
+				return nil, dns.RcodeFormatError, fmt.Errorf("%w: update type ANY for %s",
+					ErrUpdateMalformed, name)
+			}
+			operations = append(operations, operation{
+				kind: operationAdd,
+				rr:   rr,
+			})
+		case dns.ClassANY:
+			if header.Ttl != 0 || header.Rdlength != 0 {
+				return nil, dns.RcodeFormatError, fmt.Errorf("%w: RRset deletion has TTL or data for %s",
+					ErrUpdateMalformed, name)
+			}
+			kind := operationDeleteRRset
+			if header.Rrtype == dns.TypeANY {
+				kind = operationDeleteName
+			}
+			operations = append(operations, operation{
+				kind:   kind,
+				name:   name,
+				rrtype: header.Rrtype,
+			})
+		case dns.ClassNONE:
+			if header.Ttl != 0 || header.Rrtype == dns.TypeANY {
+				return nil, dns.RcodeFormatError, fmt.Errorf("%w: record deletion has TTL or type ANY for %s",
+					ErrUpdateMalformed, name)
+			}
+			rr = dns.Copy(rr)
+			rr.Header().Class = dns.ClassINET
+			operations = append(operations, operation{
+				kind: operationDelete,
+				rr:   rr,
+			})
+		default:
+			return nil, dns.RcodeFormatError, fmt.Errorf("%w: update class %s for %s",
+				ErrUpdateMalformed, dns.ClassToString[header.Class], name)
+		}
+	}
+	return operations, dns.RcodeSuccess, nil
+}
+
+func isMetaType(rrtype uint16) bool {
+	switch rrtype {
+	case dns.TypeANY, dns.TypeAXFR, dns.TypeIXFR,
+		dns.TypeMAILA, dns.TypeMAILB, dns.TypeOPT, dns.TypeTSIG:
+		return true
+	default:
+		return false
+	}
+}
+
+func containsRR(rrs []dns.RR, rr dns.RR) bool {
+	for _, existing := range rrs {
+		if dns.IsDuplicate(existing, rr) {
+			return true
+		}
+	}
+	return false
+}
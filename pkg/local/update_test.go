@@ -0,0 +1,268 @@
+package local
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	require.NoError(t, err)
+	return rr
+}
+
+func newTestUpdate(zone string, signed bool) *dns.Msg {
+	request := new(dns.Msg)
+	request.SetUpdate(zone)
+	if signed {
+		request.SetTsig("key.", dns.HmacSHA256, 300, time.Now().Unix())
+	}
+	return request
+}
+
+func newTestKeys() map[string]TSIGKey {
+	return map[string]TSIGKey{
+		"Key": {Secret: "c2VjcmV0", Zones: []string{"lan"}},
+	}
+}
+
+func Test_zones_Update(t *testing.T) {
+	t.Parallel()
+
+	rrA := "host.lan. 300 IN A 192.168.1.2"
+	rrAAAA := "host.lan. 300 IN AAAA fd00::2"
+
+	testCases := map[string]struct {
+		initial    []string
+		makeUpdate func(t *testing.T) *dns.Msg
+		tsigStatus error
+		rcode      int
+		tsigError  uint16
+		errWrapped error
+		answerName string
+		answerType uint16
+		answer     []string
+	}{
+		"not signed": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", false)
+				request.Insert([]dns.RR{newTestRR(t, rrA)})
+				return request
+			},
+			rcode:      dns.RcodeRefused,
+			errWrapped: ErrUpdateNotSigned,
+			answerName: "host.lan.",
+			answerType: dns.TypeA,
+		},
+		"bad signature": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.Insert([]dns.RR{newTestRR(t, rrA)})
+				return request
+			},
+			tsigStatus: dns.ErrSig,
+			rcode:      dns.RcodeNotAuth,
+			tsigError:  dns.RcodeBadSig,
+			errWrapped: ErrUpdateBadSignature,
+			answerName: "host.lan.",
+			answerType: dns.TypeA,
+		},
+		"unknown key": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.Insert([]dns.RR{newTestRR(t, rrA)})
+				return request
+			},
+			tsigStatus: dns.ErrSecret,
+			rcode:      dns.RcodeNotAuth,
+			tsigError:  dns.RcodeBadKey,
+			errWrapped: ErrUpdateBadSignature,
+			answerName: "host.lan.",
+			answerType: dns.TypeA,
+		},
+		"zone not local": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("example.com.", true)
+				request.Insert([]dns.RR{newTestRR(t, "host.example.com. 300 IN A 1.2.3.4")})
+				return request
+			},
+			rcode:      dns.RcodeNotAuth,
+			errWrapped: ErrUpdateZoneNotLocal,
+		},
+		"key not allowed for zone": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("home.", true)
+				request.Insert([]dns.RR{newTestRR(t, "host.home. 300 IN A 192.168.1.2")})
+				return request
+			},
+			rcode:      dns.RcodeNotAuth,
+			errWrapped: ErrUpdateKeyNotAllowed,
+			answerName: "host.home.",
+			answerType: dns.TypeA,
+		},
+		"name not in zone": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.Insert([]dns.RR{newTestRR(t, "host.example.com. 300 IN A 1.2.3.4")})
+				return request
+			},
+			rcode:      dns.RcodeNotZone,
+			errWrapped: ErrUpdateNameNotInZone,
+		},
+		"add record": {
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.Insert([]dns.RR{newTestRR(t, rrA), newTestRR(t, rrAAAA)})
+				return request
+			},
+			rcode:      dns.RcodeSuccess,
+			answerName: "HOST.lan.",
+			answerType: dns.TypeA,
+			answer:     []string{"host.lan.\t300\tIN\tA\t192.168.1.2"},
+		},
+		"prerequisite name not used failed": {
+			initial: []string{rrA},
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.NameNotUsed([]dns.RR{newTestRR(t, rrA)})
+				request.Insert([]dns.RR{newTestRR(t, "host.lan. 300 IN A 192.168.1.3")})
+				return request
+			},
+			rcode:      dns.RcodeYXDomain,
+			errWrapped: ErrUpdatePrerequisite,
+			answerName: "host.lan.",
+			answerType: dns.TypeA,
+			answer:     []string{"host.lan.\t300\tIN\tA\t192.168.1.2"},
+		},
+		"prerequisite value dependent": {
+			initial: []string{rrA},
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.Used([]dns.RR{newTestRR(t, "host.lan. 0 IN A 192.168.1.2")})
+				request.Remove([]dns.RR{newTestRR(t, rrA)})
+				request.Insert([]dns.RR{newTestRR(t, "host.lan. 300 IN A 192.168.1.3")})
+				return request
+			},
+			rcode:      dns.RcodeSuccess,
+			answerName: "host.lan.",
+			answerType: dns.TypeA,
+			answer:     []string{"host.lan.\t300\tIN\tA\t192.168.1.3"},
+		},
+		"remove name": {
+			initial: []string{rrA, rrAAAA},
+			makeUpdate: func(t *testing.T) *dns.Msg {
+				request := newTestUpdate("lan.", true)
+				request.RemoveName([]dns.RR{newTestRR(t, rrA)})
+				return request
+			},
+			rcode:      dns.RcodeSuccess,
+			answerName: "host.lan.",
+			answerType: dns.TypeAAAA,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := Settings{
+				Zones:    []string{"lan", "home"},
+				TSIGKeys: newTestKeys(),
+			}
+			local, err := New(settings)
+			require.NoError(t, err)
+			z := local.(*zones)
+
+			initial := make([]operation, len(testCase.initial))
+			for i, s := range testCase.initial {
+				initial[i] = operation{kind: operationAdd, rr: newTestRR(t, s)}
+			}
+			z.apply(initial)
+
+			request := testCase.makeUpdate(t)
+			response, err := local.Update(request, testCase.tsigStatus)
+
+			require.NotNil(t, response)
+			assert.Equal(t, testCase.rcode, response.Rcode)
+			if testCase.tsigError != dns.RcodeSuccess {
+				tsig := response.IsTsig()
+				require.NotNil(t, tsig)
+				assert.Equal(t, testCase.tsigError, tsig.Error)
+				assert.Empty(t, tsig.MAC)
+			}
+			if testCase.errWrapped != nil {
+				assert.True(t, errors.Is(err, testCase.errWrapped))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			if testCase.answerName == "" {
+				return
+			}
+
+			question := new(dns.Msg).SetQuestion(testCase.answerName, testCase.answerType)
+			response = local.Answer(question)
+			require.NotNil(t, response)
+			answer := make([]string, len(response.Answer))
+			for i, rr := range response.Answer {
+				answer[i] = rr.String()
+			}
+			if len(testCase.answer) == 0 {
+				assert.Empty(t, answer)
+			} else {
+				assert.Equal(t, testCase.answer, answer)
+			}
+		})
+	}
+}
+
+func Test_zones_journal(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{
+		Zones:       []string{"lan."},
+		TSIGKeys:    newTestKeys(),
+		JournalPath: filepath.Join(t.TempDir(), "journal"),
+	}
+
+	local, err := New(settings)
+	require.NoError(t, err)
+
+	request := newTestUpdate("lan.", true)
+	request.Insert([]dns.RR{
+		newTestRR(t, "host.lan. 300 IN A 192.168.1.2"),
+		newTestRR(t, "other.lan. 300 IN A 192.168.1.3"),
+	})
+	_, err = local.Update(request, nil)
+	require.NoError(t, err)
+
+	request = newTestUpdate("lan.", true)
+	request.RemoveRRset([]dns.RR{newTestRR(t, "other.lan. 0 IN A 0.0.0.0")})
+	_, err = local.Update(request, nil)
+	require.NoError(t, err)
+
+	// Simulate a restart
+	local, err = New(settings)
+	require.NoError(t, err)
+
+	response := local.Answer(new(dns.Msg).SetQuestion("host.lan.", dns.TypeA))
+	require.NotNil(t, response)
+	require.Len(t, response.Answer, 1)
+	assert.Equal(t, "host.lan.\t300\tIN\tA\t192.168.1.2", response.Answer[0].String())
+
+	response = local.Answer(new(dns.Msg).SetQuestion("other.lan.", dns.TypeA))
+	require.NotNil(t, response)
+	assert.Equal(t, dns.RcodeNameError, response.Rcode)
+	assert.Empty(t, response.Answer)
+	require.Len(t, response.Ns, 1)
+	soa := response.Ns[0].(*dns.SOA)
+	assert.Equal(t, uint32(4), soa.Serial)
+}
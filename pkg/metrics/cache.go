@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/qdm12/dns/pkg/cache/stats"
+)
+
+// CacheStatser returns the statistics of the current cache of
+// a DNS server, and false if caching is disabled.
+type CacheStatser interface {
+	CacheStats() (stats stats.Stats, enabled bool)
+}
+
+// cacheCollector collects the cache statistics of a DNS
+// server each time the metrics are scraped. Cache hits and
+// misses are already counted with the CacheHit and CacheMiss
+// methods, so only the other statistics are collected.
+type cacheCollector struct {
+	statser CacheStatser
+
+	evictions *prometheus.Desc
+	expired   *prometheus.Desc
+	entries   *prometheus.Desc
+	bytes     *prometheus.Desc
+}
+
+func newCacheCollector(server string, statser CacheStatser) *cacheCollector {
+	const subsystem = "cache"
+	constLabels := prometheus.Labels{"server": server}
+	newDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name),
+			help, nil, constLabels)
+	}
+
+	return &cacheCollector{
+		statser: statser,
+		evictions: newDesc("evictions_total",
+			"Number of cache entries removed to make room for new entries."),
+		expired: newDesc("expired_total",
+			"Number of cache entries removed because they expired."),
+		entries: newDesc("entries",
+			"Number of entries in the cache."),
+		bytes: newDesc("bytes",
+			"Size of the entries in the cache, in bytes."),
+	}
+}
+
+func (c *cacheCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.evictions
+	descs <- c.expired
+	descs <- c.entries
+	descs <- c.bytes
+}
+
+func (c *cacheCollector) Collect(metrics chan<- prometheus.Metric) {
+	stats, enabled := c.statser.CacheStats()
+	if !enabled {
+		return
+	}
+
+	metrics <- prometheus.MustNewConstMetric(c.evictions,
+		prometheus.CounterValue, float64(stats.Evictions))
+	metrics <- prometheus.MustNewConstMetric(c.expired,
+		prometheus.CounterValue, float64(stats.Expired))
+	metrics <- prometheus.MustNewConstMetric(c.entries,
+		prometheus.GaugeValue, float64(stats.Entries))
+	metrics <- prometheus.MustNewConstMetric(c.bytes,
+		prometheus.GaugeValue, float64(stats.Bytes))
+}
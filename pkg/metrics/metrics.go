@@ -0,0 +1,216 @@
+// Package metrics records metrics of the DNS servers
+// and serves them in the Prometheus text format.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Metrics
+
+type Metrics interface {
+	Query(server, qType, rcode string)
+	CacheHit(server string)
+	CacheMiss(server string)
+	Blocked(server, category string)
+	MonitoredMatch(server, list string)
+	UpstreamLatency(server, upstream string, latency time.Duration)
+	UpstreamError(server, upstream string)
+	BlockLists(hostnames, ips, ipPrefixes int)
+	UnboundRestart(reason string)
+	UnboundCrash()
+	CollectUnbound(statisticser UnboundStatisticser)
+	CollectCache(server string, statser CacheStatser)
+	Handler() http.Handler
+}
+
+// Reasons to restart Unbound.
+const (
+	RestartPlanned    = "planned"
+	RestartLocalNames = "local names"
+	RestartRequested  = "requested"
+	RestartBlocking   = "blocking"
+)
+
+const namespace = "dns"
+
+type metrics struct {
+	registry        *prometheus.Registry
+	queries         *prometheus.CounterVec
+	cacheHits       *prometheus.CounterVec
+	cacheMisses     *prometheus.CounterVec
+	blocked         *prometheus.CounterVec
+	monitored       *prometheus.CounterVec
+	upstreamLatency *prometheus.HistogramVec
+	upstreamErrors  *prometheus.CounterVec
+	blockList       *prometheus.GaugeVec
+	blockListUpdate prometheus.Gauge
+	unboundRestarts *prometheus.CounterVec
+	unboundCrashes  prometheus.Counter
+
+	// cacheCollectors maps each server to its cache collector,
+	// and is protected by the cacheCollectorsMutex.
+	cacheCollectors      map[string]prometheus.Collector
+	cacheCollectorsMutex sync.Mutex
+}
+
+// New creates metrics registered in their own registry,
+// together with the Go runtime and process metrics.
+func New() Metrics {
+	m := &metrics{
+		registry:        prometheus.NewRegistry(),
+		cacheCollectors: make(map[string]prometheus.Collector),
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queries_total",
+			Help:      "Number of DNS queries answered, by server, query type and response code.",
+		}, []string{"server", "type", "rcode"}),
+		cacheHits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of DNS queries answered from the cache.",
+		}, []string{"server"}),
+		cacheMisses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_misses_total",
+			Help:      "Number of DNS queries not found in the cache.",
+		}, []string{"server"}),
+		blocked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "blocked_queries_total",
+			Help:      "Number of DNS queries blocked, by server and block list category.",
+		}, []string{"server", "category"}),
+		monitored: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "monitored_matches_total",
+			Help:      "Number of block list matches not blocked in monitor only mode, by block list category.",
+		}, []string{"server", "list"}),
+		upstreamLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "upstream_latency_seconds",
+			Help:      "Latency of exchanges with upstream servers, by provider.",
+			Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}, //nolint:gomnd
+		}, []string{"server", "upstream"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upstream_errors_total",
+			Help:      "Number of failed exchanges with upstream servers, by provider.",
+		}, []string{"server", "upstream"}),
+		blockList: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "blocklist_entries",
+			Help:      "Number of entries blocked, by kind.",
+		}, []string{"kind"}),
+		blockListUpdate: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "blocklist_last_update_timestamp_seconds",
+			Help:      "Unix time of the last block lists update.",
+		}),
+		unboundRestarts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "unbound_restarts_total",
+			Help:      "Number of Unbound restarts, by reason.",
+		}, []string{"reason"}),
+		unboundCrashes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "unbound_crashes_total",
+			Help:      "Number of times Unbound exited unexpectedly.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.queries,
+		m.cacheHits,
+		m.cacheMisses,
+		m.blocked,
+		m.monitored,
+		m.upstreamLatency,
+		m.upstreamErrors,
+		m.blockList,
+		m.blockListUpdate,
+		m.unboundRestarts,
+		m.unboundCrashes,
+	)
+
+	return m
+}
+
+func (m *metrics) Query(server, qType, rcode string) {
+	m.queries.WithLabelValues(server, qType, rcode).Inc()
+}
+
+func (m *metrics) CacheHit(server string) {
+	m.cacheHits.WithLabelValues(server).Inc()
+}
+
+func (m *metrics) CacheMiss(server string) {
+	m.cacheMisses.WithLabelValues(server).Inc()
+}
+
+func (m *metrics) Blocked(server, category string) {
+	m.blocked.WithLabelValues(server, category).Inc()
+}
+
+func (m *metrics) MonitoredMatch(server, list string) {
+	m.monitored.WithLabelValues(server, list).Inc()
+}
+
+func (m *metrics) UpstreamLatency(server, upstream string, latency time.Duration) {
+	m.upstreamLatency.WithLabelValues(server, upstream).Observe(latency.Seconds())
+}
+
+func (m *metrics) UpstreamError(server, upstream string) {
+	m.upstreamErrors.WithLabelValues(server, upstream).Inc()
+}
+
+// BlockLists sets the block lists sizes,
+// and sets their last update time to now.
+func (m *metrics) BlockLists(hostnames, ips, ipPrefixes int) {
+	m.blockList.WithLabelValues("hostname").Set(float64(hostnames))
+	m.blockList.WithLabelValues("ip").Set(float64(ips))
+	m.blockList.WithLabelValues("ip_prefix").Set(float64(ipPrefixes))
+	m.blockListUpdate.SetToCurrentTime()
+}
+
+func (m *metrics) UnboundRestart(reason string) {
+	m.unboundRestarts.WithLabelValues(reason).Inc()
+}
+
+func (m *metrics) UnboundCrash() {
+	m.unboundCrashes.Inc()
+}
+
+// CollectUnbound registers a collector obtaining the Unbound
+// statistics each time the metrics are scraped. It must be
+// called at most once.
+func (m *metrics) CollectUnbound(statisticser UnboundStatisticser) {
+	m.registry.MustRegister(newUnboundCollector(statisticser))
+}
+
+// CollectCache registers a collector obtaining the cache statistics
+// of the server each time the metrics are scraped. It replaces the
+// cache collector previously registered for the server, if any.
+func (m *metrics) CollectCache(server string, statser CacheStatser) {
+	m.cacheCollectorsMutex.Lock()
+	defer m.cacheCollectorsMutex.Unlock()
+
+	if collector, ok := m.cacheCollectors[server]; ok {
+		m.registry.Unregister(collector)
+	}
+	collector := newCacheCollector(server, statser)
+	m.registry.MustRegister(collector)
+	m.cacheCollectors[server] = collector
+}
+
+// Handler returns an HTTP handler serving
+// the metrics in the Prometheus text format.
+func (m *metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/qdm12/dns/pkg/cache/stats"
+	"github.com/qdm12/dns/pkg/unbound"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_metrics_Handler(t *testing.T) {
+	t.Parallel()
+
+	metrics := New()
+	metrics.Query("dot", "A", "NOERROR")
+	metrics.Query("dot", "A", "NOERROR")
+	metrics.CacheHit("dot")
+	metrics.CacheMiss("doh")
+	metrics.Blocked("dot", "ads")
+	metrics.MonitoredMatch("dot", "ads")
+	metrics.UpstreamLatency("dot", "cloudflare", 30*time.Millisecond)
+	metrics.UpstreamError("doh", "google")
+	metrics.BlockLists(3, 2, 1)
+	metrics.UnboundRestart(RestartPlanned)
+	metrics.UnboundCrash()
+
+	body := scrape(t, metrics)
+
+	expectedLines := []string{
+		`dns_queries_total{rcode="NOERROR",server="dot",type="A"} 2`,
+		`dns_cache_hits_total{server="dot"} 1`,
+		`dns_cache_misses_total{server="doh"} 1`,
+		`dns_blocked_queries_total{category="ads",server="dot"} 1`,
+		`dns_monitored_matches_total{list="ads",server="dot"} 1`,
+		`dns_upstream_latency_seconds_bucket{server="dot",upstream="cloudflare",le="0.05"} 1`,
+		`dns_upstream_latency_seconds_bucket{server="dot",upstream="cloudflare",le="0.025"} 0`,
+		`dns_upstream_errors_total{server="doh",upstream="google"} 1`,
+		`dns_blocklist_entries{kind="hostname"} 3`,
+		`dns_blocklist_entries{kind="ip"} 2`,
+		`dns_blocklist_entries{kind="ip_prefix"} 1`,
+		`dns_unbound_restarts_total{reason="planned"} 1`,
+		`dns_unbound_crashes_total 1`,
+	}
+	for _, line := range expectedLines {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.Contains(t, body, "dns_blocklist_last_update_timestamp_seconds ")
+}
+
+type statisticserFunc func(ctx context.Context) (stats unbound.Statistics, err error)
+
+func (f statisticserFunc) Statistics(ctx context.Context) (stats unbound.Statistics, err error) {
+	return f(ctx)
+}
+
+func Test_metrics_CollectUnbound(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		stats         unbound.Statistics
+		err           error
+		expectedLines []string
+	}{
+		"statistics error": {
+			err:           errors.New("dummy"),
+			expectedLines: []string{`dns_unbound_up 0`},
+		},
+		"statistics": {
+			stats: unbound.Statistics{
+				Queries:              12,
+				CacheHits:            9,
+				CacheMisses:          3,
+				RecursionTimeAverage: 50 * time.Millisecond,
+				Uptime:               2 * time.Minute,
+				QueriesByType:        map[string]uint64{"A": 8},
+				AnswersByRcode:       map[string]uint64{"NXDOMAIN": 1},
+				MemoryBytes:          map[string]uint64{"cache.rrset": 66488},
+			},
+			expectedLines: []string{
+				`dns_unbound_up 1`,
+				`dns_unbound_queries_total 12`,
+				`dns_unbound_cache_hits_total 9`,
+				`dns_unbound_cache_misses_total 3`,
+				`dns_unbound_prefetches_total 0`,
+				`dns_unbound_recursion_time_average_seconds 0.05`,
+				`dns_unbound_uptime_seconds 120`,
+				`dns_unbound_queries_by_type_total{type="A"} 8`,
+				`dns_unbound_answers_by_rcode_total{rcode="NXDOMAIN"} 1`,
+				`dns_unbound_memory_bytes{kind="cache.rrset"} 66488`,
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			metrics := New()
+			metrics.CollectUnbound(statisticserFunc(
+				func(ctx context.Context) (unbound.Statistics, error) {
+					return testCase.stats, testCase.err
+				}))
+
+			body := scrape(t, metrics)
+
+			for _, line := range testCase.expectedLines {
+				assert.Contains(t, body, line+"\n")
+			}
+		})
+	}
+}
+
+type cacheStatserFunc func() (stats stats.Stats, enabled bool)
+
+func (f cacheStatserFunc) CacheStats() (stats stats.Stats, enabled bool) {
+	return f()
+}
+
+func Test_metrics_CollectCache(t *testing.T) {
+	t.Parallel()
+
+	metrics := New()
+	metrics.CollectCache("dot", cacheStatserFunc(func() (stats.Stats, bool) {
+		return stats.Stats{}, false
+	}))
+	metrics.CollectCache("doh", cacheStatserFunc(func() (stats.Stats, bool) {
+		return stats.Stats{Evictions: 1}, true
+	}))
+
+	body := scrape(t, metrics)
+	assert.NotContains(t, body, `dns_cache_entries{server="dot"}`)
+	assert.Contains(t, body, `dns_cache_evictions_total{server="doh"} 1`+"\n")
+
+	// Replace the cache collector of the doh server
+	metrics.CollectCache("doh", cacheStatserFunc(func() (stats.Stats, bool) {
+		return stats.Stats{Evictions: 2, Expired: 3, Entries: 4, Bytes: 500}, true
+	}))
+
+	body = scrape(t, metrics)
+	expectedLines := []string{
+		`dns_cache_evictions_total{server="doh"} 2`,
+		`dns_cache_expired_total{server="doh"} 3`,
+		`dns_cache_entries{server="doh"} 4`,
+		`dns_cache_bytes{server="doh"} 500`,
+	}
+	for _, line := range expectedLines {
+		assert.Contains(t, body, line+"\n")
+	}
+}
+
+func scrape(t *testing.T, metrics Metrics) (body string) {
+	t.Helper()
+
+	server := httptest.NewServer(metrics.Handler())
+	t.Cleanup(server.Close)
+
+	response, err := http.Get(server.URL) //nolint:noctx
+	require.NoError(t, err)
+	defer response.Body.Close()
+	b, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,195 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/metrics (interfaces: Metrics)
+
+// Package mock_metrics is a generated GoMock package.
+package mock_metrics
+
+import (
+	http "net/http"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	metrics "github.com/qdm12/dns/pkg/metrics"
+)
+
+// MockMetrics is a mock of Metrics interface.
+type MockMetrics struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricsMockRecorder
+}
+
+// MockMetricsMockRecorder is the mock recorder for MockMetrics.
+type MockMetricsMockRecorder struct {
+	mock *MockMetrics
+}
+
+// NewMockMetrics creates a new mock instance.
+func NewMockMetrics(ctrl *gomock.Controller) *MockMetrics {
+	mock := &MockMetrics{ctrl: ctrl}
+	mock.recorder = &MockMetricsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
+	return m.recorder
+}
+
+// BlockLists mocks base method.
+func (m *MockMetrics) BlockLists(arg0, arg1, arg2 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BlockLists", arg0, arg1, arg2)
+}
+
+// BlockLists indicates an expected call of BlockLists.
+func (mr *MockMetricsMockRecorder) BlockLists(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockLists", reflect.TypeOf((*MockMetrics)(nil).BlockLists), arg0, arg1, arg2)
+}
+
+// Blocked mocks base method.
+func (m *MockMetrics) Blocked(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Blocked", arg0, arg1)
+}
+
+// Blocked indicates an expected call of Blocked.
+func (mr *MockMetricsMockRecorder) Blocked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Blocked", reflect.TypeOf((*MockMetrics)(nil).Blocked), arg0, arg1)
+}
+
+// CacheHit mocks base method.
+func (m *MockMetrics) CacheHit(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CacheHit", arg0)
+}
+
+// CacheHit indicates an expected call of CacheHit.
+func (mr *MockMetricsMockRecorder) CacheHit(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheHit", reflect.TypeOf((*MockMetrics)(nil).CacheHit), arg0)
+}
+
+// CacheMiss mocks base method.
+func (m *MockMetrics) CacheMiss(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CacheMiss", arg0)
+}
+
+// CacheMiss indicates an expected call of CacheMiss.
+func (mr *MockMetricsMockRecorder) CacheMiss(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CacheMiss", reflect.TypeOf((*MockMetrics)(nil).CacheMiss), arg0)
+}
+
+// CollectCache mocks base method.
+func (m *MockMetrics) CollectCache(arg0 string, arg1 metrics.CacheStatser) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CollectCache", arg0, arg1)
+}
+
+// CollectCache indicates an expected call of CollectCache.
+func (mr *MockMetricsMockRecorder) CollectCache(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectCache", reflect.TypeOf((*MockMetrics)(nil).CollectCache), arg0, arg1)
+}
+
+// CollectUnbound mocks base method.
+func (m *MockMetrics) CollectUnbound(arg0 metrics.UnboundStatisticser) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CollectUnbound", arg0)
+}
+
+// CollectUnbound indicates an expected call of CollectUnbound.
+func (mr *MockMetricsMockRecorder) CollectUnbound(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectUnbound", reflect.TypeOf((*MockMetrics)(nil).CollectUnbound), arg0)
+}
+
+// Handler mocks base method.
+func (m *MockMetrics) Handler() http.Handler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handler")
+	ret0, _ := ret[0].(http.Handler)
+	return ret0
+}
+
+// Handler indicates an expected call of Handler.
+func (mr *MockMetricsMockRecorder) Handler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handler", reflect.TypeOf((*MockMetrics)(nil).Handler))
+}
+
+// MonitoredMatch mocks base method.
+func (m *MockMetrics) MonitoredMatch(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MonitoredMatch", arg0, arg1)
+}
+
+// MonitoredMatch indicates an expected call of MonitoredMatch.
+func (mr *MockMetricsMockRecorder) MonitoredMatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MonitoredMatch", reflect.TypeOf((*MockMetrics)(nil).MonitoredMatch), arg0, arg1)
+}
+
+// Query mocks base method.
+func (m *MockMetrics) Query(arg0, arg1, arg2 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Query", arg0, arg1, arg2)
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockMetricsMockRecorder) Query(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*MockMetrics)(nil).Query), arg0, arg1, arg2)
+}
+
+// UnboundCrash mocks base method.
+func (m *MockMetrics) UnboundCrash() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnboundCrash")
+}
+
+// UnboundCrash indicates an expected call of UnboundCrash.
+func (mr *MockMetricsMockRecorder) UnboundCrash() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnboundCrash", reflect.TypeOf((*MockMetrics)(nil).UnboundCrash))
+}
+
+// UnboundRestart mocks base method.
+func (m *MockMetrics) UnboundRestart(arg0 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UnboundRestart", arg0)
+}
+
+// UnboundRestart indicates an expected call of UnboundRestart.
+func (mr *MockMetricsMockRecorder) UnboundRestart(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnboundRestart", reflect.TypeOf((*MockMetrics)(nil).UnboundRestart), arg0)
+}
+
+// UpstreamError mocks base method.
+func (m *MockMetrics) UpstreamError(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpstreamError", arg0, arg1)
+}
+
+// UpstreamError indicates an expected call of UpstreamError.
+func (mr *MockMetricsMockRecorder) UpstreamError(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpstreamError", reflect.TypeOf((*MockMetrics)(nil).UpstreamError), arg0, arg1)
+}
+
+// UpstreamLatency mocks base method.
+func (m *MockMetrics) UpstreamLatency(arg0, arg1 string, arg2 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpstreamLatency", arg0, arg1, arg2)
+}
+
+// UpstreamLatency indicates an expected call of UpstreamLatency.
+func (mr *MockMetricsMockRecorder) UpstreamLatency(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpstreamLatency", reflect.TypeOf((*MockMetrics)(nil).UpstreamLatency), arg0, arg1, arg2)
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+)
+
+type noop struct{}
+
+// NewNoop creates metrics which are not recorded,
+// to be used when metrics are disabled.
+func NewNoop() Metrics {
+	return noop{}
+}
+
+func (noop) Query(string, string, string)                  {}
+func (noop) CacheHit(string)                               {}
+func (noop) CacheMiss(string)                              {}
+func (noop) Blocked(string, string)                        {}
+func (noop) MonitoredMatch(string, string)                 {}
+func (noop) UpstreamLatency(string, string, time.Duration) {}
+func (noop) UpstreamError(string, string)                  {}
+func (noop) BlockLists(int, int, int)                      {}
+func (noop) UnboundRestart(string)                         {}
+func (noop) UnboundCrash()                                 {}
+func (noop) CollectUnbound(UnboundStatisticser)            {}
+func (noop) CollectCache(string, CacheStatser)             {}
+func (noop) Handler() http.Handler                         { return http.NotFoundHandler() }
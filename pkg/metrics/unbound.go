@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/qdm12/dns/pkg/unbound"
+)
+
+type UnboundStatisticser interface {
+	Statistics(ctx context.Context) (stats unbound.Statistics, err error)
+}
+
+// unboundCollector collects the Unbound statistics
+// each time the metrics are scraped.
+type unboundCollector struct {
+	statisticser UnboundStatisticser
+	timeout      time.Duration
+
+	up                *prometheus.Desc
+	queries           *prometheus.Desc
+	cacheHits         *prometheus.Desc
+	cacheMisses       *prometheus.Desc
+	prefetches        *prometheus.Desc
+	expiredAnswers    *prometheus.Desc
+	recursionAverage  *prometheus.Desc
+	recursionMedian   *prometheus.Desc
+	uptime            *prometheus.Desc
+	queriesByType     *prometheus.Desc
+	answersByRcode    *prometheus.Desc
+	memoryBytesByKind *prometheus.Desc
+}
+
+func newUnboundCollector(statisticser UnboundStatisticser) *unboundCollector {
+	const subsystem = "unbound"
+	newDesc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name),
+			help, labels, nil)
+	}
+
+	const timeout = 5 * time.Second
+	return &unboundCollector{
+		statisticser: statisticser,
+		timeout:      timeout,
+		up: newDesc("up",
+			"1 if the Unbound statistics could be obtained, 0 otherwise."),
+		queries: newDesc("queries_total",
+			"Number of queries received by Unbound."),
+		cacheHits: newDesc("cache_hits_total",
+			"Number of queries answered from the Unbound cache."),
+		cacheMisses: newDesc("cache_misses_total",
+			"Number of queries needing recursion in Unbound."),
+		prefetches: newDesc("prefetches_total",
+			"Number of cache prefetches performed by Unbound."),
+		expiredAnswers: newDesc("expired_answers_total",
+			"Number of expired answers served by Unbound."),
+		recursionAverage: newDesc("recursion_time_average_seconds",
+			"Average time to answer queries needing recursion."),
+		recursionMedian: newDesc("recursion_time_median_seconds",
+			"Median time to answer queries needing recursion."),
+		uptime: newDesc("uptime_seconds",
+			"Duration since Unbound started."),
+		queriesByType: newDesc("queries_by_type_total",
+			"Number of queries received by Unbound, by query type.", "type"),
+		answersByRcode: newDesc("answers_by_rcode_total",
+			"Number of answers sent by Unbound, by response code.", "rcode"),
+		memoryBytesByKind: newDesc("memory_bytes",
+			"Memory used by Unbound in bytes, by kind.", "kind"),
+	}
+}
+
+func (c *unboundCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.up
+	descs <- c.queries
+	descs <- c.cacheHits
+	descs <- c.cacheMisses
+	descs <- c.prefetches
+	descs <- c.expiredAnswers
+	descs <- c.recursionAverage
+	descs <- c.recursionMedian
+	descs <- c.uptime
+	descs <- c.queriesByType
+	descs <- c.answersByRcode
+	descs <- c.memoryBytesByKind
+}
+
+func (c *unboundCollector) Collect(metrics chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	stats, err := c.statisticser.Statistics(ctx)
+	if err != nil {
+		// Unbound is restarting or down, only report it is down
+		metrics <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+	metrics <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+
+	counters := map[*prometheus.Desc]uint64{
+		c.queries:        stats.Queries,
+		c.cacheHits:      stats.CacheHits,
+		c.cacheMisses:    stats.CacheMisses,
+		c.prefetches:     stats.Prefetches,
+		c.expiredAnswers: stats.ExpiredAnswers,
+	}
+	for desc, value := range counters {
+		metrics <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value))
+	}
+
+	durations := map[*prometheus.Desc]time.Duration{
+		c.recursionAverage: stats.RecursionTimeAverage,
+		c.recursionMedian:  stats.RecursionTimeMedian,
+		c.uptime:           stats.Uptime,
+	}
+	for desc, value := range durations {
+		metrics <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value.Seconds())
+	}
+
+	for queryType, value := range stats.QueriesByType {
+		metrics <- prometheus.MustNewConstMetric(c.queriesByType,
+			prometheus.CounterValue, float64(value), queryType)
+	}
+	for rcode, value := range stats.AnswersByRcode {
+		metrics <- prometheus.MustNewConstMetric(c.answersByRcode,
+			prometheus.CounterValue, float64(value), rcode)
+	}
+	for kind, value := range stats.MemoryBytes {
+		metrics <- prometheus.MustNewConstMetric(c.memoryBytesByKind,
+			prometheus.GaugeValue, float64(value), kind)
+	}
+}
@@ -0,0 +1,93 @@
+package querylog
+
+import (
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+	"inet.af/netaddr"
+)
+
+// Entry is the query log entry for a single DNS query.
+type Entry struct {
+	Time      time.Time     `json:"time"`
+	ClientIP  netaddr.IP    `json:"client_ip"`
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`
+	Rcode     string        `json:"rcode"`
+	AnswerIPs []netaddr.IP  `json:"answer_ips,omitempty"`
+	Upstream  string        `json:"upstream,omitempty"`
+	Latency   time.Duration `json:"latency_ns"`
+	Cached    bool          `json:"cached"`
+	Blocked   bool          `json:"blocked"`
+	// BlockedCategory is the category of the block list
+	// blocking the query, and is empty if it is not blocked.
+	BlockedCategory string `json:"blocked_category,omitempty"`
+	// BlockedRule is the blocked hostname, IP address or IP network
+	// matched by the query, and is empty if it is not blocked.
+	BlockedRule string `json:"blocked_rule,omitempty"`
+}
+
+// NewEntry creates an entry for the request and the response,
+// which was written back to the client address given. The
+// latency is the duration elapsed since the start time given.
+func NewEntry(start time.Time, clientAddr net.Addr,
+	request, response *dns.Msg) (entry Entry) {
+	entry = Entry{
+		Time:     start,
+		ClientIP: addrIP(clientAddr),
+		Rcode:    dns.RcodeToString[response.Rcode],
+		Latency:  time.Since(start),
+	}
+
+	if len(request.Question) > 0 {
+		entry.Name = request.Question[0].Name
+		entry.Type = dns.TypeToString[request.Question[0].Qtype]
+	}
+
+	for _, rr := range response.Answer {
+		var ip net.IP
+		switch record := rr.(type) {
+		case *dns.A:
+			ip = record.A
+		case *dns.AAAA:
+			ip = record.AAAA
+		default:
+			continue
+		}
+		if netaddrIP, ok := netaddr.FromStdIP(ip); ok {
+			entry.AnswerIPs = append(entry.AnswerIPs, netaddrIP)
+		}
+	}
+
+	return entry
+}
+
+func addrIP(addr net.Addr) (ip netaddr.IP) {
+	switch typedAddr := addr.(type) {
+	case *net.UDPAddr:
+		ip, _ = netaddr.FromStdIP(typedAddr.IP)
+	case *net.TCPAddr:
+		ip, _ = netaddr.FromStdIP(typedAddr.IP)
+	}
+	return ip
+}
+
+// anonymize zeroes the last byte of IPv4 addresses
+// and all but the first 48 bits of IPv6 addresses.
+func anonymize(ip netaddr.IP) netaddr.IP {
+	switch {
+	case ip.IsZero():
+		return ip
+	case ip.Is4():
+		b := ip.As4()
+		return netaddr.IPv4(b[0], b[1], b[2], 0)
+	default:
+		b := ip.As16()
+		const keepBytes = 6
+		for i := keepBytes; i < len(b); i++ {
+			b[i] = 0
+		}
+		return netaddr.IPv6Raw(b)
+	}
+}
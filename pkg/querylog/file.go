@@ -0,0 +1,114 @@
+package querylog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+type file struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+	mutex      sync.Mutex
+}
+
+// NewFile creates a sink writing entries as JSON lines to the file at
+// the path given, appending to it if it already exists. Once writing
+// an entry would make the file larger than maxBytes, the file is
+// renamed to path.1, path.1 to path.2 and so on, and only maxBackups
+// of these rotated files are kept.
+func NewFile(path string, maxBytes int64, maxBackups int) (sink Sink, err error) {
+	f := &file{
+		path:       path,
+		maxBytes:   maxBytes,
+		maxBackups: maxBackups,
+	}
+
+	err = f.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *file) open() (err error) {
+	const perms = 0600
+	f.file, err = os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, perms)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.file.Stat()
+	if err != nil {
+		_ = f.file.Close()
+		return err
+	}
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *file) Write(entry Entry) (err error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.size > 0 && f.size+int64(len(line)) > f.maxBytes {
+		err = f.rotate()
+		if err != nil {
+			return fmt.Errorf("cannot rotate query log file: %w", err)
+		}
+	}
+
+	n, err := f.file.Write(line)
+	f.size += int64(n)
+	return err
+}
+
+func (f *file) rotate() (err error) {
+	err = f.file.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(f.backupPath(f.maxBackups))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for i := f.maxBackups - 1; i >= 0; i-- {
+		err = os.Rename(f.backupPath(i), f.backupPath(i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return f.open()
+}
+
+// backupPath returns the path of the i-th rotated file,
+// or the path of the current file if i is 0.
+func (f *file) backupPath(i int) string {
+	if i == 0 {
+		return f.path
+	}
+	return f.path + "." + strconv.Itoa(i)
+}
+
+func (f *file) Close() (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.file.Close()
+}
@@ -0,0 +1,77 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/qdm12/dns/pkg/querylog (interfaces: Logger)
+
+// Package mock_querylog is a generated GoMock package.
+package mock_querylog
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	querylog "github.com/qdm12/dns/pkg/querylog"
+)
+
+// MockLogger is a mock of Logger interface.
+type MockLogger struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoggerMockRecorder
+}
+
+// MockLoggerMockRecorder is the mock recorder for MockLogger.
+type MockLoggerMockRecorder struct {
+	mock *MockLogger
+}
+
+// NewMockLogger creates a new mock instance.
+func NewMockLogger(ctrl *gomock.Controller) *MockLogger {
+	mock := &MockLogger{ctrl: ctrl}
+	mock.recorder = &MockLoggerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLogger) EXPECT() *MockLoggerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockLogger) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockLoggerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockLogger)(nil).Close))
+}
+
+// Entries mocks base method.
+func (m *MockLogger) Entries(arg0 querylog.Filter) []querylog.Entry {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Entries", arg0)
+	ret0, _ := ret[0].([]querylog.Entry)
+	return ret0
+}
+
+// Entries indicates an expected call of Entries.
+func (mr *MockLoggerMockRecorder) Entries(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Entries", reflect.TypeOf((*MockLogger)(nil).Entries), arg0)
+}
+
+// Log mocks base method.
+func (m *MockLogger) Log(arg0 querylog.Entry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Log", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Log indicates an expected call of Log.
+func (mr *MockLoggerMockRecorder) Log(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Log", reflect.TypeOf((*MockLogger)(nil).Log), arg0)
+}
@@ -0,0 +1,98 @@
+// Package querylog records a structured log entry for each DNS
+// query, and writes it to one or more sinks.
+package querylog
+
+import (
+	"fmt"
+	"os"
+)
+
+//go:generate mockgen -destination=mock_$GOPACKAGE/$GOFILE . Logger
+
+type Logger interface {
+	Log(entry Entry) (err error)
+	Entries(filter Filter) (entries []Entry)
+	Close() (err error)
+}
+
+// Sink is a destination for query log entries.
+type Sink interface {
+	Write(entry Entry) (err error)
+	Close() (err error)
+}
+
+type logger struct {
+	sinks     []Sink
+	ring      *Ring
+	anonymize bool
+}
+
+// New creates a query logger writing to the sinks set in the settings.
+// It returns a nil Logger if no sink is set.
+func New(settings Settings) (l Logger, err error) {
+	settings.SetDefaults()
+	if !settings.Enabled() {
+		return nil, nil //nolint:nilnil
+	}
+
+	queryLogger := &logger{
+		anonymize: settings.AnonymizeIPs,
+	}
+
+	if settings.Stdout {
+		queryLogger.sinks = append(queryLogger.sinks, NewWriter(os.Stdout))
+	}
+
+	if settings.File != "" {
+		file, err := NewFile(settings.File, settings.FileMaxBytes, settings.FileMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create query log file: %w", err)
+		}
+		queryLogger.sinks = append(queryLogger.sinks, file)
+	}
+
+	if settings.RingSize > 0 {
+		queryLogger.ring = NewRing(settings.RingSize)
+		queryLogger.sinks = append(queryLogger.sinks, queryLogger.ring)
+	}
+
+	return queryLogger, nil
+}
+
+// Log writes the entry to all the sinks, anonymizing its client
+// IP address first if set in the settings. It returns the first
+// error encountered, and still writes to the other sinks.
+func (l *logger) Log(entry Entry) (err error) {
+	if l.anonymize {
+		entry.ClientIP = anonymize(entry.ClientIP)
+	}
+
+	for _, sink := range l.sinks {
+		sinkErr := sink.Write(entry)
+		if sinkErr != nil && err == nil {
+			err = sinkErr
+		}
+	}
+	return err
+}
+
+// Entries returns the entries kept in memory matching the filter,
+// most recent first. It returns no entry if queries are not kept
+// in memory.
+func (l *logger) Entries(filter Filter) (entries []Entry) {
+	if l.ring == nil {
+		return nil
+	}
+	return l.ring.Entries(filter)
+}
+
+// Close closes all the sinks and returns the first error encountered.
+func (l *logger) Close() (err error) {
+	for _, sink := range l.sinks {
+		sinkErr := sink.Close()
+		if sinkErr != nil && err == nil {
+			err = sinkErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package querylog
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"inet.af/netaddr"
+)
+
+func Test_NewEntry(t *testing.T) {
+	t.Parallel()
+
+	request := new(dns.Msg).SetQuestion("github.com.", dns.TypeA)
+	response := new(dns.Msg).SetReply(request)
+	response.Answer = []dns.RR{
+		&dns.CNAME{
+			Hdr:    dns.RR_Header{Name: "github.com.", Rrtype: dns.TypeCNAME, Class: dns.ClassINET},
+			Target: "lb.github.com.",
+		},
+		&dns.A{
+			Hdr: dns.RR_Header{Name: "lb.github.com.", Rrtype: dns.TypeA, Class: dns.ClassINET},
+			A:   net.IP{140, 82, 121, 4},
+		},
+	}
+	start := time.Now()
+	clientAddr := &net.UDPAddr{IP: net.IP{192, 168, 1, 2}, Port: 5353}
+
+	entry := NewEntry(start, clientAddr, request, response)
+
+	assert.Equal(t, start, entry.Time)
+	assert.Equal(t, netaddr.IPv4(192, 168, 1, 2), entry.ClientIP)
+	assert.Equal(t, "github.com.", entry.Name)
+	assert.Equal(t, "A", entry.Type)
+	assert.Equal(t, "NOERROR", entry.Rcode)
+	assert.Equal(t, []netaddr.IP{netaddr.IPv4(140, 82, 121, 4)}, entry.AnswerIPs)
+	assert.GreaterOrEqual(t, int64(entry.Latency), int64(0))
+}
+
+func Test_anonymize(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		ip         netaddr.IP
+		anonymized netaddr.IP
+	}{
+		"zero": {},
+		"ipv4": {
+			ip:         netaddr.IPv4(192, 168, 1, 2),
+			anonymized: netaddr.IPv4(192, 168, 1, 0),
+		},
+		"ipv6": {
+			ip:         netaddr.MustParseIP("2001:db8:1:2:3:4:5:6"),
+			anonymized: netaddr.MustParseIP("2001:db8:1::"),
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			anonymized := anonymize(testCase.ip)
+			assert.Equal(t, testCase.anonymized, anonymized)
+		})
+	}
+}
+
+func Test_Ring(t *testing.T) {
+	t.Parallel()
+
+	ring := NewRing(3)
+	names := []string{"a.com.", "b.com.", "c.org.", "d.com."}
+	for i, name := range names {
+		entry := Entry{
+			ClientIP: netaddr.IPv4(10, 0, 0, byte(i%2)),
+			Name:     name,
+			Blocked:  name == "c.org.",
+		}
+		err := ring.Write(entry)
+		require.NoError(t, err)
+	}
+
+	entryNames := func(entries []Entry) (names []string) {
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+
+	entries := ring.Entries(Filter{})
+	assert.Equal(t, []string{"d.com.", "c.org.", "b.com."}, entryNames(entries))
+
+	entries = ring.Entries(Filter{Name: "COM", Limit: 1})
+	assert.Equal(t, []string{"d.com."}, entryNames(entries))
+
+	entries = ring.Entries(Filter{ClientIP: netaddr.IPv4(10, 0, 0, 0)})
+	assert.Equal(t, []string{"c.org."}, entryNames(entries))
+
+	entries = ring.Entries(Filter{BlockedOnly: true})
+	assert.Equal(t, []string{"c.org."}, entryNames(entries))
+}
+
+func Test_file(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "queries.log")
+	const maxBackups = 2
+	entry := Entry{Name: "github.com.", Type: "A", Rcode: "NOERROR"}
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+	maxBytes := int64(2 * (len(line) + 1)) // 2 entries per file
+
+	sink, err := NewFile(path, maxBytes, maxBackups)
+	require.NoError(t, err)
+
+	const entries = 7
+	for i := 0; i < entries; i++ {
+		err = sink.Write(entry)
+		require.NoError(t, err)
+	}
+	err = sink.Close()
+	require.NoError(t, err)
+
+	countLines := func(path string) (count int) {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			var decoded Entry
+			err := json.Unmarshal(scanner.Bytes(), &decoded)
+			require.NoError(t, err)
+			assert.Equal(t, entry, decoded)
+			count++
+		}
+		require.NoError(t, scanner.Err())
+		return count
+	}
+
+	assert.Equal(t, 1, countLines(path))
+	assert.Equal(t, 2, countLines(path+".1"))
+	assert.Equal(t, 2, countLines(path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,87 @@
+package querylog
+
+import (
+	"strings"
+	"sync"
+
+	"inet.af/netaddr"
+)
+
+// Ring is a sink keeping the most recent entries in memory.
+type Ring struct {
+	entries []Entry
+	next    int // index to write the next entry to
+	full    bool
+	mutex   sync.RWMutex
+}
+
+// NewRing creates a sink keeping the last size entries in memory.
+func NewRing(size int) *Ring {
+	return &Ring{
+		entries: make([]Entry, size),
+	}
+}
+
+func (r *Ring) Write(entry Entry) (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.entries[r.next] = entry
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.full = true
+	}
+	return nil
+}
+
+func (r *Ring) Close() (err error) { return nil }
+
+// Filter selects query log entries. Its zero value matches all entries.
+type Filter struct {
+	// ClientIP matches entries for this client IP address only.
+	ClientIP netaddr.IP
+	// Name matches entries with a query name containing it,
+	// case insensitively.
+	Name string
+	// BlockedOnly matches blocked queries only.
+	BlockedOnly bool
+	// Limit is the maximum number of entries to return,
+	// and 0 means there is no limit.
+	Limit int
+}
+
+func (f *Filter) match(entry Entry) bool {
+	return (f.ClientIP.IsZero() || f.ClientIP == entry.ClientIP) &&
+		(f.Name == "" || strings.Contains(strings.ToLower(entry.Name), strings.ToLower(f.Name))) &&
+		(!f.BlockedOnly || entry.Blocked)
+}
+
+// Entries returns the entries matching the filter, most recent first.
+func (r *Ring) Entries(filter Filter) (entries []Entry) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+
+	for i := 0; i < count; i++ {
+		index := r.next - 1 - i
+		if index < 0 {
+			index += len(r.entries)
+		}
+
+		entry := r.entries[index]
+		if !filter.match(entry) {
+			continue
+		}
+
+		entries = append(entries, entry)
+		if len(entries) == filter.Limit {
+			break
+		}
+	}
+
+	return entries
+}
@@ -0,0 +1,87 @@
+package querylog
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Settings struct {
+	// Stdout logs queries as JSON lines to the standard output.
+	// It defaults to false.
+	Stdout bool
+	// File is the path of the file to log queries to as JSON lines.
+	// It defaults to the empty string, meaning no file is written.
+	File string
+	// FileMaxBytes is the size in bytes after which the query log
+	// file is rotated. It defaults to 10MB.
+	FileMaxBytes int64
+	// FileMaxBackups is the number of rotated query log files
+	// to keep. It defaults to 3.
+	FileMaxBackups int
+	// RingSize is the number of most recent queries kept in memory
+	// to be searched. It defaults to 0, meaning queries are not
+	// kept in memory.
+	RingSize int
+	// AnonymizeIPs zeroes the last byte of IPv4 client addresses
+	// and all but the first 48 bits of IPv6 client addresses.
+	// It defaults to false.
+	AnonymizeIPs bool
+}
+
+func (s *Settings) SetDefaults() {
+	if s.FileMaxBytes == 0 {
+		const defaultFileMaxBytes = 10 * 1000 * 1000
+		s.FileMaxBytes = defaultFileMaxBytes
+	}
+
+	if s.FileMaxBackups == 0 {
+		const defaultFileMaxBackups = 3
+		s.FileMaxBackups = defaultFileMaxBackups
+	}
+}
+
+// Enabled returns true if at least one query log sink is set.
+func (s *Settings) Enabled() bool {
+	return s.Stdout || s.File != "" || s.RingSize > 0
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled() {
+		return []string{subSection + "Query log: disabled"}
+	}
+
+	lines = append(lines, subSection+"Query log:")
+
+	if s.Stdout {
+		lines = append(lines, indent+subSection+"Standard output: enabled")
+	}
+
+	if s.File != "" {
+		lines = append(lines, indent+subSection+"File: "+s.File)
+		lines = append(lines, indent+subSection+"File max size: "+
+			strconv.FormatInt(s.FileMaxBytes, 10)+" bytes")
+		lines = append(lines, indent+subSection+"File max backups: "+
+			strconv.Itoa(s.FileMaxBackups))
+	}
+
+	if s.RingSize > 0 {
+		lines = append(lines, indent+subSection+"In memory queries: "+
+			strconv.Itoa(s.RingSize))
+	}
+
+	anonymize := "disabled"
+	if s.AnonymizeIPs {
+		anonymize = "enabled"
+	}
+	lines = append(lines, indent+subSection+"Anonymize client IPs: "+anonymize)
+
+	return lines
+}
@@ -0,0 +1,138 @@
+package querylog
+
+import (
+	"sort"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Summary summarizes query log entries, for example for a dashboard.
+type Summary struct {
+	Queries int `json:"queries"`
+	Blocked int `json:"blocked"`
+	// QueriesPerMinute contains the number of queries and blocked
+	// queries for each of the last minutes, oldest first.
+	QueriesPerMinute []MinuteCount `json:"queries_per_minute"`
+	TopDomains       []Count       `json:"top_domains"`
+	TopBlocked       []Count       `json:"top_blocked"`
+	TopClients       []Count       `json:"top_clients"`
+	// CacheHitRatio is the ratio of queries answered
+	// from the cache, between 0 and 1.
+	CacheHitRatio float64           `json:"cache_hit_ratio"`
+	Upstreams     []UpstreamSummary `json:"upstreams"`
+}
+
+type MinuteCount struct {
+	Time    time.Time `json:"time"`
+	Queries int       `json:"queries"`
+	Blocked int       `json:"blocked"`
+}
+
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// UpstreamSummary summarizes the queries sent to an upstream,
+// where failures are queries answered with SERVFAIL.
+type UpstreamSummary struct {
+	Upstream       string        `json:"upstream"`
+	Queries        int           `json:"queries"`
+	Failures       int           `json:"failures"`
+	AverageLatency time.Duration `json:"average_latency_ns"`
+}
+
+// Summarize summarizes the entries given, with the queries per minute
+// for the last minutes before now, and at most top items in each top.
+func Summarize(entries []Entry, now time.Time, minutes, top int) (summary Summary) {
+	summary.QueriesPerMinute = make([]MinuteCount, minutes)
+	currentMinute := now.Truncate(time.Minute)
+	for i := range summary.QueriesPerMinute {
+		offset := time.Duration(minutes-1-i) * time.Minute
+		summary.QueriesPerMinute[i].Time = currentMinute.Add(-offset)
+	}
+
+	domains := make(map[string]int)
+	blockedDomains := make(map[string]int)
+	clients := make(map[string]int)
+	upstreams := make(map[string]*UpstreamSummary)
+	upstreamLatencies := make(map[string]time.Duration)
+	cached := 0
+
+	for _, entry := range entries {
+		summary.Queries++
+		domains[entry.Name]++
+		if !entry.ClientIP.IsZero() {
+			clients[entry.ClientIP.String()]++
+		}
+
+		minutesAgo := int(currentMinute.Sub(entry.Time.Truncate(time.Minute)) / time.Minute)
+		inWindow := minutesAgo >= 0 && minutesAgo < minutes
+		if inWindow {
+			summary.QueriesPerMinute[minutes-1-minutesAgo].Queries++
+		}
+
+		switch {
+		case entry.Blocked:
+			summary.Blocked++
+			blockedDomains[entry.Name]++
+			if inWindow {
+				summary.QueriesPerMinute[minutes-1-minutesAgo].Blocked++
+			}
+		case entry.Cached:
+			cached++
+		case entry.Upstream != "":
+			upstream, ok := upstreams[entry.Upstream]
+			if !ok {
+				upstream = &UpstreamSummary{Upstream: entry.Upstream}
+				upstreams[entry.Upstream] = upstream
+			}
+			upstream.Queries++
+			if entry.Rcode == dns.RcodeToString[dns.RcodeServerFailure] {
+				upstream.Failures++
+			}
+			upstreamLatencies[entry.Upstream] += entry.Latency
+		}
+	}
+
+	if summary.Queries > 0 {
+		summary.CacheHitRatio = float64(cached) / float64(summary.Queries)
+	}
+
+	summary.TopDomains = topCounts(domains, top)
+	summary.TopBlocked = topCounts(blockedDomains, top)
+	summary.TopClients = topCounts(clients, top)
+
+	summary.Upstreams = make([]UpstreamSummary, 0, len(upstreams))
+	for name, upstream := range upstreams {
+		upstream.AverageLatency = upstreamLatencies[name] / time.Duration(upstream.Queries)
+		summary.Upstreams = append(summary.Upstreams, *upstream)
+	}
+	sort.Slice(summary.Upstreams, func(i, j int) bool {
+		return summary.Upstreams[i].Upstream < summary.Upstreams[j].Upstream
+	})
+
+	return summary
+}
+
+// topCounts returns at most top counts, sorted by decreasing
+// count and then by name.
+func topCounts(nameToCount map[string]int, top int) (counts []Count) {
+	counts = make([]Count, 0, len(nameToCount))
+	for name, count := range nameToCount {
+		counts = append(counts, Count{Name: name, Count: count})
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Name < counts[j].Name
+	})
+
+	if len(counts) > top {
+		counts = counts[:top]
+	}
+	return counts
+}
@@ -0,0 +1,49 @@
+package querylog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_Summarize(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2021, 7, 22, 10, 30, 15, 0, time.UTC)
+	clientA := netaddr.IPv4(10, 0, 0, 1)
+	clientB := netaddr.IPv4(10, 0, 0, 2)
+	entries := []Entry{
+		{Time: now, ClientIP: clientA, Name: "a.com.", Rcode: "NOERROR", Cached: true},
+		{Time: now, ClientIP: clientA, Name: "ads.com.", Rcode: "REFUSED", Blocked: true},
+		{Time: now.Add(-time.Minute), ClientIP: clientB, Name: "a.com.", Rcode: "NOERROR",
+			Upstream: "cloudflare", Latency: 10 * time.Millisecond},
+		{Time: now.Add(-time.Minute), ClientIP: clientA, Name: "b.com.", Rcode: "SERVFAIL",
+			Upstream: "cloudflare", Latency: 30 * time.Millisecond},
+		{Time: now.Add(-time.Hour), ClientIP: clientA, Name: "ads.com.", Rcode: "REFUSED", Blocked: true},
+	}
+
+	summary := Summarize(entries, now, 3, 1)
+
+	expected := Summary{
+		Queries: 5,
+		Blocked: 2,
+		QueriesPerMinute: []MinuteCount{
+			{Time: time.Date(2021, 7, 22, 10, 28, 0, 0, time.UTC)},
+			{Time: time.Date(2021, 7, 22, 10, 29, 0, 0, time.UTC), Queries: 2},
+			{Time: time.Date(2021, 7, 22, 10, 30, 0, 0, time.UTC), Queries: 2, Blocked: 1},
+		},
+		TopDomains:    []Count{{Name: "a.com.", Count: 2}},
+		TopBlocked:    []Count{{Name: "ads.com.", Count: 2}},
+		TopClients:    []Count{{Name: "10.0.0.1", Count: 4}},
+		CacheHitRatio: 0.2,
+		Upstreams: []UpstreamSummary{{
+			Upstream:       "cloudflare",
+			Queries:        2,
+			Failures:       1,
+			AverageLatency: 20 * time.Millisecond,
+		}},
+	}
+	assert.Equal(t, expected, summary)
+}
@@ -0,0 +1,28 @@
+package querylog
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+type writer struct {
+	encoder *json.Encoder
+	mutex   sync.Mutex
+}
+
+// NewWriter creates a sink writing entries as JSON lines to the writer.
+// Closing the sink does not close the writer.
+func NewWriter(w io.Writer) Sink {
+	return &writer{
+		encoder: json.NewEncoder(w),
+	}
+}
+
+func (w *writer) Write(entry Entry) (err error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.encoder.Encode(entry)
+}
+
+func (w *writer) Close() (err error) { return nil }
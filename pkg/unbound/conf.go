@@ -10,17 +10,19 @@ import (
 )
 
 func (c *configurator) MakeUnboundConf(settings Settings) (err error) {
+	if settings.RemoteControl {
+		if err := c.setupControlKeys(); err != nil {
+			return fmt.Errorf("cannot setup remote control keys: %w", err)
+		}
+	}
+
 	configFilepath := filepath.Join(c.unboundEtcDir, unboundConfigFilename)
 	file, err := os.OpenFile(configFilepath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
 
-	blacklistLines := convertBlockedToConfigLines(settings.Blacklist)
-
-	lines := generateUnboundConf(settings, blacklistLines,
-		c.unboundEtcDir, c.cacertsPath, settings.Username)
-	_, err = file.WriteString(strings.Join(lines, "\n"))
+	_, err = file.WriteString(c.UnboundConf(settings))
 	if err != nil {
 		_ = file.Close()
 		return err
@@ -29,8 +31,19 @@ func (c *configurator) MakeUnboundConf(settings Settings) (err error) {
 	return file.Close()
 }
 
+// UnboundConf returns the Unbound configuration MakeUnboundConf
+// writes for the settings given, without writing any file.
+func (c *configurator) UnboundConf(settings Settings) (conf string) {
+	blacklistLines := convertBlockedToConfigLines(settings.Blacklist)
+	localNamesLines := convertLocalNamesToConfigLines(settings.LocalDomain, settings.LocalNames)
+
+	lines := generateUnboundConf(settings, blacklistLines, localNamesLines,
+		c.unboundEtcDir, c.cacertsPath, settings.Username)
+	return strings.Join(lines, "\n")
+}
+
 // generateUnboundConf generates an Unbound configuration from the user provided settings.
-func generateUnboundConf(settings Settings, blacklistLines []string,
+func generateUnboundConf(settings Settings, blacklistLines, localNamesLines []string,
 	unboundDir, cacertsPath, username string) (
 	lines []string) {
 	const (
@@ -44,11 +57,16 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 	if settings.IPv6 {
 		ipv6 = yes
 	}
+	logReplies := no
+	if settings.LogReplies {
+		logReplies = yes
+	}
 	serverLines := []string{
 		// Logging
 		"verbosity: " + strconv.Itoa(int(settings.VerbosityLevel)),
 		"val-log-level: " + strconv.Itoa(int(settings.ValidationLogLevel)),
 		"use-syslog: no",
+		"log-replies: " + logReplies,
 		// Performance
 		"num-threads: 2",
 		"prefetch: yes",
@@ -82,6 +100,11 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 		`include: "` + filepath.Join(unboundDir, includeConfFilename) + `"`,
 	}
 
+	if settings.RemoteControl {
+		// Statistics by query type, response code and memory usage
+		serverLines = append(serverLines, "extended-statistics: yes")
+	}
+
 	// Access control
 	for _, subnet := range settings.AccessControl.Allowed {
 		line := "access-control: " + subnet.String() + " allow"
@@ -94,10 +117,12 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 	})
 
 	blacklistLines = ensureIndentLines(blacklistLines)
+	localNamesLines = ensureIndentLines(localNamesLines)
 
 	lines = append(lines, "server:")
 	lines = append(lines, serverLines...)
 	lines = append(lines, blacklistLines...)
+	lines = append(lines, localNamesLines...)
 
 	// Forward zone
 	lines = append(lines, "forward-zone:")
@@ -128,6 +153,9 @@ func generateUnboundConf(settings Settings, blacklistLines []string,
 	forwardZoneLines = ensureIndentLines(forwardZoneLines)
 
 	lines = append(lines, forwardZoneLines...)
+
+	lines = append(lines, convertRemoteControlToConfigLines(settings.RemoteControl, unboundDir)...)
+	lines = append(lines, convertDnstapToConfigLines(settings.DnstapSocket)...)
 	return lines
 }
 
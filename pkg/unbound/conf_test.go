@@ -32,6 +32,9 @@ func Test_generateUnboundConf(t *testing.T) {
 			"  private-address: c",
 			"  private-address: d",
 		},
+		[]string{
+			`  local-data: "nas.lan. A 192.168.1.2"`,
+		},
 		"/unbound",
 		"/unbound/ca-certificates.crt",
 		"user",
@@ -52,6 +55,7 @@ server:
   interface: 0.0.0.0
   key-cache-size: 32m
   key-cache-slabs: 4
+  log-replies: no
   msg-cache-size: 8m
   msg-cache-slabs: 4
   num-threads: 2
@@ -72,6 +76,7 @@ server:
   local-zone: "c" static
   private-address: c
   private-address: d
+  local-data: "nas.lan. A 192.168.1.2"
 forward-zone:
   forward-no-cache: yes
   forward-tls-upstream: yes
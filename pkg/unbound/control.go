@@ -0,0 +1,168 @@
+package unbound
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	controlInterface      = "127.0.0.1"
+	controlPort           = 8953
+	serverKeyFilename     = "unbound_server.key"
+	serverCertFilename    = "unbound_server.pem"
+	controlKeyFilename    = "unbound_control.key"
+	controlCertFilename   = "unbound_control.pem"
+	controlKeyBits        = 3072
+	controlCertValidity   = 7200 * 24 * time.Hour
+	controlServerName     = "unbound"
+	controlClientName     = "unbound-control"
+	controlFilePermission = 0600
+)
+
+func convertRemoteControlToConfigLines(enabled bool, unboundDir string) (configLines []string) {
+	if !enabled {
+		return nil
+	}
+
+	return []string{
+		"remote-control:",
+		"  control-enable: yes",
+		"  control-interface: " + controlInterface,
+		"  control-port: " + strconv.Itoa(controlPort),
+		`  server-key-file: "` + filepath.Join(unboundDir, serverKeyFilename) + `"`,
+		`  server-cert-file: "` + filepath.Join(unboundDir, serverCertFilename) + `"`,
+		`  control-key-file: "` + filepath.Join(unboundDir, controlKeyFilename) + `"`,
+		`  control-cert-file: "` + filepath.Join(unboundDir, controlCertFilename) + `"`,
+	}
+}
+
+// control runs unbound-control with the arguments given
+// and returns its output. Remote control must be enabled.
+func (c *configurator) control(ctx context.Context, args ...string) (output string, err error) {
+	configFilepath := filepath.Join(c.unboundEtcDir, unboundConfigFilename)
+	args = append([]string{"-c", configFilepath}, args...)
+	cmd := exec.CommandContext(ctx, c.unboundControlPath, args...) //nolint:gosec
+	return c.cmder.Run(cmd)
+}
+
+// FlushCache removes all the entries from the Unbound cache.
+func (c *configurator) FlushCache(ctx context.Context) (err error) {
+	_, err = c.control(ctx, "flush_zone", ".")
+	if err != nil {
+		return fmt.Errorf("cannot flush unbound cache: %w", err)
+	}
+	return nil
+}
+
+// FlushName removes the entries for the name given from the Unbound
+// cache, for the A, AAAA, NS, SOA, CNAME, DNAME, MX, PTR, SRV and
+// NAPTR record types.
+func (c *configurator) FlushName(ctx context.Context, name string) (err error) {
+	_, err = c.control(ctx, "flush", name)
+	if err != nil {
+		return fmt.Errorf("cannot flush %s from unbound cache: %w", name, err)
+	}
+	return nil
+}
+
+// setupControlKeys generates the server and control keys and certificates
+// used by Unbound and unbound-control to authenticate each other, the same
+// way unbound-control-setup does. The control certificate is signed by the
+// self-signed server certificate. Nothing is done if all the files exist.
+func (c *configurator) setupControlKeys() (err error) {
+	filenames := []string{serverKeyFilename, serverCertFilename,
+		controlKeyFilename, controlCertFilename}
+	allExist := true
+	for _, filename := range filenames {
+		_, err := os.Stat(filepath.Join(c.unboundEtcDir, filename))
+		if os.IsNotExist(err) {
+			allExist = false
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+	if allExist {
+		return nil
+	}
+
+	serverKey, err := rsa.GenerateKey(rand.Reader, controlKeyBits)
+	if err != nil {
+		return fmt.Errorf("cannot generate server key: %w", err)
+	}
+	serverTemplate, err := newCertificateTemplate(controlServerName)
+	if err != nil {
+		return err
+	}
+	serverTemplate.IsCA = true
+	serverTemplate.KeyUsage |= x509.KeyUsageCertSign
+	// client auth usage for the control certificate it signs
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{
+		x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	serverCertDER, err := x509.CreateCertificate(rand.Reader,
+		serverTemplate, serverTemplate, &serverKey.PublicKey, serverKey)
+	if err != nil {
+		return fmt.Errorf("cannot create server certificate: %w", err)
+	}
+
+	controlKey, err := rsa.GenerateKey(rand.Reader, controlKeyBits)
+	if err != nil {
+		return fmt.Errorf("cannot generate control key: %w", err)
+	}
+	controlTemplate, err := newCertificateTemplate(controlClientName)
+	if err != nil {
+		return err
+	}
+	controlTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	controlCertDER, err := x509.CreateCertificate(rand.Reader,
+		controlTemplate, serverTemplate, &controlKey.PublicKey, serverKey)
+	if err != nil {
+		return fmt.Errorf("cannot create control certificate: %w", err)
+	}
+
+	blocks := map[string]*pem.Block{
+		serverKeyFilename:   {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(serverKey)},
+		serverCertFilename:  {Type: "CERTIFICATE", Bytes: serverCertDER},
+		controlKeyFilename:  {Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(controlKey)},
+		controlCertFilename: {Type: "CERTIFICATE", Bytes: controlCertDER},
+	}
+	for _, filename := range filenames {
+		path := filepath.Join(c.unboundEtcDir, filename)
+		err := os.WriteFile(path, pem.EncodeToMemory(blocks[filename]), controlFilePermission)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newCertificateTemplate(commonName string) (template *x509.Certificate, err error) {
+	const serialNumberBits = 128
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialNumberBits))
+	if err != nil {
+		return nil, fmt.Errorf("cannot generate certificate serial number: %w", err)
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(controlCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}, nil
+}
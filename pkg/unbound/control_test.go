@@ -0,0 +1,86 @@
+package unbound
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_convertRemoteControlToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		enabled     bool
+		configLines []string
+	}{
+		"disabled": {},
+		"enabled": {
+			enabled: true,
+			configLines: []string{
+				"remote-control:",
+				"  control-enable: yes",
+				"  control-interface: 127.0.0.1",
+				"  control-port: 8953",
+				`  server-key-file: "/unbound/unbound_server.key"`,
+				`  server-cert-file: "/unbound/unbound_server.pem"`,
+				`  control-key-file: "/unbound/unbound_control.key"`,
+				`  control-cert-file: "/unbound/unbound_control.pem"`,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			configLines := convertRemoteControlToConfigLines(tc.enabled, "/unbound")
+			assert.Equal(t, tc.configLines, configLines)
+		})
+	}
+}
+
+func Test_setupControlKeys(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	c := &configurator{unboundEtcDir: dir}
+
+	err := c.setupControlKeys()
+	require.NoError(t, err)
+
+	path := func(filename string) string { return filepath.Join(dir, filename) }
+
+	serverPair, err := tls.LoadX509KeyPair(path(serverCertFilename), path(serverKeyFilename))
+	require.NoError(t, err)
+	controlPair, err := tls.LoadX509KeyPair(path(controlCertFilename), path(controlKeyFilename))
+	require.NoError(t, err)
+
+	serverCert, err := x509.ParseCertificate(serverPair.Certificate[0])
+	require.NoError(t, err)
+	controlCert, err := x509.ParseCertificate(controlPair.Certificate[0])
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert)
+	_, err = serverCert.Verify(x509.VerifyOptions{DNSName: "unbound", Roots: roots})
+	assert.NoError(t, err)
+	_, err = controlCert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	assert.NoError(t, err)
+
+	// Existing keys are kept
+	serverKeyPEM, err := os.ReadFile(path(serverKeyFilename))
+	require.NoError(t, err)
+	err = c.setupControlKeys()
+	require.NoError(t, err)
+	newServerKeyPEM, err := os.ReadFile(path(serverKeyFilename))
+	require.NoError(t, err)
+	assert.Equal(t, serverKeyPEM, newServerKeyPEM)
+}
@@ -11,9 +11,13 @@ import (
 type Configurator interface {
 	SetupFiles(ctx context.Context) error
 	MakeUnboundConf(settings Settings) (err error)
+	UnboundConf(settings Settings) (conf string)
 	Start(ctx context.Context, verbosityDetailsLevel uint8) (
 		stdoutLines, stderrLines chan string, waitError chan error, err error)
 	Version(ctx context.Context) (version string, err error)
+	Statistics(ctx context.Context) (stats Statistics, err error)
+	FlushCache(ctx context.Context) (err error)
+	FlushName(ctx context.Context, name string) (err error)
 }
 
 type configurator struct {
@@ -21,17 +25,21 @@ type configurator struct {
 	dnscrypto     dnscrypto.DNSCrypto
 	unboundEtcDir string
 	unboundPath   string
-	cacertsPath   string
+	// unboundControlPath is the path to the unbound-control
+	// program used to query Unbound statistics.
+	unboundControlPath string
+	cacertsPath        string
 }
 
 func NewConfigurator(logger logging.Logger,
 	cmder command.RunStarter, dnscrypto dnscrypto.DNSCrypto,
-	unboundEtcDir, unboundPath, cacertsPath string) Configurator {
+	unboundEtcDir, unboundPath, unboundControlPath, cacertsPath string) Configurator {
 	return &configurator{
-		cmder:         cmder,
-		dnscrypto:     dnscrypto,
-		unboundEtcDir: unboundEtcDir,
-		unboundPath:   unboundPath,
-		cacertsPath:   cacertsPath,
+		cmder:              cmder,
+		dnscrypto:          dnscrypto,
+		unboundEtcDir:      unboundEtcDir,
+		unboundPath:        unboundPath,
+		unboundControlPath: unboundControlPath,
+		cacertsPath:        cacertsPath,
 	}
 }
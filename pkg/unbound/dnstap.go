@@ -0,0 +1,19 @@
+package unbound
+
+func convertDnstapToConfigLines(socketPath string) (configLines []string) {
+	if socketPath == "" {
+		return nil
+	}
+
+	return []string{
+		"dnstap:",
+		"  dnstap-enable: yes",
+		`  dnstap-socket-path: "` + socketPath + `"`,
+		"  dnstap-send-identity: yes",
+		"  dnstap-send-version: yes",
+		"  dnstap-log-client-query-messages: yes",
+		"  dnstap-log-client-response-messages: yes",
+		"  dnstap-log-forwarder-query-messages: yes",
+		"  dnstap-log-forwarder-response-messages: yes",
+	}
+}
@@ -0,0 +1,41 @@
+package unbound
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_convertDnstapToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		socketPath  string
+		configLines []string
+	}{
+		"disabled": {},
+		"socket": {
+			socketPath: "/dnstap/dnstap.sock",
+			configLines: []string{
+				"dnstap:",
+				"  dnstap-enable: yes",
+				`  dnstap-socket-path: "/dnstap/dnstap.sock"`,
+				"  dnstap-send-identity: yes",
+				"  dnstap-send-version: yes",
+				"  dnstap-log-client-query-messages: yes",
+				"  dnstap-log-client-response-messages: yes",
+				"  dnstap-log-forwarder-query-messages: yes",
+				"  dnstap-log-forwarder-response-messages: yes",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			configLines := convertDnstapToConfigLines(tc.socketPath)
+			assert.Equal(t, tc.configLines, configLines)
+		})
+	}
+}
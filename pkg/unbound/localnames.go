@@ -0,0 +1,31 @@
+package unbound
+
+import (
+	"strings"
+
+	"github.com/miekg/dns"
+	"github.com/qdm12/dns/pkg/hosts"
+)
+
+func convertLocalNamesToConfigLines(domain string, records []hosts.Record) (configLines []string) {
+	configLines = make([]string, 0, 1+2*len(records)) //nolint:gomnd
+
+	domain = strings.Trim(strings.ToLower(domain), ".")
+	if domain != "" {
+		configLines = append(configLines, "  local-zone: \""+dns.Fqdn(domain)+"\" static")
+	}
+
+	for _, record := range records {
+		for _, ip := range record.IPs {
+			recordType := "A"
+			if !ip.Is4() {
+				recordType = "AAAA"
+			}
+			configLines = append(configLines,
+				"  local-data: \""+record.FqdnHostname+" "+recordType+" "+ip.String()+"\"",
+				"  local-data-ptr: \""+ip.String()+" "+record.FqdnHostname+"\"")
+		}
+	}
+
+	return configLines
+}
@@ -0,0 +1,52 @@
+package unbound
+
+import (
+	"testing"
+
+	"github.com/qdm12/dns/pkg/hosts"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_convertLocalNamesToConfigLines(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		domain      string
+		records     []hosts.Record
+		configLines []string
+	}{
+		"none": {
+			configLines: []string{},
+		},
+		"domain and records": {
+			domain: "LAN.",
+			records: []hosts.Record{
+				{
+					FqdnHostname: "nas.lan.",
+					IPs: []netaddr.IP{
+						netaddr.IPv4(192, 168, 1, 2),
+						netaddr.MustParseIP("fd00::2"),
+					},
+				},
+			},
+			configLines: []string{
+				"  local-zone: \"lan.\" static",
+				"  local-data: \"nas.lan. A 192.168.1.2\"",
+				"  local-data-ptr: \"192.168.1.2 nas.lan.\"",
+				"  local-data: \"nas.lan. AAAA fd00::2\"",
+				"  local-data-ptr: \"fd00::2 nas.lan.\"",
+			},
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			configLines := convertLocalNamesToConfigLines(tc.domain, tc.records)
+
+			assert.Equal(t, tc.configLines, configLines)
+		})
+	}
+}
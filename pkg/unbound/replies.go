@@ -0,0 +1,53 @@
+package unbound
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qdm12/dns/pkg/querylog"
+	"inet.af/netaddr"
+)
+
+// ParseReplyLine parses a reply line logged by Unbound when
+// LogReplies is enabled, in the format
+// `[1626950000] unbound[1:0] info: 127.0.0.1 github.com. A IN NOERROR 0.012345 0 55`,
+// where the last three fields are the latency in seconds, whether
+// the reply came from the cache and the reply size in bytes.
+// It returns false if the line is not a reply line.
+func ParseReplyLine(line string) (entry querylog.Entry, ok bool) {
+	const infoMarker = " info: "
+	i := strings.Index(line, infoMarker)
+	if i < 0 {
+		return entry, false
+	}
+
+	fields := strings.Fields(line[i+len(infoMarker):])
+	const replyFields = 8
+	if len(fields) != replyFields {
+		return entry, false
+	}
+
+	clientIP, err := netaddr.ParseIP(fields[0])
+	if err != nil {
+		return entry, false
+	}
+
+	seconds, err := strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return entry, false
+	}
+	latency := time.Duration(seconds * float64(time.Second))
+
+	entry = querylog.Entry{
+		Time:     time.Now().Add(-latency),
+		ClientIP: clientIP,
+		Name:     fields[1],
+		Type:     fields[2],
+		Rcode:    fields[4],
+		Latency:  latency,
+		Cached:   fields[6] == "1",
+	}
+
+	return entry, true
+}
@@ -0,0 +1,56 @@
+package unbound
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qdm12/dns/pkg/querylog"
+	"github.com/stretchr/testify/assert"
+	"inet.af/netaddr"
+)
+
+func Test_ParseReplyLine(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		line  string
+		entry querylog.Entry
+		ok    bool
+	}{
+		"empty": {},
+		"other info line": {
+			line: "[1626950000] unbound[1:0] info: start of service (unbound 1.13.1).",
+		},
+		"bad client IP": {
+			line: "[1626950000] unbound[1:0] info: x github.com. A IN NOERROR 0.012000 0 55",
+		},
+		"reply": {
+			line: "[1626950000] unbound[1:0] info: 172.17.0.1 github.com. AAAA IN NOERROR 0.012000 1 55",
+			entry: querylog.Entry{
+				ClientIP: netaddr.IPv4(172, 17, 0, 1),
+				Name:     "github.com.",
+				Type:     "AAAA",
+				Rcode:    "NOERROR",
+				Latency:  12 * time.Millisecond,
+				Cached:   true,
+			},
+			ok: true,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, ok := ParseReplyLine(testCase.line)
+
+			assert.Equal(t, testCase.ok, ok)
+			if ok {
+				assert.WithinDuration(t, time.Now(), entry.Time, time.Second)
+			}
+			entry.Time = time.Time{}
+			assert.Equal(t, testCase.entry, entry)
+		})
+	}
+}
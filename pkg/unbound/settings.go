@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/qdm12/dns/pkg/blacklist"
+	"github.com/qdm12/dns/pkg/hosts"
 	"github.com/qdm12/dns/pkg/provider"
 	"inet.af/netaddr"
 )
@@ -27,6 +28,19 @@ type Settings struct {
 	AccessControl         AccessControlSettings
 	Username              string
 	Blacklist             blacklist.Settings
+	LocalDomain           string
+	LocalNames            []hosts.Record
+	// LogReplies makes Unbound log a line for each reply,
+	// which can be parsed with ParseReplyLine.
+	LogReplies bool
+	// DnstapSocket is the path of a unix socket for Unbound to
+	// send dnstap messages to. It defaults to the empty string,
+	// meaning dnstap is disabled.
+	DnstapSocket string
+	// RemoteControl enables the Unbound remote control on
+	// 127.0.0.1:8953 so statistics can be queried with
+	// unbound-control. The control keys are generated if needed.
+	RemoteControl bool
 }
 
 func (s *Settings) String() string {
@@ -82,8 +96,33 @@ func (s *Settings) Lines() (lines []string) {
 	lines = append(lines, subIndent+
 		"Validation log level: "+strconv.Itoa(int(s.ValidationLogLevel))+"/2")
 
+	logReplies := disabled
+	if s.LogReplies {
+		logReplies = enabled
+	}
+	lines = append(lines, subIndent+"Log replies: "+logReplies)
+
+	remoteControl := disabled
+	if s.RemoteControl {
+		remoteControl = enabled
+	}
+	lines = append(lines, subIndent+"Remote control: "+remoteControl)
+
+	if s.DnstapSocket != "" {
+		lines = append(lines, subIndent+"Dnstap socket: "+s.DnstapSocket)
+	}
+
 	lines = append(lines, subIndent+"Username: "+s.Username)
 
+	if s.LocalDomain != "" {
+		lines = append(lines, subIndent+"Local domain: "+s.LocalDomain)
+	}
+
+	if len(s.LocalNames) > 0 {
+		lines = append(lines, subIndent+
+			"Local names: "+strconv.Itoa(len(s.LocalNames)))
+	}
+
 	return lines
 }
 
@@ -26,6 +26,8 @@ func Test_Settings_Lines(t *testing.T) {
 				" |--Verbosity level: 0/5",
 				" |--Verbosity details level: 0/4",
 				" |--Validation log level: 0/2",
+				" |--Log replies: disabled",
+				" |--Remote control: disabled",
 				" |--Username: ",
 			},
 		},
@@ -42,6 +44,8 @@ func Test_Settings_Lines(t *testing.T) {
 				VerbosityLevel:        1,
 				VerbosityDetailsLevel: 2,
 				ValidationLogLevel:    3,
+				LogReplies:            true,
+				RemoteControl:         true,
 				AccessControl: AccessControlSettings{
 					Allowed: []netaddr.IPPrefix{{IP: netaddr.IPv4(0, 0, 0, 0)}},
 				},
@@ -61,6 +65,8 @@ func Test_Settings_Lines(t *testing.T) {
 				" |--Verbosity level: 1/5",
 				" |--Verbosity details level: 2/4",
 				" |--Validation log level: 3/2",
+				" |--Log replies: enabled",
+				" |--Remote control: enabled",
 				" |--Username: username",
 			},
 		},
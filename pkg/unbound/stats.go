@@ -0,0 +1,128 @@
+package unbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statistics are the Unbound statistics obtained
+// with `unbound-control stats_noreset`.
+type Statistics struct {
+	// Queries is the number of queries received.
+	Queries uint64
+	// CacheHits is the number of queries answered from the cache.
+	CacheHits uint64
+	// CacheMisses is the number of queries needing recursion.
+	CacheMisses uint64
+	// Prefetches is the number of cache prefetches performed.
+	Prefetches uint64
+	// ExpiredAnswers is the number of expired answers served.
+	ExpiredAnswers uint64
+	// RecursionTimeAverage is the average time to answer
+	// queries needing recursion.
+	RecursionTimeAverage time.Duration
+	// RecursionTimeMedian is the median time to answer
+	// queries needing recursion.
+	RecursionTimeMedian time.Duration
+	// Uptime is the duration since Unbound started.
+	Uptime time.Duration
+	// QueriesByType maps query types such as A
+	// to their number of queries received.
+	QueriesByType map[string]uint64
+	// AnswersByRcode maps response codes such as NXDOMAIN
+	// to their number of answers sent.
+	AnswersByRcode map[string]uint64
+	// MemoryBytes maps memory usage kinds such
+	// as cache.rrset to their size in bytes.
+	MemoryBytes map[string]uint64
+}
+
+func (c *configurator) Statistics(ctx context.Context) (stats Statistics, err error) {
+	output, err := c.control(ctx, "stats_noreset")
+	if err != nil {
+		return stats, fmt.Errorf("unbound statistics: %w", err)
+	}
+
+	return parseStatistics(output)
+}
+
+var ErrStatisticMalformed = errors.New("statistic is malformed")
+
+const (
+	queryTypePrefix   = "num.query.type."
+	answerRcodePrefix = "num.answer.rcode."
+	memoryPrefix      = "mem."
+)
+
+// parseStatistics parses the `name=value` lines output
+// by unbound-control stats. Unknown statistics are ignored.
+func parseStatistics(output string) (stats Statistics, err error) {
+	stats = Statistics{
+		QueriesByType:  make(map[string]uint64),
+		AnswersByRcode: make(map[string]uint64),
+		MemoryBytes:    make(map[string]uint64),
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		const parts = 2
+		fields := strings.SplitN(line, "=", parts)
+		if len(fields) != parts {
+			return stats, fmt.Errorf("%w: %s", ErrStatisticMalformed, line)
+		}
+		name, value := fields[0], fields[1]
+
+		switch {
+		case name == "total.num.queries":
+			stats.Queries, err = parseCounter(value)
+		case name == "total.num.cachehits":
+			stats.CacheHits, err = parseCounter(value)
+		case name == "total.num.cachemiss":
+			stats.CacheMisses, err = parseCounter(value)
+		case name == "total.num.prefetch":
+			stats.Prefetches, err = parseCounter(value)
+		case name == "total.num.expired":
+			stats.ExpiredAnswers, err = parseCounter(value)
+		case name == "total.recursion.time.avg":
+			stats.RecursionTimeAverage, err = parseSeconds(value)
+		case name == "total.recursion.time.median":
+			stats.RecursionTimeMedian, err = parseSeconds(value)
+		case name == "time.up":
+			stats.Uptime, err = parseSeconds(value)
+		case strings.HasPrefix(name, queryTypePrefix):
+			queryType := strings.TrimPrefix(name, queryTypePrefix)
+			stats.QueriesByType[queryType], err = parseCounter(value)
+		case strings.HasPrefix(name, answerRcodePrefix):
+			rcode := strings.TrimPrefix(name, answerRcodePrefix)
+			stats.AnswersByRcode[rcode], err = parseCounter(value)
+		case strings.HasPrefix(name, memoryPrefix):
+			kind := strings.TrimPrefix(name, memoryPrefix)
+			stats.MemoryBytes[kind], err = parseCounter(value)
+		}
+		if err != nil {
+			return stats, fmt.Errorf("%w: %s: %s", ErrStatisticMalformed, line, err)
+		}
+	}
+
+	return stats, nil
+}
+
+func parseCounter(value string) (counter uint64, err error) {
+	return strconv.ParseUint(value, 10, 64) //nolint:gomnd
+}
+
+func parseSeconds(value string) (duration time.Duration, err error) {
+	seconds, err := strconv.ParseFloat(value, 64) //nolint:gomnd
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,167 @@
+package unbound
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/qdm12/golibs/command"
+	"github.com/qdm12/golibs/command/mock_command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const statsOutput = `thread0.num.queries=10
+total.num.queries=12
+total.num.queries_ip_ratelimited=0
+total.num.cachehits=9
+total.num.cachemiss=3
+total.num.prefetch=1
+total.num.expired=2
+total.num.recursivereplies=3
+total.requestlist.avg=0
+total.recursion.time.avg=0.046250
+total.recursion.time.median=0.032
+time.now=1626950000.123456
+time.up=123.5
+mem.cache.rrset=66488
+mem.cache.message=66428
+num.query.type.A=8
+num.query.type.AAAA=4
+num.query.class.IN=12
+num.answer.rcode.NOERROR=11
+num.answer.rcode.NXDOMAIN=1
+`
+
+func Test_Statistics(t *testing.T) {
+	t.Parallel()
+
+	errDummy := errors.New("dummy")
+
+	testCases := map[string]struct {
+		runOutput  string
+		runErr     error
+		stats      Statistics
+		errWrapped error
+		errMessage string
+	}{
+		"run error": {
+			runErr:     errDummy,
+			errWrapped: errDummy,
+			errMessage: "unbound statistics: dummy",
+		},
+		"success": {
+			runOutput: statsOutput,
+			stats: Statistics{
+				Queries:              12,
+				CacheHits:            9,
+				CacheMisses:          3,
+				Prefetches:           1,
+				ExpiredAnswers:       2,
+				RecursionTimeAverage: 46250 * time.Microsecond,
+				RecursionTimeMedian:  32 * time.Millisecond,
+				Uptime:               123500 * time.Millisecond,
+				QueriesByType:        map[string]uint64{"A": 8, "AAAA": 4},
+				AnswersByRcode:       map[string]uint64{"NOERROR": 11, "NXDOMAIN": 1},
+				MemoryBytes:          map[string]uint64{"cache.rrset": 66488, "cache.message": 66428},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctrl := gomock.NewController(t)
+
+			cmder := mock_command.NewMockRunStarter(ctrl)
+			cmder.EXPECT().Run(gomock.Any()).
+				DoAndReturn(func(cmd command.ExecCmd) (string, error) {
+					execCmd, ok := cmd.(*exec.Cmd)
+					require.True(t, ok)
+					expectedArgs := []string{"/unbound/unbound-control",
+						"-c", "/unbound/unbound.conf", "stats_noreset"}
+					assert.Equal(t, expectedArgs, execCmd.Args)
+					return testCase.runOutput, testCase.runErr
+				})
+
+			c := &configurator{
+				cmder:              cmder,
+				unboundEtcDir:      "/unbound",
+				unboundControlPath: "/unbound/unbound-control",
+			}
+
+			stats, err := c.Statistics(context.Background())
+
+			assert.True(t, errors.Is(err, testCase.errWrapped))
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.stats, stats)
+		})
+	}
+}
+
+func Test_parseStatistics(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		output     string
+		stats      Statistics
+		errWrapped error
+		errMessage string
+	}{
+		"empty output": {
+			stats: Statistics{
+				QueriesByType:  map[string]uint64{},
+				AnswersByRcode: map[string]uint64{},
+				MemoryBytes:    map[string]uint64{},
+			},
+		},
+		"missing equal sign": {
+			output:     "total.num.queries 12\n",
+			errWrapped: ErrStatisticMalformed,
+			errMessage: "statistic is malformed: total.num.queries 12",
+		},
+		"malformed counter": {
+			output:     "total.num.queries=x\n",
+			errWrapped: ErrStatisticMalformed,
+			errMessage: `statistic is malformed: total.num.queries=x: ` +
+				`strconv.ParseUint: parsing "x": invalid syntax`,
+		},
+		"malformed duration": {
+			output:     "time.up=x\n",
+			errWrapped: ErrStatisticMalformed,
+			errMessage: `statistic is malformed: time.up=x: ` +
+				`strconv.ParseFloat: parsing "x": invalid syntax`,
+		},
+		"unknown statistics ignored": {
+			output: "total.requestlist.avg=x\nnum.query.type.A=1\n",
+			stats: Statistics{
+				QueriesByType:  map[string]uint64{"A": 1},
+				AnswersByRcode: map[string]uint64{},
+				MemoryBytes:    map[string]uint64{},
+			},
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			stats, err := parseStatistics(testCase.output)
+
+			assert.True(t, errors.Is(err, testCase.errWrapped))
+			if testCase.errWrapped != nil {
+				assert.EqualError(t, err, testCase.errMessage)
+				return
+			}
+			assert.Equal(t, testCase.stats, stats)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package warmup
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Settings struct {
+	// Domains are the domains to resolve into the cache, each
+	// optionally followed by space separated record types, for
+	// example "github.com A AAAA". The record types default to
+	// A and AAAA if none is given.
+	Domains []string
+	// File is the path to a file listing domains to resolve into
+	// the cache, one per line, in the same format as Domains.
+	// Text after a # is ignored. It defaults to the empty string,
+	// meaning no file is read.
+	File string
+	// Concurrency is the maximum number of domains
+	// resolved at the same time. It defaults to 10.
+	Concurrency int
+}
+
+func (s *Settings) SetDefaults() {
+	if s.Concurrency == 0 {
+		const defaultConcurrency = 10
+		s.Concurrency = defaultConcurrency
+	}
+}
+
+// Enabled returns true if there are domains or a domains file set.
+func (s *Settings) Enabled() bool {
+	return len(s.Domains) > 0 || s.File != ""
+}
+
+func (s *Settings) String() string {
+	const (
+		subSection = " |--"
+		indent     = "    " // used if lines already contain the subSection
+	)
+	return strings.Join(s.Lines(indent, subSection), "\n")
+}
+
+func (s *Settings) Lines(indent, subSection string) (lines []string) {
+	if !s.Enabled() {
+		return []string{subSection + "Cache warm-up: disabled"}
+	}
+
+	lines = append(lines, subSection+"Cache warm-up:")
+
+	if len(s.Domains) > 0 {
+		lines = append(lines, indent+subSection+"Domains: "+
+			strconv.Itoa(len(s.Domains)))
+	}
+
+	if s.File != "" {
+		lines = append(lines, indent+subSection+"Domains file: "+s.File)
+	}
+
+	lines = append(lines, indent+subSection+"Concurrency: "+
+		strconv.Itoa(s.Concurrency))
+
+	return lines
+}
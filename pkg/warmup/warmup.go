@@ -0,0 +1,136 @@
+// Package warmup resolves a list of commonly used domains
+// into the cache of a DNS server, so their first queries
+// are answered from the cache.
+package warmup
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+)
+
+var ErrRecordTypeUnknown = errors.New("record type is unknown")
+
+// Questions returns the questions to resolve from the domains
+// and the domains file of the settings.
+func Questions(settings Settings) (questions []dns.Question, err error) {
+	for _, line := range settings.Domains {
+		lineQuestions, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, lineQuestions...)
+	}
+
+	if settings.File == "" {
+		return questions, nil
+	}
+
+	file, err := os.Open(settings.File)
+	if err != nil {
+		return nil, err
+	}
+
+	fileQuestions, err := parse(file)
+	_ = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("domains file %s: %w", settings.File, err)
+	}
+
+	return append(questions, fileQuestions...), nil
+}
+
+func parse(reader io.Reader) (questions []dns.Question, err error) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		lineQuestions, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, lineQuestions...)
+	}
+	return questions, scanner.Err()
+}
+
+// parseLine parses a line in the format `domain [type...]`,
+// and returns no question if the line is empty.
+func parseLine(line string) (questions []dns.Question, err error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	name := dns.Fqdn(strings.ToLower(fields[0]))
+	typeStrings := fields[1:]
+	if len(typeStrings) == 0 {
+		typeStrings = []string{"A", "AAAA"}
+	}
+
+	questions = make([]dns.Question, len(typeStrings))
+	for i, typeString := range typeStrings {
+		qType, ok := dns.StringToType[strings.ToUpper(typeString)]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrRecordTypeUnknown, typeString)
+		}
+		questions[i] = dns.Question{Name: name, Qtype: qType, Qclass: dns.ClassINET}
+	}
+	return questions, nil
+}
+
+// ResolveFunc resolves the request into the cache.
+type ResolveFunc func(request *dns.Msg) (err error)
+
+// Run resolves the questions using the resolve function given, with
+// at most concurrency resolutions running at the same time.
+// It returns an error for each question which cannot be resolved,
+// and stops early if the context is canceled.
+func Run(ctx context.Context, questions []dns.Question,
+	concurrency int, resolve ResolveFunc) (errs []error) {
+	slots := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+	errsMutex := &sync.Mutex{}
+
+	for _, question := range questions {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return append(errs, ctx.Err())
+		}
+
+		wg.Add(1)
+		go func(question dns.Question) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			request := new(dns.Msg)
+			request.SetQuestion(question.Name, question.Qtype)
+			err := resolve(request)
+			if err == nil {
+				return
+			}
+
+			errsMutex.Lock()
+			defer errsMutex.Unlock()
+			errs = append(errs, fmt.Errorf("cannot warm up %s %s: %w",
+				question.Name, dns.TypeToString[question.Qtype], err))
+		}(question)
+	}
+
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,117 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Questions(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		domains     []string
+		fileContent string
+		questions   []dns.Question
+		errWrapped  error
+	}{
+		"empty": {},
+		"domains and file": {
+			domains: []string{"GitHub.com", "example.com mx"},
+			fileContent: `# common domains
+google.com A # search
+
+cloudflare.com. AAAA HTTPS
+`,
+			questions: []dns.Question{
+				{Name: "github.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+				{Name: "github.com.", Qtype: dns.TypeAAAA, Qclass: dns.ClassINET},
+				{Name: "example.com.", Qtype: dns.TypeMX, Qclass: dns.ClassINET},
+				{Name: "google.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+				{Name: "cloudflare.com.", Qtype: dns.TypeAAAA, Qclass: dns.ClassINET},
+				{Name: "cloudflare.com.", Qtype: dns.TypeHTTPS, Qclass: dns.ClassINET},
+			},
+		},
+		"unknown record type": {
+			domains:    []string{"github.com B"},
+			errWrapped: ErrRecordTypeUnknown,
+		},
+	}
+
+	for name, testCase := range testCases {
+		testCase := testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			settings := Settings{Domains: testCase.domains}
+			if testCase.fileContent != "" {
+				settings.File = filepath.Join(t.TempDir(), "domains")
+				const perms = 0600
+				err := os.WriteFile(settings.File, []byte(testCase.fileContent), perms)
+				require.NoError(t, err)
+			}
+
+			questions, err := Questions(settings)
+
+			if testCase.errWrapped != nil {
+				assert.True(t, errors.Is(err, testCase.errWrapped))
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, testCase.questions, questions)
+		})
+	}
+}
+
+func Test_Run(t *testing.T) {
+	t.Parallel()
+
+	questions := []dns.Question{
+		{Name: "a.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+		{Name: "b.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+		{Name: "c.com.", Qtype: dns.TypeA, Qclass: dns.ClassINET},
+		{Name: "d.com.", Qtype: dns.TypeAAAA, Qclass: dns.ClassINET},
+	}
+	const concurrency = 2
+
+	errTest := errors.New("test error")
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	resolved := make(map[string]struct{})
+	resolve := func(request *dns.Msg) (err error) {
+		mutex.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		resolved[request.Question[0].Name] = struct{}{}
+		mutex.Unlock()
+
+		defer func() {
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+		}()
+
+		if request.Question[0].Name == "c.com." {
+			return errTest
+		}
+		return nil
+	}
+
+	errs := Run(context.Background(), questions, concurrency, resolve)
+
+	require.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], errTest))
+	assert.EqualError(t, errs[0], "cannot warm up c.com. A: test error")
+	assert.LessOrEqual(t, maxRunning, concurrency)
+	assert.Len(t, resolved, len(questions))
+}